@@ -0,0 +1,36 @@
+package cluster
+
+// constrainedNodes returns nodes satisfying affinity and anti-affinity of container.
+func (dcs *DefaultClusterService) constrainedNodes(nodes []*Node, container *Container) []*Node {
+	res := []*Node{}
+	for _, node := range nodes {
+		if dcs.satisfiesConstraints(node, container) {
+			res = append(res, node)
+		}
+	}
+	return res
+}
+
+// satisfiesConstraints returns true if node has labels of NodeAffinity, container tolerates taints of node,
+// and node has no other alive container matching AntiAffinity.
+func (dcs *DefaultClusterService) satisfiesConstraints(node *Node, container *Container) bool {
+	if !matchLabels(node.Labels, container.Spec.NodeAffinity) || !tolerates(container.Spec.Tolerations, node.Taints) {
+		return false
+	}
+	if len(container.Spec.AntiAffinity) == 0 {
+		return true
+	}
+	for _, c := range dcs.containersByNode[node.Id] {
+		if c.Id == container.Id {
+			continue
+		}
+		state := containerStateOf(c)
+		if state != ContainerCreated && state != ContainerRunning && state != ContainerPaused {
+			continue
+		}
+		if matchLabels(c.Labels, container.Spec.AntiAffinity) {
+			return false
+		}
+	}
+	return true
+}
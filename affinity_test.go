@@ -0,0 +1,65 @@
+package cluster
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDefaultClusterService_CreateContainerWithSpec_Affinity(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	client := NewFakeContainerClient("hash1")
+	cpu := runTestNode(t, clusterService, client)
+	gpu := func(node *Node) {
+		node.Client = client
+		node.Labels = map[string]string{"gpu": "true"}
+	}
+	prepareTestNode(t, clusterService, gpu)
+	prepareTestNode(t, clusterService, gpu)
+
+	spec := ContainerSpec{
+		NodeAffinity: map[string]string{"gpu": "true"},
+		AntiAffinity: map[string]string{"app": "train"},
+	}
+	placed := map[UID]bool{}
+	trains := Containers{}
+	for i := 0; i < 2; i++ {
+		container, err := clusterService.CreateContainerWithSpec(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		labelTestContainer(t, clusterService, container, map[string]string{"app": "train"})
+		trains = append(trains, container)
+		if container.NodeId == cpu.Id || placed[container.NodeId] {
+			t.Errorf("unexpected node:%v", container.NodeName)
+		}
+		placed[container.NodeId] = true
+	}
+
+	tests := []struct {
+		name     string
+		spec     ContainerSpec
+		expected error
+	}{
+		{"antiAffinity", spec, ErrUnsatisfiedConstraints},
+		{"nodeAffinity", ContainerSpec{NodeAffinity: map[string]string{"gpu": "false"}}, ErrUnsatisfiedConstraints},
+		{"noConstraint", ContainerSpec{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := clusterService.CreateContainerWithSpec(tt.spec); !errors.Is(err, tt.expected) {
+				t.Errorf("want:%v,have:%v", tt.expected, err)
+			}
+		})
+	}
+
+	// exited container does not conflict
+	if err := clusterService.RunContainer(trains[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.KillContainer(trains[0], 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := clusterService.CreateContainerWithSpec(spec); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,213 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Autoscaler wraps service to add a node when new container does not fit in running nodes,
+// and remove an idle node when utilization of running nodes drops, keeping number of nodes in bounds.
+type Autoscaler struct {
+	*DefaultClusterService
+	// bounds of number of nodes not exited
+	minNodes int
+	maxNodes int
+	// utilization of running nodes which removing a node must not exceed, 0 disables removing
+	targetUtilization float64
+	// set client, resource provider and capacity of new node before it runs
+	prepare func(node *Node) error
+	// serializes scaling not to exceed bounds
+	mu sync.Mutex
+}
+
+// NewAutoscaler create autoscaler of service. prepare is called with new node before it runs.
+// targetUtilization is ratio of allocated to capacity in [0, 1].
+func NewAutoscaler(service *DefaultClusterService, minNodes, maxNodes int, targetUtilization float64, prepare func(node *Node) error) (*Autoscaler, error) {
+	if minNodes < 0 || maxNodes < 1 || minNodes > maxNodes {
+		return nil, fmt.Errorf("invalid bounds, min:%v, max:%v", minNodes, maxNodes)
+	}
+	if targetUtilization < 0 || targetUtilization > 1 {
+		return nil, fmt.Errorf("invalid target utilization:%v", targetUtilization)
+	}
+	if prepare == nil {
+		return nil, errors.New("prepare required")
+	}
+	return &Autoscaler{
+		DefaultClusterService: service,
+		minNodes:              minNodes,
+		maxNodes:              maxNodes,
+		targetUtilization:     targetUtilization,
+		prepare:               prepare,
+	}, nil
+}
+
+// CreateContainer is CreateContainer of service, which adds a node and retries if no node fits container.
+func (a *Autoscaler) CreateContainer() (*Container, error) {
+	return a.createWithScaleUp(a.DefaultClusterService.CreateContainer)
+}
+
+// CreateContainerWithSpec is CreateContainerWithSpec of service, which adds a node and retries if no node fits container.
+func (a *Autoscaler) CreateContainerWithSpec(spec ContainerSpec) (*Container, error) {
+	return a.createWithScaleUp(func() (*Container, error) {
+		return a.DefaultClusterService.CreateContainerWithSpec(spec)
+	})
+}
+
+func (a *Autoscaler) createWithScaleUp(create func() (*Container, error)) (*Container, error) {
+	container, err := create()
+	if !errors.Is(err, ErrInsufficientCapacity) && !errors.Is(err, ErrNoValidNode) {
+		return container, err
+	}
+	if _, scaleErr := a.ScaleUp(context.Background()); scaleErr != nil {
+		return nil, fmt.Errorf("%w, failed to add node:%v", err, scaleErr)
+	}
+	return create()
+}
+
+// StartReconcileLoop is StartReconcileLoop of service, which also keeps number of nodes in bounds
+// and removes an idle node in each round.
+func (a *Autoscaler) StartReconcileLoop(ctx context.Context, interval time.Duration) <-chan struct{} {
+	return a.goLoop(ctx, func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				a.reconcileOnce(ctx)
+				a.autoscaleOnce(ctx)
+			}
+		}
+	})
+}
+
+// autoscaleOnce add nodes up to min, or remove an idle node. errors are retried in the next round.
+func (a *Autoscaler) autoscaleOnce(ctx context.Context) {
+	for a.activeNodes() < a.minNodes {
+		if _, err := a.ScaleUp(ctx); err != nil {
+			return
+		}
+	}
+	a.ScaleDown(ctx)
+}
+
+// ScaleUp create, prepare and run a node, returns ErrNodeLimitReached if there are max nodes.
+// node failed to run is removed.
+func (a *Autoscaler) ScaleUp(ctx context.Context) (*Node, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if active := a.activeNodes(); active >= a.maxNodes {
+		return nil, fmt.Errorf("%w, nodes:%v, max:%v", ErrNodeLimitReached, active, a.maxNodes)
+	}
+	dcs := a.DefaultClusterService
+	dcs.mu.Lock()
+	node, err := dcs.createNode(DefaultNamespace)
+	if err == nil {
+		err = a.prepare(node)
+	}
+	dcs.mu.Unlock()
+	if err == nil {
+		err = dcs.RunNodeContext(ctx, node)
+	}
+	if err != nil {
+		if node != nil {
+			dcs.RemoveNode(node.Id)
+		}
+		return nil, err
+	}
+	return dcs.GetNode(node.Id)
+}
+
+// ScaleDown kill and remove a running node without alive containers, if there are more than min nodes
+// and utilization stays under target without the node. it returns nil if no node is removed.
+// removed node is kept as exited if its exited containers are not removed.
+func (a *Autoscaler) ScaleDown(ctx context.Context) (*Node, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.targetUtilization == 0 || a.activeNodes() <= a.minNodes {
+		return nil, nil
+	}
+	running, utilization := a.utilization()
+	// idle node has no allocation, others share its part after removed
+	if running < 2 || utilization*float64(running)/float64(running-1) >= a.targetUtilization {
+		return nil, nil
+	}
+	idle := a.idleNode()
+	if idle == nil {
+		return nil, nil
+	}
+	dcs := a.DefaultClusterService
+	if err := dcs.DrainNode(idle.Id); err != nil {
+		dcs.Uncordon(idle.Id)
+		return nil, err
+	}
+	if err := dcs.KillNodeContext(ctx, *idle, int(DefaultStopGracePeriod/time.Millisecond)); err != nil {
+		dcs.Uncordon(idle.Id)
+		return nil, err
+	}
+	if err := dcs.RemoveNode(idle.Id); err != nil && !errors.Is(err, ErrNodeHasContainers) {
+		return nil, err
+	}
+	return idle, nil
+}
+
+// activeNodes returns number of nodes not exited.
+func (a *Autoscaler) activeNodes() int {
+	nodes, _ := a.Nodes(true)
+	active := 0
+	for _, node := range nodes {
+		if node.NodeState != NodeExited {
+			active++
+		}
+	}
+	return active
+}
+
+// utilization returns number of running nodes and their average ratio of allocated capacity.
+func (a *Autoscaler) utilization() (int, float64) {
+	dcs := a.DefaultClusterService
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	dcs.refreshAllocated()
+	running := 0
+	used := 0.0
+	for _, node := range dcs.nodes {
+		if node.NodeState != NodeRunning {
+			continue
+		}
+		running++
+		used += 1 - node.FreeRatio()
+	}
+	if running == 0 {
+		return 0, 0
+	}
+	return running, used / float64(running)
+}
+
+// idleNode returns the newest running node without alive containers, nil if none.
+func (a *Autoscaler) idleNode() *Node {
+	dcs := a.DefaultClusterService
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
+	for i := len(dcs.nodes) - 1; i >= 0; i-- {
+		node := dcs.nodes[i]
+		if node.NodeState != NodeRunning {
+			continue
+		}
+		idle := true
+		for _, c := range dcs.containersByNode[node.Id] {
+			if state := containerStateOf(c); state == ContainerCreated || state == ContainerRunning || state == ContainerPaused {
+				idle = false
+				break
+			}
+		}
+		if idle {
+			return node.Clone()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func newTestAutoscaler(t *testing.T, minNodes, maxNodes int) *Autoscaler {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	provider := NewFakeResourceProvider(ResourceInfo{})
+	autoscaler, err := NewAutoscaler(clusterService, minNodes, maxNodes, 0.8, func(node *Node) error {
+		node.ResourceProvider = provider
+		node.Client = NewFakeContainerClient("hash1")
+		node.Capacity = Capacity{CPUShares: 1024}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return autoscaler
+}
+
+func TestNewAutoscaler(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	prepare := func(node *Node) error { return nil }
+	tests := []struct {
+		name              string
+		minNodes          int
+		maxNodes          int
+		targetUtilization float64
+		prepare           func(node *Node) error
+		valid             bool
+	}{
+		{"valid", 1, 3, 0.8, prepare, true},
+		{"minOverMax", 3, 1, 0.8, prepare, false},
+		{"noMax", 0, 0, 0.8, prepare, false},
+		{"targetOverOne", 1, 3, 1.5, prepare, false},
+		{"noPrepare", 1, 3, 0.8, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewAutoscaler(clusterService, tt.minNodes, tt.maxNodes, tt.targetUtilization, tt.prepare)
+			if (err == nil) != tt.valid {
+				t.Errorf("want:%v,have:%v", tt.valid, err)
+			}
+		})
+	}
+}
+
+func TestAutoscaler_CreateContainerWithSpec(t *testing.T) {
+	autoscaler := newTestAutoscaler(t, 0, 2)
+	spec := ContainerSpec{ResourceRequests: Capacity{CPUShares: 512}}
+	// the first node is added as there is no node, and the second when the first is full
+	expectedNodes := []int{1, 1, 2, 2}
+	for _, expected := range expectedNodes {
+		if _, err := autoscaler.CreateContainerWithSpec(spec); err != nil {
+			t.Fatal(err)
+		}
+		if have := autoscaler.activeNodes(); have != expected {
+			t.Errorf("want:%v,have:%v", expected, have)
+		}
+	}
+	_, err := autoscaler.CreateContainerWithSpec(spec)
+	if !errors.Is(err, ErrInsufficientCapacity) {
+		t.Errorf("want:%v,have:%v", ErrInsufficientCapacity, err)
+	}
+	if autoscaler.activeNodes() != 2 {
+		t.Errorf("want:%v,have:%v", 2, autoscaler.activeNodes())
+	}
+}
+
+func TestAutoscaler_ScaleDown(t *testing.T) {
+	autoscaler := newTestAutoscaler(t, 1, 3)
+	autoscaler.autoscaleOnce(context.Background())
+	if autoscaler.activeNodes() != 1 {
+		t.Fatalf("want:%v,have:%v", 1, autoscaler.activeNodes())
+	}
+	spec := ContainerSpec{ResourceRequests: Capacity{CPUShares: 512}}
+	containers := Containers{}
+	for i := 0; i < 3; i++ {
+		c, err := autoscaler.CreateContainerWithSpec(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		containers = append(containers, c)
+	}
+
+	// the second node is busy
+	if node, err := autoscaler.ScaleDown(context.Background()); node != nil || err != nil {
+		t.Errorf("want:nil,have:%v,%v", node, err)
+	}
+	if err := autoscaler.RemoveContainer(containers[2].Id); err != nil {
+		t.Fatal(err)
+	}
+	node, err := autoscaler.ScaleDown(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node == nil || node.Id != containers[2].NodeId {
+		t.Errorf("want:%v,have:%v", containers[2].NodeName, node)
+	}
+	if autoscaler.activeNodes() != 1 {
+		t.Errorf("want:%v,have:%v", 1, autoscaler.activeNodes())
+	}
+	// min nodes are kept even if idle
+	for _, c := range containers[:2] {
+		autoscaler.RemoveContainer(c.Id)
+	}
+	if node, err := autoscaler.ScaleDown(context.Background()); node != nil || err != nil {
+		t.Errorf("want:nil,have:%v,%v", node, err)
+	}
+}
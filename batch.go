@@ -0,0 +1,284 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultMaxInFlight is max number of concurrent runtime calls per node in batch operations.
+const DefaultMaxInFlight = 4
+
+// SetMaxInFlight set max number of concurrent runtime calls per node in batch operations, at least 1.
+func (dcs *DefaultClusterService) SetMaxInFlight(maxInFlight int) {
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	if maxInFlight < 1 {
+		maxInFlight = 1
+	}
+	dcs.maxInFlight = maxInFlight
+}
+
+// RunContainers run containers concurrently, calling runtime of each node at most max in flight at a time,
+// and launching at most MaxConcurrentLaunches of the node at a time, including launches by other calls.
+// It returns error of each container in order, and joined errors if any of them failed.
+func (dcs *DefaultClusterService) RunContainers(containers Containers) ([]error, error) {
+	return dcs.RunContainersContext(context.Background(), containers)
+}
+
+// RunContainersContext is RunContainers which gives up when ctx is done.
+func (dcs *DefaultClusterService) RunContainersContext(ctx context.Context, containers Containers) ([]error, error) {
+	dcs.mu.RLock()
+	secrets := dcs.secrets
+	dcs.mu.RUnlock()
+	// containers run on runtime but failed to be applied, which are discarded not to leak
+	discards := map[UID]func() error{}
+	errs, _ := dcs.batch(ctx, containers, ContainerRunning, func(ctx context.Context, node *Node, container *Container) (func(*Container) error, error) {
+		// container is snapshot, so env populated from secrets is not kept
+		env, err := secretEnv(ctx, secrets, container.Spec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run container:%v, %w", container.Name, err)
+		}
+		container.Spec.Env = env
+		release, err := dcs.launches.acquire(ctx, node.Id, node.MaxConcurrentLaunches)
+		if err != nil {
+			return nil, err
+		}
+		ran, err := node.runOnClient(ctx, container)
+		release()
+		if err != nil {
+			return nil, err
+		}
+		return func(owned *Container) error {
+			if err := ran.apply(owned); err != nil {
+				container.Hash = ran.hash
+				discards[owned.Id] = func() error { return discardOnClient(ctx, node, container) }
+				return err
+			}
+			dcs.emit(EventContainerStarted, owned.Id)
+			return nil
+		}, nil
+	})
+	// applies are called under lock one by one, so discards are collected without race
+	for i, container := range containers {
+		if discard, ok := discards[container.Id]; ok {
+			delete(discards, container.Id)
+			errs[i] = errors.Join(errs[i], discard())
+		}
+	}
+	return errs, errors.Join(errs...)
+}
+
+// discardOnClient stop and remove container just run on runtime of node, whose result can not be applied.
+// container is snapshot with hash of the run.
+func discardOnClient(ctx context.Context, node *Node, container *Container) error {
+	if err := node.Client.Stop(ctx, container, 0); err != nil {
+		return fmt.Errorf("failed to discard container:%v, %w", container.Name, err)
+	}
+	if err := node.Client.Remove(ctx, container); err != nil {
+		return fmt.Errorf("failed to discard container:%v, %w", container.Name, err)
+	}
+	return nil
+}
+
+// KillContainers kill containers concurrently, calling runtime of each node at most max in flight at a time.
+// It returns error of each container in order, and joined errors if any of them failed.
+func (dcs *DefaultClusterService) KillContainers(containers Containers) ([]error, error) {
+	return dcs.KillContainersContext(context.Background(), containers)
+}
+
+// KillContainersContext is KillContainers which gives up when ctx is done.
+func (dcs *DefaultClusterService) KillContainersContext(ctx context.Context, containers Containers) ([]error, error) {
+	return dcs.killContainers(ctx, containers, DefaultStopGracePeriod, "killed by KillContainers")
+}
+
+// killContainers stop containers in batch with gracePeriod, and record reason to their statuses.
+func (dcs *DefaultClusterService) killContainers(ctx context.Context, containers Containers, gracePeriod time.Duration, reason string) ([]error, error) {
+	return dcs.batch(ctx, containers, ContainerExited, func(ctx context.Context, node *Node, container *Container) (func(*Container) error, error) {
+		if node.Client == nil {
+			return nil, fmt.Errorf("%w:%v", ErrNodeHasNoClient, node.Name)
+		}
+		if err := node.Client.Stop(ctx, container, gracePeriod); err != nil {
+			return nil, err
+		}
+		return func(container *Container) error {
+			if err := checkContainerTransition(containerStateOf(container), ContainerExited); err != nil {
+				return err
+			}
+			container.Killed = true
+			if err := transitionContainer(container.ContainerStatus, ContainerExited, reason); err != nil {
+				return err
+			}
+			dcs.emit(EventContainerExited, container.Id)
+			return nil
+		}, nil
+	})
+}
+
+// batch check containers can move to state and mark them in flight under lock, then call runtime by call
+// with snapshot of container without lock concurrently, and apply its result to container under lock again.
+// same container given twice fails except the first, and container already in flight fails with ErrConflict.
+func (dcs *DefaultClusterService) batch(ctx context.Context, containers Containers, state ContainerState,
+	call func(ctx context.Context, node *Node, container *Container) (func(*Container) error, error)) ([]error, error) {
+	errs := make([]error, len(containers))
+	nodes := make([]*Node, len(containers))
+	owned := make(Containers, len(containers))
+	snapshots := make(Containers, len(containers))
+	marked := make([]bool, len(containers))
+	dcs.mu.Lock()
+	maxInFlight := dcs.maxInFlight
+	seen := make(map[UID]bool, len(containers))
+	for i, container := range containers {
+		if seen[container.Id] {
+			errs[i] = fmt.Errorf("duplicated container:%v", container.Name)
+			continue
+		}
+		seen[container.Id] = true
+		owned[i] = dcs.ownedContainer(container)
+		snapshots[i] = owned[i].Clone()
+		errs[i] = dcs.checkBatch(owned[i], state)
+		if errs[i] == nil {
+			// other operations on container are rejected until result is applied
+			errs[i] = dcs.acquireInFlight(owned[i])
+			marked[i] = errs[i] == nil
+		}
+		// runtime is called with copy of node, which may be changed meanwhile
+		nodes[i] = dcs.findNodeById(owned[i].NodeId).Clone()
+	}
+	dcs.mu.Unlock()
+
+	// containers of each node are dispatched in order of priority
+	order := priorityOrder(snapshots)
+	byNode := map[UID][]int{}
+	for _, i := range order {
+		if errs[i] == nil {
+			byNode[nodes[i].Id] = append(byNode[nodes[i].Id], i)
+		}
+	}
+	applies := make([]func(*Container) error, len(containers))
+	var wg sync.WaitGroup
+	for _, indexes := range byNode {
+		wg.Add(1)
+		go func(indexes []int) {
+			defer wg.Done()
+			slot := make(chan struct{}, maxInFlight)
+			var calls sync.WaitGroup
+			for _, i := range indexes {
+				select {
+				case <-ctx.Done():
+					errs[i] = ctx.Err()
+					continue
+				case slot <- struct{}{}:
+				}
+				calls.Add(1)
+				go func(i int) {
+					defer calls.Done()
+					defer func() { <-slot }()
+					applies[i], errs[i] = call(ctx, nodes[i], snapshots[i])
+				}(i)
+			}
+			calls.Wait()
+		}(indexes)
+	}
+	wg.Wait()
+
+	dcs.mu.Lock()
+	for _, i := range order {
+		if applies[i] != nil {
+			errs[i] = applies[i](owned[i])
+		}
+		if marked[i] {
+			dcs.releaseInFlight(owned[i])
+		}
+		if owned[i] != nil {
+			copyContainer(containers[i], owned[i])
+		}
+	}
+	dcs.mu.Unlock()
+	return errs, errors.Join(errs...)
+}
+
+// acquireInFlight mark container in flight, or returns ErrConflict if it is already marked by other operation.
+// caller must hold lock, and release it by releaseInFlight under lock after applying result of runtime.
+func (dcs *DefaultClusterService) acquireInFlight(container *Container) error {
+	if dcs.inFlight[container.Id] {
+		return fmt.Errorf("%w for uid:%v, operation in flight", ErrConflict, container.Id)
+	}
+	if dcs.inFlight == nil {
+		dcs.inFlight = make(map[UID]bool)
+	}
+	dcs.inFlight[container.Id] = true
+	return nil
+}
+
+// releaseInFlight unmark container marked by acquireInFlight, caller must hold lock.
+func (dcs *DefaultClusterService) releaseInFlight(container *Container) {
+	delete(dcs.inFlight, container.Id)
+}
+
+func (dcs *DefaultClusterService) checkBatch(container *Container, state ContainerState) error {
+	current := container.ContainerStatus.ContainerState
+	if current == ContainerPending && state == ContainerRunning {
+		return fmt.Errorf("%w:%v", ErrContainerPending, container.Name)
+	}
+	if current == state && state == ContainerRunning {
+		return fmt.Errorf("%w:%v", ErrAlreadyRunning, container.Name)
+	}
+	if current == state && state == ContainerExited {
+		return fmt.Errorf("%w:%v", ErrAlreadyExited, container.Name)
+	}
+	if dcs.findNodeById(container.NodeId) == nil {
+		return fmt.Errorf("%w for uid:%v", ErrNodeNotFound, container.NodeId)
+	}
+	return checkContainerTransition(current, state)
+}
+
+// RunNodes run nodes concurrently by their resource providers.
+// It returns error of each node in order, and joined errors if any of them failed.
+func (dcs *DefaultClusterService) RunNodes(nodes Nodes) ([]error, error) {
+	return dcs.RunNodesContext(context.Background(), nodes)
+}
+
+// RunNodesContext is RunNodes which gives up waiting resource providers when ctx is done.
+func (dcs *DefaultClusterService) RunNodesContext(ctx context.Context, nodes Nodes) ([]error, error) {
+	errs := make([]error, len(nodes))
+	owned := make(Nodes, len(nodes))
+	snapshots := make(Nodes, len(nodes))
+	dcs.mu.RLock()
+	seen := make(map[UID]bool, len(nodes))
+	for i, node := range nodes {
+		if seen[node.Id] {
+			errs[i] = fmt.Errorf("duplicated node:%v", node.Name)
+			continue
+		}
+		seen[node.Id] = true
+		owned[i] = dcs.ownedNode(node)
+		snapshots[i] = owned[i].Clone()
+		errs[i] = checkRunNode(owned[i])
+	}
+	dcs.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for i, node := range snapshots {
+		if errs[i] != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, node *Node) {
+			defer wg.Done()
+			errs[i] = dcs.runNodeOnProvider(ctx, owned[i], node)
+		}(i, node)
+	}
+	wg.Wait()
+
+	dcs.mu.RLock()
+	for i, node := range owned {
+		if node != nil {
+			copyNode(nodes[i], node)
+		}
+	}
+	dcs.mu.RUnlock()
+	return errs, errors.Join(errs...)
+}
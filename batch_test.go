@@ -0,0 +1,243 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowContainerClient tracks max number of concurrent Run calls.
+type slowContainerClient struct {
+	*FakeContainerClient
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func (scc *slowContainerClient) Run(ctx context.Context, container *Container) (string, error) {
+	scc.mu.Lock()
+	scc.inFlight++
+	if scc.inFlight > scc.maxInFlight {
+		scc.maxInFlight = scc.inFlight
+	}
+	scc.mu.Unlock()
+	time.Sleep(10 * time.Millisecond)
+	scc.mu.Lock()
+	scc.inFlight--
+	scc.mu.Unlock()
+	return scc.FakeContainerClient.Run(ctx, container)
+}
+
+func TestDefaultClusterService_RunContainers(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	clusterService.SetMaxInFlight(2)
+	client := &slowContainerClient{FakeContainerClient: NewFakeContainerClient("hash1")}
+	runTestNode(t, clusterService, client)
+	containers := Containers{}
+	for i := 0; i < 5; i++ {
+		container, err := clusterService.CreateContainerWithSpec(ContainerSpec{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		containers = append(containers, container)
+	}
+	running := containers[0]
+	if err := clusterService.RunContainer(running); err != nil {
+		t.Fatal(err)
+	}
+
+	errs, err := clusterService.RunContainers(append(containers, containers[1]))
+	if err == nil {
+		t.Fatal("want error for partial failure")
+	}
+	want := []error{ErrAlreadyRunning, nil, nil, nil, nil}
+	for i, e := range want {
+		if !errors.Is(errs[i], e) {
+			t.Errorf("want:%v,have:%v", e, errs[i])
+		}
+	}
+	if errs[5] == nil {
+		t.Errorf("want error for duplicated container")
+	}
+	for _, container := range containers {
+		if container.ContainerStatus.ContainerState != ContainerRunning || container.Hash != "hash1" {
+			t.Errorf("want:%v,have:%v", ContainerRunning, container.ContainerStatus)
+		}
+	}
+	if client.maxInFlight != 2 {
+		t.Errorf("want:%v,have:%v", 2, client.maxInFlight)
+	}
+
+	errs, err = clusterService.KillContainers(containers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != len(containers) || len(client.Stops()) != len(containers) {
+		t.Errorf("want:%v,have:%v", len(containers), errs)
+	}
+	for _, container := range containers {
+		if container.ContainerStatus.ContainerState != ContainerExited || !container.Killed {
+			t.Errorf("want:%v,have:%v", ContainerExited, container.ContainerStatus)
+		}
+	}
+	if _, err := clusterService.KillContainers(containers); !errors.Is(err, ErrAlreadyExited) {
+		t.Errorf("want:%v,have:%v", ErrAlreadyExited, err)
+	}
+}
+
+func TestDefaultClusterService_RunContainers_PrepareNode(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	client := &slowContainerClient{FakeContainerClient: NewFakeContainerClient("hash1")}
+	node := runTestNode(t, clusterService, client)
+	containers := Containers{}
+	for i := 0; i < 4; i++ {
+		container, err := clusterService.CreateContainerWithSpec(ContainerSpec{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		containers = append(containers, container)
+	}
+
+	// node is prepared while runtime is called, which must not race with it
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			clusterService.PrepareNode(node.Id, func(node *Node) error {
+				node.Labels = map[string]string{"round": "prepared"}
+				return nil
+			})
+		}
+	}()
+	if _, err := clusterService.RunContainers(containers); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+}
+
+func TestDefaultClusterService_CreateNodes(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	// taken name is skipped in batch
+	if _, err := clusterService.CreateNamedNode("node-3"); err != nil {
+		t.Fatal(err)
+	}
+	nodes, err := clusterService.CreateNodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 10 || len(clusterService.nodes) != 11 {
+		t.Fatalf("want:%v,have:%v", 10, nodes)
+	}
+	names := map[string]bool{}
+	for _, node := range clusterService.nodes {
+		if names[node.Name] {
+			t.Errorf("duplicated name:%v", node.Name)
+		}
+		names[node.Name] = true
+		if node.NodeState != NodeCreated {
+			t.Errorf("want:%v,have:%v", NodeCreated, node.NodeState)
+		}
+	}
+	if nodes[9].Name != "node-11" {
+		t.Errorf("want:%v,have:%v", "node-11", nodes[9].Name)
+	}
+	if _, err := clusterService.CreateNodes(0); err == nil {
+		t.Error("want error for no node")
+	}
+}
+
+func TestDefaultClusterService_RunNodes(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	provider := NewFakeResourceProvider(ResourceInfo{"host": "localhost"})
+	nodes, err := clusterService.CreateNodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, node := range nodes[1:] {
+		if _, err := clusterService.PrepareNode(node.Id, func(node *Node) error {
+			node.ResourceProvider = provider
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	errs, err := clusterService.RunNodes(append(nodes, nodes[1]))
+	if err == nil {
+		t.Fatal("want error for partial failure")
+	}
+	if !errors.Is(errs[0], ErrNoResourceProvider) || errs[10] == nil {
+		t.Errorf("want:%v,have:%v", ErrNoResourceProvider, errs)
+	}
+	for i, node := range nodes[1:] {
+		if errs[i+1] != nil {
+			t.Errorf("want:nil,have:%v", errs[i+1])
+		}
+		if node.NodeState != NodeRunning || node.ResourceInfo["host"] != "localhost" {
+			t.Errorf("want:%v,have:%v,%v", NodeRunning, node.NodeState, node.ResourceInfo)
+		}
+		if status := clusterService.findNodeStatusById(node.Id); status == nil || status.NodeState != NodeRunning {
+			t.Errorf("want:%v,have:%v", NodeRunning, status)
+		}
+	}
+	if provider.runCalls != 9 {
+		t.Errorf("want:%v,have:%v", 9, provider.runCalls)
+	}
+}
+
+func TestDefaultClusterService_RunContainers_InFlight(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	client := newBlockingContainerClient()
+	runTestNode(t, clusterService, client)
+	container, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+
+	done := make(chan error, 1)
+	go func() { done <- clusterService.RunContainer(container) }()
+	if called := <-client.called; called != "Run" {
+		t.Fatalf("want:%v,have:%v", "Run", called)
+	}
+	if err := clusterService.RunContainer(container); !errors.Is(err, ErrConflict) {
+		t.Errorf("want:%v,have:%v", ErrConflict, err)
+	}
+	if err := clusterService.RemoveContainer(container.Id); !errors.Is(err, ErrConflict) {
+		t.Errorf("want:%v,have:%v", ErrConflict, err)
+	}
+	close(client.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if len(client.Runs()) != 1 {
+		t.Errorf("want:%v,have:%v", 1, len(client.Runs()))
+	}
+	if err := clusterService.KillContainer(container, 0); err != nil {
+		t.Errorf("want:%v,have:%v", nil, err)
+	}
+}
+
+func TestDefaultClusterService_RunContainers_Discard(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	client := newBlockingContainerClient()
+	runTestNode(t, clusterService, client)
+	container, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+
+	done := make(chan error, 1)
+	go func() { done <- clusterService.RunContainer(container) }()
+	if called := <-client.called; called != "Run" {
+		t.Fatalf("want:%v,have:%v", "Run", called)
+	}
+	// container got running while runtime is called, which operations can not do as it is in flight
+	clusterService.mu.Lock()
+	clusterService.findContainerById(container.Id).ContainerStatus.ContainerState = ContainerRunning
+	clusterService.mu.Unlock()
+	close(client.release)
+	if err := <-done; !errors.Is(err, ErrIllegalTransition) {
+		t.Errorf("want:%v,have:%v", ErrIllegalTransition, err)
+	}
+	if len(client.Stops()) != 1 || len(client.Removes()) != 1 {
+		t.Errorf("%v,%v", client.Stops(), client.Removes())
+	}
+	if container.Hash != "" {
+		t.Errorf("want:%v,have:%v", "", container.Hash)
+	}
+}
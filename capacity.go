@@ -0,0 +1,108 @@
+package cluster
+
+import "fmt"
+
+// Capacity is amount of resources. zero value of each field means unlimited on node, or nothing requested by container.
+type Capacity struct {
+	// cpu shares, 1024 shares for 1 cpu
+	CPUShares int64
+	// memory, MB
+	MemoryMB int64
+	// disk, GB
+	DiskGB int64
+}
+
+// Add returns sum of capacities.
+func (c Capacity) Add(other Capacity) Capacity {
+	return Capacity{
+		CPUShares: c.CPUShares + other.CPUShares,
+		MemoryMB:  c.MemoryMB + other.MemoryMB,
+		DiskGB:    c.DiskGB + other.DiskGB,
+	}
+}
+
+// Covers returns true if request is within c, zero field of c is unlimited.
+func (c Capacity) Covers(request Capacity) bool {
+	return fitsResource(c.CPUShares, c.CPUShares, request.CPUShares) &&
+		fitsResource(c.MemoryMB, c.MemoryMB, request.MemoryMB) &&
+		fitsResource(c.DiskGB, c.DiskGB, request.DiskGB)
+}
+
+// FreeCapacity returns capacity minus allocated, fields unlimited on node are 0.
+// fields allocated over capacity are negative.
+func (n *Node) FreeCapacity() Capacity {
+	free := Capacity{}
+	if n.Capacity.CPUShares > 0 {
+		free.CPUShares = n.Capacity.CPUShares - n.Allocated.CPUShares
+	}
+	if n.Capacity.MemoryMB > 0 {
+		free.MemoryMB = n.Capacity.MemoryMB - n.Allocated.MemoryMB
+	}
+	if n.Capacity.DiskGB > 0 {
+		free.DiskGB = n.Capacity.DiskGB - n.Allocated.DiskGB
+	}
+	return free
+}
+
+// Fits returns true if request fits in free capacity of node.
+func (n *Node) Fits(request Capacity) bool {
+	free := n.FreeCapacity()
+	return fitsResource(n.Capacity.CPUShares, free.CPUShares, request.CPUShares) &&
+		fitsResource(n.Capacity.MemoryMB, free.MemoryMB, request.MemoryMB) &&
+		fitsResource(n.Capacity.DiskGB, free.DiskGB, request.DiskGB)
+}
+
+func fitsResource(capacity, free, request int64) bool {
+	return capacity == 0 || request <= free
+}
+
+// FreeRatio returns average ratio of free capacity to capacity, unlimited resource counts as 1.
+func (n *Node) FreeRatio() float64 {
+	free := n.FreeCapacity()
+	return (resourceRatio(n.Capacity.CPUShares, free.CPUShares) +
+		resourceRatio(n.Capacity.MemoryMB, free.MemoryMB) +
+		resourceRatio(n.Capacity.DiskGB, free.DiskGB)) / 3
+}
+
+func resourceRatio(capacity, free int64) float64 {
+	if capacity == 0 {
+		return 1
+	}
+	return float64(free) / float64(capacity)
+}
+
+// validateResources rejects spec whose limits are under its requests,
+// or whose requests exceed capacity of every node, which never fits even if nodes are empty.
+func (dcs *DefaultClusterService) validateResources(spec ContainerSpec) error {
+	if !spec.ResourceLimits.Covers(spec.ResourceRequests) {
+		return fmt.Errorf("%w, requests:%+v, limits:%+v", ErrInvalidResources, spec.ResourceRequests, spec.ResourceLimits)
+	}
+	if len(dcs.nodes) == 0 {
+		return nil
+	}
+	for _, node := range dcs.nodes {
+		if node.Capacity.Covers(spec.ResourceRequests) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w, requests:%+v", ErrRequestExceedsCapacity, spec.ResourceRequests)
+}
+
+// refreshAllocated recalculate Allocated and ContainerCount of nodes from containers not exited.
+func (dcs *DefaultClusterService) refreshAllocated() {
+	for _, node := range dcs.nodes {
+		node.Allocated = Capacity{}
+		node.ContainerCount = 0
+	}
+	for _, c := range dcs.containers {
+		if c.ContainerStatus != nil && c.ContainerStatus.ContainerState == ContainerExited {
+			continue
+		}
+		node := dcs.findNodeById(c.NodeId)
+		if node == nil {
+			continue
+		}
+		node.Allocated = node.Allocated.Add(c.Spec.ResourceRequests)
+		node.ContainerCount++
+	}
+}
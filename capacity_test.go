@@ -0,0 +1,150 @@
+package cluster
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDefaultClusterService_CreateContainerWithSpec_Capacity(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	client := NewFakeContainerClient("hash1")
+	small := prepareTestNode(t, clusterService, func(node *Node) {
+		node.Client = client
+		node.Capacity = Capacity{CPUShares: 1024, MemoryMB: 1024}
+	})
+	large := prepareTestNode(t, clusterService, func(node *Node) {
+		node.Client = client
+		node.Capacity = Capacity{CPUShares: 2048, MemoryMB: 2048}
+	})
+
+	spec := ContainerSpec{ResourceRequests: Capacity{CPUShares: 512, MemoryMB: 768}}
+	// first placement ties and takes the first node
+	expectedNodes := []*Node{small, large, large}
+	containers := Containers{}
+	for _, expected := range expectedNodes {
+		container, err := clusterService.CreateContainerWithSpec(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		containers = append(containers, container)
+		if container.NodeId != expected.Id {
+			t.Errorf("want:%v,have:%v", expected.Name, container.NodeName)
+		}
+	}
+	if _, err := clusterService.CreateContainerWithSpec(spec); !errors.Is(err, ErrInsufficientCapacity) {
+		t.Fatalf("%v", err)
+	}
+
+	// exited container releases its requests
+	if err := clusterService.RunContainer(containers[2]); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.KillContainer(containers[2], 0); err != nil {
+		t.Fatal(err)
+	}
+	container, err := clusterService.CreateContainerWithSpec(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if container.NodeId != large.Id {
+		t.Errorf("want:%v,have:%v", large.Name, container.NodeName)
+	}
+}
+
+func TestDefaultClusterService_DryRunCreateContainer(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	if _, err := clusterService.DryRunCreateContainerWithSpec(ContainerSpec{}); !errors.Is(err, ErrNoValidNode) {
+		t.Errorf("want:%v,have:%v", ErrNoValidNode, err)
+	}
+	small := prepareTestNode(t, clusterService, func(node *Node) {
+		node.Capacity = Capacity{CPUShares: 1024, MemoryMB: 1024}
+	})
+	large := prepareTestNode(t, clusterService, func(node *Node) {
+		node.Capacity = Capacity{CPUShares: 2048, MemoryMB: 2048}
+	})
+
+	spec := ContainerSpec{ResourceRequests: Capacity{CPUShares: 512, MemoryMB: 768}}
+	for _, expected := range []*Node{small, large, large} {
+		// dry run does not change selection of next create
+		for i := 0; i < 2; i++ {
+			node, err := clusterService.DryRunCreateContainerWithSpec(spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if node.Id != expected.Id {
+				t.Errorf("want:%v,have:%v", expected.Name, node.Name)
+			}
+		}
+		if _, err := clusterService.CreateContainerWithSpec(spec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := clusterService.DryRunCreateContainerWithSpec(spec); !errors.Is(err, ErrInsufficientCapacity) {
+		t.Errorf("want:%v,have:%v", ErrInsufficientCapacity, err)
+	}
+	if len(clusterService.containers) != 3 || len(clusterService.containerStatuses) != 3 {
+		t.Errorf("want:%v,have:%v", 3, clusterService.containers)
+	}
+
+	// default options are used same as CreateContainer
+	if _, err := clusterService.DryRunCreateContainer(); err == nil {
+		t.Error("want error for no options")
+	}
+	clusterService.SetOptions(ContainerOptions{})
+	node, err := clusterService.DryRunCreateContainer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// both nodes have same free ratio, so first one is taken
+	if node.Id != small.Id {
+		t.Errorf("want:%v,have:%v", small.Name, node.Name)
+	}
+}
+
+func TestDefaultClusterService_CreateContainerWithSpec_Resources(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	prepareTestNode(t, clusterService, func(node *Node) {
+		node.Capacity = Capacity{CPUShares: 1024, MemoryMB: 1024}
+	})
+
+	tests := []struct {
+		name string
+		spec ContainerSpec
+		want error
+	}{
+		{"fits", ContainerSpec{ResourceRequests: Capacity{MemoryMB: 512}, ResourceLimits: Capacity{MemoryMB: 1024}}, nil},
+		{"noLimits", ContainerSpec{ResourceRequests: Capacity{MemoryMB: 512}}, nil},
+		{"limitsUnderRequests", ContainerSpec{ResourceRequests: Capacity{MemoryMB: 512}, ResourceLimits: Capacity{MemoryMB: 256}}, ErrInvalidResources},
+		{"exceedsCapacity", ContainerSpec{ResourceRequests: Capacity{CPUShares: 2048}}, ErrRequestExceedsCapacity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := clusterService.CreateContainerWithSpec(tt.spec)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("want:%v,have:%v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestNode_FreeCapacity(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	node := prepareTestNode(t, clusterService, func(node *Node) {
+		node.Capacity = Capacity{MemoryMB: 1024}
+	})
+	if _, err := clusterService.CreateContainerWithSpec(ContainerSpec{ResourceRequests: Capacity{CPUShares: 4096, MemoryMB: 256}}); err != nil {
+		t.Fatal(err)
+	}
+	clusterService.refreshAllocated()
+	node, _ = clusterService.GetNode(node.Id)
+	expected := Capacity{MemoryMB: 768}
+	if free := node.FreeCapacity(); free != expected {
+		t.Errorf("want:%v,have:%v", expected, free)
+	}
+	if node.ContainerCount != 1 {
+		t.Errorf("%v", node.ContainerCount)
+	}
+	if !node.Fits(Capacity{CPUShares: 4096, MemoryMB: 768}) || node.Fits(Capacity{MemoryMB: 769}) {
+		t.Errorf("%v", node)
+	}
+}
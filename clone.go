@@ -0,0 +1,186 @@
+package cluster
+
+// Clone returns deep copy of container, sharing nothing with it.
+func (c *Container) Clone() *Container {
+	if c == nil {
+		return nil
+	}
+	clone := *c
+	clone.ContainerStatus = c.ContainerStatus.Clone()
+	if c.Image != nil {
+		image := *c.Image
+		clone.Image = &image
+	}
+	clone.ContainerOptions = cloneStringMap(c.ContainerOptions)
+	clone.Spec = c.Spec.Clone()
+	clone.Labels = cloneStringMap(c.Labels)
+	return &clone
+}
+
+// Clone returns deep copy of container status. Error is shared as it is immutable.
+func (cs *ContainerStatus) Clone() *ContainerStatus {
+	if cs == nil {
+		return nil
+	}
+	clone := *cs
+	clone.Ports = clonePorts(cs.Ports)
+	clone.Volumes = cloneVolumes(cs.Volumes)
+	if cs.History != nil {
+		clone.History = append([]StatusEvent{}, cs.History...)
+	}
+	return &clone
+}
+
+// Clone returns deep copy of spec.
+func (spec ContainerSpec) Clone() ContainerSpec {
+	clone := spec
+	clone.Env = cloneStrings(spec.Env)
+	clone.EnvFrom = cloneStrings(spec.EnvFrom)
+	clone.Ports = clonePorts(spec.Ports)
+	clone.Volumes = cloneVolumes(spec.Volumes)
+	clone.Command = cloneStrings(spec.Command)
+	clone.NodeAffinity = cloneStringMap(spec.NodeAffinity)
+	clone.AntiAffinity = cloneStringMap(spec.AntiAffinity)
+	if spec.Tolerations != nil {
+		clone.Tolerations = append([]Toleration{}, spec.Tolerations...)
+	}
+	if spec.DependsOn != nil {
+		clone.DependsOn = append([]UID{}, spec.DependsOn...)
+	}
+	clone.HealthCheck = spec.HealthCheck.Clone()
+	clone.LivenessProbe = spec.LivenessProbe.Clone()
+	clone.ReadinessProbe = spec.ReadinessProbe.Clone()
+	return clone
+}
+
+// Clone returns deep copy of node. Client and ResourceProvider are shared as they are not state of node.
+func (n *Node) Clone() *Node {
+	if n == nil {
+		return nil
+	}
+	clone := *n
+	clone.ResourceInfo = cloneStringMap(n.ResourceInfo)
+	clone.Labels = cloneStringMap(n.Labels)
+	if n.Taints != nil {
+		clone.Taints = append([]Taint{}, n.Taints...)
+	}
+	return &clone
+}
+
+// Clone returns copy of node status. Error is shared as it is immutable.
+func (ns *NodeStatus) Clone() *NodeStatus {
+	if ns == nil {
+		return nil
+	}
+	clone := *ns
+	if ns.Conditions != nil {
+		clone.Conditions = append([]NodeCondition{}, ns.Conditions...)
+	}
+	return &clone
+}
+
+// Clone returns deep copy of containers.
+func (cs Containers) Clone() Containers {
+	clone := make(Containers, 0, len(cs))
+	for _, c := range cs {
+		clone = append(clone, c.Clone())
+	}
+	return clone
+}
+
+// Clone returns deep copy of nodes.
+func (ns Nodes) Clone() Nodes {
+	clone := make(Nodes, 0, len(ns))
+	for _, n := range ns {
+		clone = append(clone, n.Clone())
+	}
+	return clone
+}
+
+func cloneStringMap[M ~map[string]string](m M) M {
+	if m == nil {
+		return nil
+	}
+	clone := make(M, len(m))
+	for k, v := range m {
+		clone[k] = v
+	}
+	return clone
+}
+
+func cloneStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string{}, s...)
+}
+
+func clonePorts(ports []PortMapping) []PortMapping {
+	if ports == nil {
+		return nil
+	}
+	return append([]PortMapping{}, ports...)
+}
+
+func cloneVolumes(volumes []VolumeMount) []VolumeMount {
+	if volumes == nil {
+		return nil
+	}
+	return append([]VolumeMount{}, volumes...)
+}
+
+// clonedContainer returns copy of container created by cluster, not to expose container held by cluster.
+func clonedContainer(container *Container, err error) (*Container, error) {
+	if err != nil {
+		return nil, err
+	}
+	return container.Clone(), nil
+}
+
+// clonedNode returns copy of node created by cluster, not to expose node held by cluster.
+func clonedNode(node *Node, err error) (*Node, error) {
+	if err != nil {
+		return nil, err
+	}
+	return node.Clone(), nil
+}
+
+// ownedContainer returns container held by cluster with same id as container, or container itself if not held.
+func (dcs *DefaultClusterService) ownedContainer(container *Container) *Container {
+	if owned := dcs.findContainerById(container.Id); owned != nil {
+		return owned
+	}
+	return container
+}
+
+// ownedNode returns node held by cluster with same id as node, or node itself if not held.
+func (dcs *DefaultClusterService) ownedNode(node *Node) *Node {
+	if owned := dcs.findNodeById(node.Id); owned != nil {
+		return owned
+	}
+	return node
+}
+
+// copyContainer copy state of owned to container given by caller, to show result of operation on owned.
+func copyContainer(container *Container, owned *Container) {
+	if container != owned {
+		*container = *owned.Clone()
+	}
+}
+
+// copyNode copy state of owned to node given by caller, to show result of operation on owned.
+func copyNode(node *Node, owned *Node) {
+	if node != owned {
+		*node = *owned.Clone()
+	}
+}
+
+// Clone returns deep copy of health check.
+func (hc *HealthCheck) Clone() *HealthCheck {
+	if hc == nil {
+		return nil
+	}
+	clone := *hc
+	clone.Command = cloneStrings(hc.Command)
+	return &clone
+}
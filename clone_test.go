@@ -0,0 +1,109 @@
+package cluster
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestContainer_Clone(t *testing.T) {
+	container := &Container{
+		Id:               "id1",
+		Name:             "name1",
+		ContainerStatus:  &ContainerStatus{Id: "id1", Ports: []PortMapping{PortMapping{ContainerPort: 80}}},
+		Image:            &Image{Name: "image"},
+		ContainerOptions: ContainerOptions{"key": "value"},
+		Spec: ContainerSpec{
+			Env:         []string{"A=1"},
+			Command:     []string{"sh"},
+			HealthCheck: &HealthCheck{Command: []string{"true"}},
+		},
+		Labels: map[string]string{"app": "web"},
+	}
+	clone := container.Clone()
+	if !reflect.DeepEqual(container, clone) {
+		t.Errorf("want:%v,have:%v", container, clone)
+	}
+
+	clone.ContainerStatus.ContainerState = ContainerRunning
+	clone.ContainerStatus.Ports[0].ContainerPort = 8080
+	clone.Image.Name = "other"
+	clone.ContainerOptions["key"] = "other"
+	clone.Spec.Env[0] = "A=2"
+	clone.Spec.Command[0] = "bash"
+	clone.Spec.HealthCheck.Command[0] = "false"
+	clone.Labels["app"] = "db"
+	if container.ContainerStatus.ContainerState == ContainerRunning || container.ContainerStatus.Ports[0].ContainerPort != 80 ||
+		container.Image.Name != "image" || container.ContainerOptions["key"] != "value" ||
+		container.Spec.Env[0] != "A=1" || container.Spec.Command[0] != "sh" ||
+		container.Spec.HealthCheck.Command[0] != "true" || container.Labels["app"] != "web" {
+		t.Errorf("want not changed,have:%v", container)
+	}
+	if (*Container)(nil).Clone() != nil {
+		t.Error("want nil")
+	}
+}
+
+func TestNode_Clone(t *testing.T) {
+	client := &mockContainerClient{}
+	node := &Node{Id: "id1", Name: "node1", Client: client, ResourceInfo: ResourceInfo{"host": "localhost"}}
+	clone := node.Clone()
+	if !reflect.DeepEqual(node, clone) {
+		t.Errorf("want:%v,have:%v", node, clone)
+	}
+	clone.ResourceInfo["host"] = "other"
+	clone.NodeState = NodeRunning
+	if node.ResourceInfo["host"] != "localhost" || node.NodeState == NodeRunning {
+		t.Errorf("want not changed,have:%v", node)
+	}
+	if clone.Client != node.Client {
+		t.Errorf("want:%v,have:%v", node.Client, clone.Client)
+	}
+}
+
+func TestDefaultClusterService_Getters_ReturnCopies(t *testing.T) {
+	clusterService, _ := newTestRestartService(t)
+	container, err := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// created container and node are copies too
+	container.Name = "changed"
+	if have, _ := clusterService.GetContainer(container.Id); have.Name == "changed" {
+		t.Errorf("want not changed,have:%v", have)
+	}
+	node, _ := clusterService.CreateNode()
+	node.Labels = map[string]string{"changed": "true"}
+	if have, _ := clusterService.GetNode(node.Id); have.Labels["changed"] == "true" {
+		t.Errorf("want not changed,have:%v", have)
+	}
+
+	containers, _ := clusterService.Containers(true)
+	containers[0].Name = "changed"
+	containers[0].ContainerStatus.ContainerState = ContainerRunning
+	if have, _ := clusterService.GetContainer(container.Id); have.Name == "changed" || have.ContainerStatus.ContainerState == ContainerRunning {
+		t.Errorf("want not changed,have:%v", have)
+	}
+	nodes, _ := clusterService.Nodes(true)
+	nodes[0].NodeState = NodeExited
+	if have, _ := clusterService.GetNode(nodes[0].Id); have.NodeState != NodeRunning {
+		t.Errorf("want:%v,have:%v", NodeRunning, have.NodeState)
+	}
+	status, _ := clusterService.ContainerStatus(container.Id, "", "")
+	status.ContainerState = ContainerExited
+	if have, _ := clusterService.GetContainer(container.Id); have.ContainerStatus.ContainerState == ContainerExited {
+		t.Errorf("want not changed,have:%v", have.ContainerStatus)
+	}
+
+	// operations on copy apply to the container in cluster, and copy shows the result
+	got, _ := clusterService.GetContainer(container.Id)
+	if err := clusterService.RunContainer(got); err != nil {
+		t.Fatal(err)
+	}
+	have, _ := clusterService.GetContainer(container.Id)
+	if got.ContainerStatus.ContainerState != ContainerRunning || have.ContainerStatus.ContainerState != ContainerRunning {
+		t.Errorf("want:%v,have:%v,%v", ContainerRunning, got.ContainerStatus, have.ContainerStatus)
+	}
+	if got == have {
+		t.Error("want copy")
+	}
+}
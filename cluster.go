@@ -1,35 +1,37 @@
 package cluster
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"github.com/google/uuid"
+	"io"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
-	//"github.com/docker/docker/client"
 )
 
 type ClusterService interface {
-	// container runtime version
+	// version of cluster library, not of container runtime
 	Version() (Version, error)
 	// image of container
-	Image() (Image, error)
+	Image() (*Image, error)
 	// default options for container
 	Options() (ContainerOptions, error)
-	// get containers in cluster
+	// get containers in cluster, only alive ones which are created, running or paused unless all
 	Containers(all bool) (Containers, error)
 	// get container status by uid or (name and nodeName).
 	ContainerStatus(uid UID, name string, nodeName string) (*ContainerStatus, error)
 	// create new container
-	CrateContainer() error
+	CreateContainer() (*Container, error)
 	// run container
 	RunContainer(container *Container) error
 	// kill running container
-	KillContainer(runningContainer *Container) error
-	// get nodes in cluster
+	KillContainer(runningContainer *Container, gracePeriod time.Duration) error
+	// get nodes in cluster, only running ones unless all
 	Nodes(all bool) ([]*Node, error)
 	// create new node
-	CrateNode() error
+	CreateNode() (*Node, error)
 	// run node
 	RunNode(node *Node) error
 	// kill running node(if it is vm, shutdown) wait for gracePeriod(ms).
@@ -43,39 +45,101 @@ type ClusterService interface {
 	FlushNodes() error
 	// flush container status
 	FlushContainers() error
+	// stop background loops, containers and nodes, then release clients
+	Shutdown(ctx context.Context) error
 }
 
 type UID string
 type Version string
 
 type DefaultClusterService struct {
-	ClusterService
 	version           Version
 	image             *Image
 	options           ContainerOptions
 	containers        Containers
 	containerStatuses ContainerStatuses
-	nodes             Nodes
-	nodeStatuses      NodeStatuses
-	nodesById         map[UID]*Node
-	nodesByName       map[string]*Node
-	maxNameI          int
+	// index of containers by NodeId
+	containersByNode map[UID]Containers
+	// index of containers by Id
+	containersById map[UID]*Container
+	// index of containerStatuses by Id
+	containerStatusesById map[UID]*ContainerStatus
+	// index of containerStatuses by Name and NodeName
+	containerStatusesByName map[containerStatusKey]*ContainerStatus
+	nodes                   Nodes
+	nodeStatuses            NodeStatuses
+	nodesById               map[UID]*Node
+	nodesByName             map[nameKey]*Node
+	maxNameI                int
+	scheduler               Scheduler
+	idGenerator             IDGenerator
+	watchers                eventWatchers
+	loops                   backgroundLoops
+	// node groups by name
+	nodeGroups map[string]*NodeGroup
+	// replica sets by name
+	replicaSets map[string]*ReplicaSet
+	// store of secrets referenced by containers, optional
+	secrets SecretStore
+	// thresholds of node pressure conditions
+	nodeThresholds NodeConditionThresholds
+	// container pending longer than this degrades cluster, 0 disables it
+	pendingTimeout time.Duration
+	// launch slots of nodes
+	launches launchLimits
+	// containers whose runtime is called without lock, other operations on them fail with ErrConflict
+	inFlight map[UID]bool
+	// max number of concurrent runtime calls per node in batch operations
+	maxInFlight int
+	// running node without heartbeat in this duration is marked exited, 0 disables it
+	heartbeatTimeout time.Duration
+	// container not ready in this duration fails to be waited for, 0 disables it
+	readyTimeout time.Duration
+	// last resource version given to container or node
+	resourceVersion uint64
+	// guards fields above, and containers and nodes owned by the service
+	mu sync.RWMutex
 }
 
+var _ ClusterService = (*DefaultClusterService)(nil)
+
 func NewDefaultClusterService(version Version, image *Image) *DefaultClusterService {
 	return &DefaultClusterService{
-		version:    version,
-		image:      image,
-		containers: Containers{},
-		containerStatuses: ContainerStatuses{},
-		nodes: Nodes{},
-		nodeStatuses: NodeStatuses{},
-		nodesById: make(map[UID]*Node),
-		nodesByName: make(map[string]*Node),
-		maxNameI:0,
+		version:                 version,
+		image:                   image,
+		containers:              Containers{},
+		containerStatuses:       ContainerStatuses{},
+		containersByNode:        make(map[UID]Containers),
+		containersById:          make(map[UID]*Container),
+		containerStatusesById:   make(map[UID]*ContainerStatus),
+		containerStatusesByName: make(map[containerStatusKey]*ContainerStatus),
+		nodes:                   Nodes{},
+		nodeStatuses:            NodeStatuses{},
+		nodesById:               make(map[UID]*Node),
+		nodesByName:             make(map[nameKey]*Node),
+		maxNameI:                0,
+		scheduler:               LeastLoadedScheduler{},
+		idGenerator:             UUIDGenerator{},
+		maxInFlight:             DefaultMaxInFlight,
+		heartbeatTimeout:        DefaultHeartbeatTimeout,
+		readyTimeout:            DefaultReadyTimeout,
 	}
 }
 
+// SetScheduler set scheduler used to place containers.
+func (dcs *DefaultClusterService) SetScheduler(scheduler Scheduler) {
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	dcs.scheduler = scheduler
+}
+
+// SetIDGenerator set generator of ids of containers and nodes created after.
+func (dcs *DefaultClusterService) SetIDGenerator(idGenerator IDGenerator) {
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	dcs.idGenerator = idGenerator
+}
+
 type ClusterStatus struct {
 	ClusterState ClusterState
 	Reason       string
@@ -83,7 +147,19 @@ type ClusterStatus struct {
 
 type ClusterState string
 
+const (
+	// all of running nodes are healthy
+	ClusterRunning ClusterState = "running"
+	// cluster works, but some of nodes or containers failed
+	ClusterDegraded ClusterState = "degraded"
+	// no node to run containers
+	ClusterDown ClusterState = "down"
+)
+
+// Version returns version of cluster library given to the service, RuntimeVersion returns version of runtime.
 func (dcs *DefaultClusterService) Version() (Version, error) {
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
 	if dcs.version == "" {
 		return "", errors.New("not set version")
 	}
@@ -91,45 +167,76 @@ func (dcs *DefaultClusterService) Version() (Version, error) {
 }
 
 func (dcs *DefaultClusterService) Image() (*Image, error) {
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
+	return dcs.getImage()
+}
+
+func (dcs *DefaultClusterService) getImage() (*Image, error) {
 	if dcs.image == nil {
 		return nil, errors.New("not set image")
 	}
 	return dcs.image, nil
 }
 
+// SetOptions set default options used by CreateContainer.
+func (dcs *DefaultClusterService) SetOptions(options ContainerOptions) {
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	dcs.options = options
+}
+
 func (dcs *DefaultClusterService) Options() (ContainerOptions, error) {
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
+	return dcs.getOptions()
+}
+
+func (dcs *DefaultClusterService) getOptions() (ContainerOptions, error) {
 	if dcs.options == nil {
-		return nil, errors.New("not set image")
+		return nil, errors.New("not set options")
 	}
 	return dcs.options, nil
 }
 
+// Containers returns containers in cluster, only alive ones unless all.
+// alive containers are pending, created, running or paused, use RunningContainers for running ones only.
 func (dcs *DefaultClusterService) Containers(all bool) (Containers, error) {
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
 	if all {
-		return dcs.containers, nil
+		return dcs.containers.Clone(), nil
 	}
 	res := Containers{}
 	for _, c := range dcs.containers {
-		cs, err := dcs.ContainerStatus(c.Id, "", "")
+		cs, err := dcs.containerStatus(c.Id, "", "")
 		if err != nil {
 			return nil, err
 		}
 		if cs.ContainerState != ContainerExited && cs.ContainerState != ContainerUnknown {
-			res = append(res, c)
+			res = append(res, c.Clone())
 		}
 	}
 	return res, nil
 }
 
 func (dcs *DefaultClusterService) ContainerStatus(uid UID, name string, nodeName string) (*ContainerStatus, error) {
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
+	cs, err := dcs.containerStatus(uid, name, nodeName)
+	if err != nil {
+		return nil, err
+	}
+	return cs.Clone(), nil
+}
+
+func (dcs *DefaultClusterService) containerStatus(uid UID, name string, nodeName string) (*ContainerStatus, error) {
 	if uid == "" && (name == "" || nodeName == "") {
 		return nil, errors.New("uid or (name and nodeName) required")
 	}
 
-	for _, cs := range dcs.containerStatuses {
-		if cs.Id == uid || (cs.Name == name && cs.NodeName == nodeName) {
-			return cs, nil
-		}
+	if cs := dcs.findContainerStatus(uid, name, nodeName); cs != nil {
+		return cs, nil
 	}
 
 	containerFound := false
@@ -139,113 +246,1256 @@ func (dcs *DefaultClusterService) ContainerStatus(uid UID, name string, nodeName
 		}
 	}
 	if containerFound {
-		return nil, fmt.Errorf("not found container for uid:%v, name:%v, nodeName:%v", uid, name, nodeName)
+		return nil, fmt.Errorf("%w for uid:%v, name:%v, nodeName:%v", ErrContainerStatusNotFound, uid, name, nodeName)
+	}
+	return nil, fmt.Errorf("%w for uid:%v, name:%v, nodeName:%v", ErrContainerNotFound, uid, name, nodeName)
+}
+
+// GetContainer returns container by uid.
+func (dcs *DefaultClusterService) GetContainer(uid UID) (*Container, error) {
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
+	container := dcs.findContainerById(uid)
+	if container == nil {
+		return nil, fmt.Errorf("%w for uid:%v", ErrContainerNotFound, uid)
+	}
+	return container.Clone(), nil
+}
+
+// GetNode returns node by uid.
+func (dcs *DefaultClusterService) GetNode(uid UID) (*Node, error) {
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
+	node := dcs.findNodeById(uid)
+	if node == nil {
+		return nil, fmt.Errorf("%w for uid:%v", ErrNodeNotFound, uid)
 	}
-	return nil, fmt.Errorf("not found container status for uid:%v, name:%v, nodeName:%v", uid, name, nodeName)
+	return node.Clone(), nil
 }
 
+// ContainersOnNode returns containers placed on node by uid.
+func (dcs *DefaultClusterService) ContainersOnNode(nodeId UID) (Containers, error) {
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
+	if dcs.findNodeById(nodeId) == nil {
+		return nil, fmt.Errorf("%w for uid:%v", ErrNodeNotFound, nodeId)
+	}
+	return dcs.containersByNode[nodeId].Clone(), nil
+}
+
+// GetNodeByName returns node by name in default namespace.
+func (dcs *DefaultClusterService) GetNodeByName(name string) (*Node, error) {
+	return dcs.GetNodeByNameInNamespace(DefaultNamespace, name)
+}
+
+// GetNodeByNameInNamespace returns node by name in namespace.
+func (dcs *DefaultClusterService) GetNodeByNameInNamespace(namespace string, name string) (*Node, error) {
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
+	node := dcs.findNodeByName(namespace, name)
+	if node == nil {
+		return nil, fmt.Errorf("%w for name:%v/%v", ErrNodeNotFound, namespace, name)
+	}
+	return node.Clone(), nil
+}
+
+// CreateContainer create container with default options.
 func (dcs *DefaultClusterService) CreateContainer() (*Container, error) {
-	image, err := dcs.Image()
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	return clonedContainer(dcs.createContainer(nil, false))
+}
+
+// CreateContainerAllowPending is CreateContainer which keeps container pending without node, instead of failing,
+// if no node can take it now. pending containers are placed by SchedulePending in order of priority and creation,
+// which reconcile loop calls every round.
+func (dcs *DefaultClusterService) CreateContainerAllowPending() (*Container, error) {
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	return clonedContainer(dcs.createContainer(nil, true))
+}
+
+// DryRunCreateContainer returns node which CreateContainer would select, without creating container.
+func (dcs *DefaultClusterService) DryRunCreateContainer() (*Node, error) {
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	options, err := dcs.getOptions()
 	if err != nil {
 		return nil, err
 	}
-	node := dcs.minWorkingNode()
+	spec, err := options.Spec()
+	if err != nil {
+		return nil, err
+	}
+	return dcs.dryRunCreateContainer(*spec)
+}
+
+// DryRunCreateContainerWithSpec returns node which CreateContainerWithSpec would select, without creating container.
+func (dcs *DefaultClusterService) DryRunCreateContainerWithSpec(spec ContainerSpec) (*Node, error) {
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	return dcs.dryRunCreateContainer(spec)
+}
+
+// dryRunCreateContainer only refreshes allocated resources of nodes, which is derived from containers.
+func (dcs *DefaultClusterService) dryRunCreateContainer(spec ContainerSpec) (*Node, error) {
+	container, err := dcs.newContainerWithSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	node, err := dcs.minWorkingNode(container)
+	if err != nil {
+		return nil, err
+	}
+	return node.Clone(), nil
+}
+
+// CreateContainerOn create container with default options on the running node, bypassing scheduler.
+func (dcs *DefaultClusterService) CreateContainerOn(nodeId UID) (*Container, error) {
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	node := dcs.findNodeById(nodeId)
 	if node == nil {
-		return nil, errors.New("no valid node")
+		return nil, fmt.Errorf("%w for uid:%v", ErrNodeNotFound, nodeId)
+	}
+	if node.NodeState != NodeRunning {
+		return nil, fmt.Errorf("%w:%v", ErrNotRunning, node.Name)
+	}
+	return clonedContainer(dcs.createContainer(node, false))
+}
+
+// createContainer create container with default options on node, or node selected by scheduler if nil.
+func (dcs *DefaultClusterService) createContainer(node *Node, allowPending bool) (*Container, error) {
+	options, err := dcs.getOptions()
+	if err != nil {
+		return nil, err
+	}
+	spec, err := options.Spec()
+	if err != nil {
+		return nil, err
+	}
+	container, err := dcs.createContainerWithSpec(DefaultNamespace, *spec, node, allowPending)
+	if err != nil {
+		return nil, err
+	}
+	container.ContainerOptions = options
+	return container, nil
+}
+
+// newContainerWithSpec validate spec and returns container not placed on node yet.
+func (dcs *DefaultClusterService) newContainerWithSpec(spec ContainerSpec) (*Container, error) {
+	image, err := dcs.getImage()
+	if spec.Image != "" {
+		image, err = NewImage(spec.Image)
 	}
-	containerId := genUID()
-	options, err := dcs.Options()
 	if err != nil {
 		return nil, err
 	}
-	container := NewContainer(containerId, "", "", node.Id, node.Name, image, "", options)
+	if err := dcs.validateResources(spec); err != nil {
+		return nil, err
+	}
+	if err := validateVolumes(spec.Volumes); err != nil {
+		return nil, err
+	}
+	container := NewContainer(dcs.idGenerator.Generate(), "", "", "", "", image, "", nil)
+	container.Spec = spec
+	return container, nil
+}
+
+// CreateContainerWithSpec create container run with spec.
+func (dcs *DefaultClusterService) CreateContainerWithSpec(spec ContainerSpec) (*Container, error) {
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	return clonedContainer(dcs.createContainerWithSpec(DefaultNamespace, spec, nil, false))
+}
+
+// CreateContainerWithSpecAllowPending is CreateContainerWithSpec which keeps container pending without node,
+// instead of failing, if no node can take it now, as CreateContainerAllowPending.
+func (dcs *DefaultClusterService) CreateContainerWithSpecAllowPending(spec ContainerSpec) (*Container, error) {
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	return clonedContainer(dcs.createContainerWithSpec(DefaultNamespace, spec, nil, true))
+}
+
+// createContainerWithSpec create container in namespace on node, or node selected by scheduler if nil.
+// container is kept pending if allowPending and scheduler finds no node for it.
+func (dcs *DefaultClusterService) createContainerWithSpec(namespace string, spec ContainerSpec, node *Node, allowPending bool) (*Container, error) {
+	if err := validateNamespace(namespace); err != nil {
+		return nil, err
+	}
+	container, err := dcs.newContainerWithSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	container.Namespace = namespace
+	container.ContainerStatus.Namespace = namespace
+	if err := dcs.placeContainer(container, node); err != nil {
+		if node != nil || !allowPending || !isUnschedulable(err) {
+			return nil, err
+		}
+		if err := dcs.enqueuePending(container); err != nil {
+			return nil, err
+		}
+	}
+	return container, nil
+}
+
+// placeContainer put new container on node, or node selected by scheduler if node is nil, and register it as created.
+func (dcs *DefaultClusterService) placeContainer(container *Container, node *Node) error {
+	if node == nil {
+		var err error
+		node, err = dcs.minWorkingNode(container)
+		if err != nil {
+			return err
+		}
+	}
+	container.NodeId = node.Id
+	container.NodeName = node.Name
+	container.Name = dcs.genContainerName(node.Id, container.Image)
+	container.ContainerStatus.NodeName = node.Name
+	container.ContainerStatus.Name = container.Name
+	if err := TransitionContainer(container.ContainerStatus, ContainerCreated); err != nil {
+		return err
+	}
 	dcs.containers = append(dcs.containers, container)
+	dcs.indexContainer(container)
 	dcs.containerStatuses = append(dcs.containerStatuses, container.ContainerStatus)
-	return container, nil
+	dcs.indexContainerStatus(container.ContainerStatus)
+	dcs.emit(EventContainerCreated, container.Id)
+	return nil
 }
 
 func (dcs *DefaultClusterService) RunContainer(container *Container) error {
-	if container.ContainerStatus.ContainerState == ContainerRunning {
-		return fmt.Errorf("already running:%v", container.Name)
+	return dcs.RunContainerContext(context.Background(), container)
+}
+
+// RunContainerContext is RunContainer which gives up when ctx is done.
+// runtime is called without lock as RunContainers does, so slow runtime does not block others.
+func (dcs *DefaultClusterService) RunContainerContext(ctx context.Context, container *Container) error {
+	errs, _ := dcs.RunContainersContext(ctx, Containers{container})
+	return errs[0]
+}
+
+// KillContainer stop container, which is killed if it is not stopped in gracePeriod. 0 kills it immediately.
+func (dcs *DefaultClusterService) KillContainer(runningContainer *Container, gracePeriod time.Duration) error {
+	return dcs.KillContainerContext(context.Background(), runningContainer, gracePeriod)
+}
+
+// KillContainerContext is KillContainer which gives up when ctx is done.
+// runtime is called without lock as KillContainers does, so slow runtime does not block others.
+func (dcs *DefaultClusterService) KillContainerContext(ctx context.Context, runningContainer *Container, gracePeriod time.Duration) error {
+	errs, _ := dcs.killContainers(ctx, Containers{runningContainer}, gracePeriod, "killed by KillContainer")
+	return errs[0]
+}
+
+// RemoveContainer remove not running container from runtime and cluster with its status.
+func (dcs *DefaultClusterService) RemoveContainer(uid UID) error {
+	return dcs.RemoveContainerContext(context.Background(), uid)
+}
+
+// RemoveContainerContext is RemoveContainer which gives up when ctx is done.
+// runtime is called without lock, so slow runtime does not block others.
+func (dcs *DefaultClusterService) RemoveContainerContext(ctx context.Context, uid UID) error {
+	dcs.mu.Lock()
+	container := dcs.findContainerById(uid)
+	err := checkRemoveContainer(container, uid)
+	snapshot := container.Clone()
+	var node *Node
+	if err == nil {
+		err = dcs.acquireInFlight(container)
+		node = dcs.findNodeById(container.NodeId).Clone()
 	}
-	node := dcs.findNodeById(container.NodeId)
-	err := node.RunContainer(container)
-	return err
+	dcs.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	err = removeOnClient(ctx, node, snapshot)
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	dcs.releaseInFlight(container)
+	if err != nil {
+		return err
+	}
+	// container may be changed while removing without lock
+	current := dcs.findContainerById(uid)
+	if err := checkRemoveContainer(current, uid); err != nil {
+		return err
+	}
+	if current != container || current.Hash != snapshot.Hash {
+		return fmt.Errorf("%w for uid:%v, changed while removing", ErrConflict, uid)
+	}
+	dcs.unregisterContainer(container)
+	return nil
+}
+
+// checkRemoveContainer returns error if container of uid can not be removed.
+func checkRemoveContainer(container *Container, uid UID) error {
+	if container == nil {
+		return fmt.Errorf("%w for uid:%v", ErrContainerNotFound, uid)
+	}
+	if state := containerStateOf(container); state == ContainerRunning || state == ContainerPaused {
+		return fmt.Errorf("%w:%v", ErrStillRunning, container.Name)
+	}
+	return nil
+}
+
+// removeOnClient remove container from runtime of node, if it has been run there.
+func removeOnClient(ctx context.Context, node *Node, container *Container) error {
+	if container.Hash == "" || node == nil || node.Client == nil {
+		return nil
+	}
+	return node.Client.Remove(ctx, container)
+}
+
+// unregisterContainer drop container and its status from cluster.
+func (dcs *DefaultClusterService) unregisterContainer(container *Container) {
+	containers := Containers{}
+	for _, c := range dcs.containers {
+		if c.Id != container.Id {
+			containers = append(containers, c)
+		}
+	}
+	dcs.unindexContainer(container)
+	containerStatuses := ContainerStatuses{}
+	for _, cs := range dcs.containerStatuses {
+		if cs.Id != container.Id {
+			containerStatuses = append(containerStatuses, cs)
+		} else {
+			dcs.unindexContainerStatus(cs)
+		}
+	}
+	dcs.containers = containers
+	dcs.containerStatuses = containerStatuses
+}
+
+// ForceRemoveContainer kill container if it is running or paused, then remove it.
+func (dcs *DefaultClusterService) ForceRemoveContainer(uid UID) error {
+	return dcs.ForceRemoveContainerContext(context.Background(), uid)
+}
+
+// ForceRemoveContainerContext is ForceRemoveContainer which gives up when ctx is done.
+// runtime is called without lock as RemoveContainerContext does.
+func (dcs *DefaultClusterService) ForceRemoveContainerContext(ctx context.Context, uid UID) error {
+	dcs.mu.RLock()
+	container := dcs.findContainerById(uid)
+	snapshot := container.Clone()
+	dcs.mu.RUnlock()
+	if snapshot == nil {
+		return fmt.Errorf("%w for uid:%v", ErrContainerNotFound, uid)
+	}
+	if state := containerStateOf(snapshot); state == ContainerRunning || state == ContainerPaused {
+		errs, _ := dcs.killContainers(ctx, Containers{snapshot}, 0, "killed by ForceRemoveContainer")
+		if errs[0] != nil {
+			return errs[0]
+		}
+	}
+	return dcs.RemoveContainerContext(ctx, uid)
+}
+
+// Logs returns stdout and stderr of container, caller must close it to stop following.
+func (dcs *DefaultClusterService) Logs(container *Container, follow bool) (io.ReadCloser, error) {
+	return dcs.LogsContext(context.Background(), container, follow)
+}
+
+// LogsContext is Logs which stops streaming when ctx is done.
+func (dcs *DefaultClusterService) LogsContext(ctx context.Context, container *Container, follow bool) (io.ReadCloser, error) {
+	return dcs.LogsWithOptions(ctx, container, LogsOptions{Follow: follow})
+}
+
+// LogsWithOptions is LogsContext returning logs selected by options, like last lines by Tail.
+func (dcs *DefaultClusterService) LogsWithOptions(ctx context.Context, container *Container, options LogsOptions) (io.ReadCloser, error) {
+	dcs.mu.RLock()
+	state := container.ContainerStatus.ContainerState
+	node := dcs.findNodeById(container.NodeId).Clone()
+	dcs.mu.RUnlock()
+	if state == ContainerUnknown || state == ContainerCreated {
+		return nil, fmt.Errorf("no logs for %v container:%v", state, container.Name)
+	}
+	if node == nil {
+		return nil, fmt.Errorf("%w for uid:%v", ErrNodeNotFound, container.NodeId)
+	}
+	if node.Client == nil {
+		return nil, fmt.Errorf("%w:%v", ErrNodeHasNoClient, node.Name)
+	}
+	return node.Client.Logs(ctx, container, options)
+}
+
+// ExecInContainer run cmd in running container.
+// non zero exitCode with nil err means the command ran and failed.
+func (dcs *DefaultClusterService) ExecInContainer(container *Container, cmd []string) (stdout string, stderr string, exitCode int, err error) {
+	return dcs.ExecInContainerContext(context.Background(), container, cmd)
+}
+
+// ExecInContainerContext is ExecInContainer which gives up when ctx is done.
+func (dcs *DefaultClusterService) ExecInContainerContext(ctx context.Context, container *Container, cmd []string) (stdout string, stderr string, exitCode int, err error) {
+	dcs.mu.RLock()
+	state := container.ContainerStatus.ContainerState
+	node := dcs.findNodeById(container.NodeId).Clone()
+	dcs.mu.RUnlock()
+	if state != ContainerRunning {
+		return "", "", 0, fmt.Errorf("%w:%v", ErrNotRunning, container.Name)
+	}
+	if len(cmd) == 0 {
+		return "", "", 0, errors.New("cmd required")
+	}
+	if node == nil {
+		return "", "", 0, fmt.Errorf("%w for uid:%v", ErrNodeNotFound, container.NodeId)
+	}
+	if node.Client == nil {
+		return "", "", 0, fmt.Errorf("%w:%v", ErrNodeHasNoClient, node.Name)
+	}
+	return node.Client.Exec(ctx, container, cmd)
+}
+
+// RuntimeVersion returns version of container runtime of node, which may differ between nodes.
+func (dcs *DefaultClusterService) RuntimeVersion(ctx context.Context, node *Node) (Version, error) {
+	dcs.mu.RLock()
+	snapshot := dcs.findNodeById(node.Id).Clone()
+	dcs.mu.RUnlock()
+	if snapshot == nil {
+		return "", fmt.Errorf("%w for uid:%v", ErrNodeNotFound, node.Id)
+	}
+	if snapshot.Client == nil {
+		return "", fmt.Errorf("%w:%v", ErrNodeHasNoClient, snapshot.Name)
+	}
+	return snapshot.Client.RuntimeVersion(ctx)
+}
+
+// AttachContainer stream stdio of main process of running container, until it is closed or ctx is done.
+func (dcs *DefaultClusterService) AttachContainer(ctx context.Context, container *Container, stdin io.Reader, stdout, stderr io.Writer) error {
+	dcs.mu.RLock()
+	state := containerStateOf(container)
+	node := dcs.findNodeById(container.NodeId).Clone()
+	dcs.mu.RUnlock()
+	if state != ContainerRunning {
+		return fmt.Errorf("%w:%v", ErrNotRunning, container.Name)
+	}
+	if node == nil {
+		return fmt.Errorf("%w for uid:%v", ErrNodeNotFound, container.NodeId)
+	}
+	if node.Client == nil {
+		return fmt.Errorf("%w:%v", ErrNodeHasNoClient, node.Name)
+	}
+	return node.Client.Attach(ctx, container, stdin, stdout, stderr)
+}
+
+// Nodes returns nodes in cluster, only running nodes unless all.
+func (dcs *DefaultClusterService) Nodes(all bool) ([]*Node, error) {
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
+	if all {
+		return Nodes(dcs.nodes).Clone(), nil
+	}
+	res := []*Node{}
+	for _, node := range dcs.nodes {
+		if node.Running() {
+			res = append(res, node.Clone())
+		}
+	}
+	return res, nil
+}
+
+// NodeStatus returns status of node by uid or name in default namespace.
+func (dcs *DefaultClusterService) NodeStatus(uid UID, name string) (NodeStatus, error) {
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
+	if uid == "" && name == "" {
+		return NodeStatus{}, errors.New("uid or name required")
+	}
+	for _, ns := range dcs.nodeStatuses {
+		if (uid != "" && ns.Id == uid) || (name != "" && ns.Name == name && namespaceOrDefault(ns.Namespace) == DefaultNamespace) {
+			return *ns.Clone(), nil
+		}
+	}
+	return NodeStatus{}, fmt.Errorf("%w for uid:%v, name:%v", ErrNodeNotFound, uid, name)
+}
+
+// Status returns cluster is down if no node is running, degraded if some nodes are unreachable, containers failed,
+// or containers are pending longer than pending timeout.
+func (dcs *DefaultClusterService) Status() (ClusterStatus, error) {
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
+	return dcs.status(), nil
+}
+
+func (dcs *DefaultClusterService) status() ClusterStatus {
+	running, unreachable := 0, 0
+	for _, node := range dcs.nodes {
+		switch node.NodeState {
+		case NodeRunning:
+			running++
+		case NodeUnreachable:
+			unreachable++
+		}
+	}
+	// unreachable nodes may recover, so cluster is not down
+	if unreachable > 0 {
+		return ClusterStatus{ClusterState: ClusterDegraded, Reason: fmt.Sprintf("%d nodes unreachable", unreachable)}
+	}
+	if running == 0 {
+		return ClusterStatus{ClusterState: ClusterDown, Reason: "no running node"}
+	}
+	failed := 0
+	for _, c := range dcs.containers {
+		if c.ContainerStatus != nil && c.ContainerStatus.Failed() {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return ClusterStatus{ClusterState: ClusterDegraded, Reason: fmt.Sprintf("%d containers failed", failed)}
+	}
+	if stuck := dcs.stuckPending(time.Now()); stuck > 0 {
+		return ClusterStatus{ClusterState: ClusterDegraded, Reason: fmt.Sprintf("%d containers pending over %v", stuck, dcs.pendingTimeout)}
+	}
+	return ClusterStatus{ClusterState: ClusterRunning, Reason: fmt.Sprintf("%d nodes running", running)}
 }
 
 func (dcs *DefaultClusterService) CreateNode() (*Node, error) {
-	nodeId := genUID()
-	nodeName := dcs.genNodeName()
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	return clonedNode(dcs.createNode(DefaultNamespace))
+}
+
+func (dcs *DefaultClusterService) createNode(namespace string) (*Node, error) {
+	nodeName := dcs.genNodeName(namespace)
+	if nodeName == "" {
+		return nil, errors.New("no available node name")
+	}
+	return dcs.createNamedNode(namespace, nodeName)
+}
+
+// CreateNodes create count of nodes with unique names at once, none of them is created if it fails.
+func (dcs *DefaultClusterService) CreateNodes(count int) (Nodes, error) {
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	if count < 1 {
+		return nil, fmt.Errorf("invalid count of nodes:%v", count)
+	}
+	names := dcs.genNodeNames(DefaultNamespace, count)
+	if names == nil {
+		return nil, errors.New("no available node name")
+	}
+	nodes := make(Nodes, 0, count)
+	for _, name := range names {
+		node, err := dcs.createNamedNode(DefaultNamespace, name)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes.Clone(), nil
+}
+
+// CreateNamedNode create node with name in default namespace, which must be a DNS label and unique in the namespace.
+func (dcs *DefaultClusterService) CreateNamedNode(name string) (*Node, error) {
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	return clonedNode(dcs.createNamedNode(DefaultNamespace, name))
+}
+
+// PrepareNode set client, resource provider or others of node before it runs, by prepare called with copy of node
+// without lock. identity, state and allocation of node are kept as they are changed by operations.
+// It returns ErrConflict if node is changed while preparing, and prepared node.
+func (dcs *DefaultClusterService) PrepareNode(uid UID, prepare func(node *Node) error) (*Node, error) {
+	dcs.mu.RLock()
+	node := dcs.findNodeById(uid)
+	prepared := node.Clone()
+	dcs.mu.RUnlock()
+	if node == nil {
+		return nil, fmt.Errorf("%w for uid:%v", ErrNodeNotFound, uid)
+	}
+	resourceVersion := prepared.ResourceVersion
+	if err := prepare(prepared); err != nil {
+		return nil, err
+	}
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	if dcs.findNodeById(uid) != node || node.ResourceVersion != resourceVersion {
+		return nil, fmt.Errorf("%w for uid:%v, want:%v, have:%v", ErrConflict, uid, resourceVersion, node.ResourceVersion)
+	}
+	prepared.Id = node.Id
+	prepared.Name = node.Name
+	prepared.Namespace = node.Namespace
+	prepared.NodeState = node.NodeState
+	prepared.Allocated = node.Allocated
+	prepared.ContainerCount = node.ContainerCount
+	*node = *prepared
+	dcs.bumpNode(node)
+	return node.Clone(), nil
+}
+
+// SetNodeClient attach client operating containers on node, replacing one attached before.
+func (dcs *DefaultClusterService) SetNodeClient(uid UID, client ContainerClient) error {
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	node := dcs.findNodeById(uid)
+	if node == nil {
+		return fmt.Errorf("%w for uid:%v", ErrNodeNotFound, uid)
+	}
+	node.Client = client
+	dcs.bumpNode(node)
+	return nil
+}
+
+func (dcs *DefaultClusterService) createNamedNode(namespace string, name string) (*Node, error) {
+	if err := validateNamespace(namespace); err != nil {
+		return nil, err
+	}
+	if err := validateNodeName(name); err != nil {
+		return nil, err
+	}
+	if dcs.findNodeByName(namespace, name) != nil {
+		return nil, fmt.Errorf("%w:%v/%v", ErrNodeAlreadyExists, namespace, name)
+	}
+	nodeId := dcs.idGenerator.Generate()
 	node := &Node{
-		Id:   nodeId,
-		Name: nodeName,
+		Id:        nodeId,
+		Name:      name,
+		Namespace: namespace,
+		NodeState: NodeCreated,
 	}
 	dcs.nodes = append(dcs.nodes, node)
 	dcs.nodesById[nodeId] = node
-	dcs.nodesByName[nodeName] = node
+	dcs.nodesByName[nameKey{namespace: namespace, name: name}] = node
+	dcs.bumpNode(node)
 	return node, nil
 }
 
-func (dcs *DefaultClusterService) minWorkingNode() *Node {
-	nodes, _ := dcs.Nodes(false)
-	return nodes[0]
+// max length of node name, same as DNS label
+const maxNodeNameLength = 63
+
+var nodeNamePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// validateNodeName check name is a DNS label, lower case alphanumerics and '-'.
+func validateNodeName(name string) error {
+	if name == "" {
+		return errors.New("node name required")
+	}
+	if len(name) > maxNodeNameLength {
+		return fmt.Errorf("node name longer than %d:%v", maxNodeNameLength, name)
+	}
+	if !nodeNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid node name:%v", name)
+	}
+	return nil
 }
 
-func (dcs *DefaultClusterService) genNodeName() string {
-	nodes, err := dcs.Nodes(true)
+func (dcs *DefaultClusterService) RunNode(node *Node) error {
+	return dcs.RunNodeContext(context.Background(), node)
+}
+
+// RunNodeContext is RunNode which gives up waiting resource provider when ctx is done.
+// resource provider is called without lock, so slow provider does not block others.
+func (dcs *DefaultClusterService) RunNodeContext(ctx context.Context, node *Node) error {
+	dcs.mu.RLock()
+	owned := dcs.ownedNode(node)
+	snapshot := owned.Clone()
+	err := checkRunNode(owned)
+	dcs.mu.RUnlock()
 	if err != nil {
-		return ""
+		return err
 	}
-	if dcs.maxNameI >= 100000 {
-		dcs.maxNameI = len(dcs.nodes)
+	err = dcs.runNodeOnProvider(ctx, owned, snapshot)
+	dcs.mu.RLock()
+	copyNode(node, owned)
+	dcs.mu.RUnlock()
+	return err
+}
+
+// runNodeOnProvider run snapshot of node by its resource provider without lock, then apply the result to owned under lock.
+// the result is applied even after ctx is done not to lose resources launched by provider,
+// and they are removed if the node has been changed meanwhile.
+func (dcs *DefaultClusterService) runNodeOnProvider(ctx context.Context, owned *Node, snapshot *Node) error {
+	// buffered not to leak provider call finished after ctx is done
+	ran := make(chan error, 1)
+	go func() {
+		resourceInfo, err := snapshot.ResourceProvider.RunNode(snapshot)
+		if err != nil {
+			ran <- err
+			return
+		}
+		dcs.mu.Lock()
+		// node may be changed while running without lock
+		if err = checkRunNode(owned); err == nil {
+			err = dcs.applyRunNode(owned, resourceInfo)
+		}
+		dcs.mu.Unlock()
+		if err != nil {
+			if resourceInfo != nil {
+				snapshot.ResourceInfo = *resourceInfo
+			}
+			if removeErr := snapshot.ResourceProvider.RemoveNode(snapshot); removeErr != nil {
+				err = errors.Join(err, removeErr)
+			}
+		}
+		ran <- err
+	}()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-ran:
+		return err
 	}
-	var name string
-	valid := false
-	for i := dcs.maxNameI + 1; !valid || i < 100000; i++ {
-		name := fmt.Sprintf("node-%d", i)
-		for _, node := range nodes {
-			if node.Name != name {
-				valid = true
-				dcs.maxNameI = i
-				break
+}
+
+// checkRunNode returns error if node can not be run by its resource provider.
+func checkRunNode(node *Node) error {
+	if node.NodeState == NodeRunning {
+		return fmt.Errorf("%w:%v", ErrAlreadyRunning, node.Name)
+	}
+	if err := checkNodeTransition(node.NodeState, NodeRunning); err != nil {
+		return err
+	}
+	if node.ResourceProvider == nil {
+		return ErrNoResourceProvider
+	}
+	return nil
+}
+
+// applyRunNode move node run by its resource provider to running, with its status.
+func (dcs *DefaultClusterService) applyRunNode(node *Node, resourceInfo *ResourceInfo) error {
+	if resourceInfo != nil {
+		node.ResourceInfo = *resourceInfo
+	}
+
+	nodeStatus := dcs.findNodeStatusById(node.Id)
+	if nodeStatus == nil {
+		nodeStatus = &NodeStatus{
+			Id:        node.Id,
+			Name:      node.Name,
+			Namespace: node.Namespace,
+			CreatedAt: time.Now(),
+		}
+		dcs.nodeStatuses = append(dcs.nodeStatuses, nodeStatus)
+	}
+	// status follows node state, which is checked before
+	nodeStatus.NodeState = node.NodeState
+	if err := TransitionNode(nodeStatus, NodeRunning); err != nil {
+		return err
+	}
+	node.NodeState = NodeRunning
+	nodeStatus.Reason = "started by RunNode"
+	nodeStatus.LastHeartbeat = time.Now()
+	dcs.emit(EventNodeJoined, node.Id)
+	return nil
+}
+
+// KillNode stop node by its resource provider, if it is not stopped in gracePeriod(ms), remove it.
+// 0 removes it immediately without stopping.
+func (dcs *DefaultClusterService) KillNode(runningNode Node, gracePeriod int) error {
+	return dcs.KillNodeContext(context.Background(), runningNode, gracePeriod)
+}
+
+// KillNodeContext is KillNode which gives up waiting resource provider when ctx is done.
+// resource provider is called without lock, and node is recorded exited even after ctx is done if it is killed.
+func (dcs *DefaultClusterService) KillNodeContext(ctx context.Context, runningNode Node, gracePeriod int) error {
+	if gracePeriod < 0 {
+		return fmt.Errorf("negative grace period:%v", gracePeriod)
+	}
+	dcs.mu.RLock()
+	node := dcs.findNodeById(runningNode.Id)
+	err := checkKillNode(node, runningNode.Id)
+	snapshot := node.Clone()
+	dcs.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	// buffered not to leak provider call finished after ctx is done
+	killed := make(chan error, 1)
+	go func() {
+		err := stopNode(snapshot, time.Duration(gracePeriod)*time.Millisecond)
+		if err == nil {
+			dcs.mu.Lock()
+			// node may be changed while stopping without lock
+			node := dcs.findNodeById(runningNode.Id)
+			if err = checkKillNode(node, runningNode.Id); err == nil {
+				err = dcs.applyKillNode(node)
 			}
+			dcs.mu.Unlock()
+		}
+		killed <- err
+	}()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-killed:
+		return err
+	}
+}
+
+// checkKillNode returns error if node of uid can not be killed by its resource provider.
+func checkKillNode(node *Node, uid UID) error {
+	if node == nil {
+		return fmt.Errorf("%w for uid:%v", ErrNodeNotFound, uid)
+	}
+	// draining node is killed after its containers moved, unreachable node may be still alive
+	if node.NodeState != NodeRunning && node.NodeState != NodeDraining && node.NodeState != NodeUnreachable {
+		return fmt.Errorf("%w:%v", ErrNotRunning, node.Name)
+	}
+	if node.ResourceProvider == nil {
+		return ErrNoResourceProvider
+	}
+	return nil
+}
+
+// stopNode stop node by its resource provider and wait for it up to gracePeriod, then remove node if not stopped.
+func stopNode(node *Node, gracePeriod time.Duration) error {
+	if gracePeriod == 0 {
+		return node.ResourceProvider.RemoveNode(node)
+	}
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- node.ResourceProvider.StopNode(node)
+	}()
+	timer := time.NewTimer(gracePeriod)
+	defer timer.Stop()
+	select {
+	case err := <-stopped:
+		return err
+	case <-timer.C:
+		return node.ResourceProvider.RemoveNode(node)
+	}
+}
+
+// applyKillNode move node killed by its resource provider to exited, with its status.
+func (dcs *DefaultClusterService) applyKillNode(node *Node) error {
+	if nodeStatus := dcs.findNodeStatusById(node.Id); nodeStatus != nil {
+		nodeStatus.NodeState = node.NodeState
+		if err := TransitionNode(nodeStatus, NodeExited); err != nil {
+			return err
+		}
+		nodeStatus.Reason = "killed by KillNode"
+	}
+	node.NodeState = NodeExited
+	dcs.emit(EventNodeLeft, node.Id)
+	return nil
+}
+
+// RemoveNode remove node from its resource provider and cluster with its status.
+// node which still has containers can not be removed.
+func (dcs *DefaultClusterService) RemoveNode(uid UID) error {
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	node := dcs.findNodeById(uid)
+	if node == nil {
+		return fmt.Errorf("%w for uid:%v", ErrNodeNotFound, uid)
+	}
+	blocking := []string{}
+	for _, c := range dcs.containersByNode[uid] {
+		blocking = append(blocking, string(c.Id))
+	}
+	if len(blocking) > 0 {
+		return fmt.Errorf("%w, node:%v, containers:%v", ErrNodeHasContainers, node.Name, strings.Join(blocking, ","))
+	}
+	if node.ResourceProvider != nil {
+		if err := node.ResourceProvider.RemoveNode(node); err != nil {
+			return err
+		}
+	}
+	nodes := Nodes{}
+	for _, n := range dcs.nodes {
+		if n.Id != uid {
+			nodes = append(nodes, n)
+		}
+	}
+	nodeStatuses := NodeStatuses{}
+	for _, ns := range dcs.nodeStatuses {
+		if ns.Id != uid {
+			nodeStatuses = append(nodeStatuses, ns)
+		}
+	}
+	dcs.nodes = nodes
+	dcs.nodeStatuses = nodeStatuses
+	delete(dcs.nodesById, uid)
+	dcs.launches.forget(uid)
+	if key := (nameKey{namespace: node.Namespace, name: node.Name}); dcs.nodesByName[key] == node {
+		delete(dcs.nodesByName, key)
+	}
+	return nil
+}
+
+// minWorkingNode returns running node selected by scheduler to place container.
+// the node container is currently placed on is not selected.
+func (dcs *DefaultClusterService) minWorkingNode(container *Container) (*Node, error) {
+	dcs.refreshAllocated()
+	nodes := []*Node{}
+	for _, node := range dcs.nodes {
+		if node.Running() && node.Schedulable() && !dcs.underPressure(node) && node.Id != container.NodeId {
+			nodes = append(nodes, node)
+		}
+	}
+	if len(nodes) == 0 {
+		return nil, ErrNoValidNode
+	}
+	nodes = dcs.constrainedNodes(nodes, container)
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("%w, nodeAffinity:%v, antiAffinity:%v, tolerations:%v",
+			ErrUnsatisfiedConstraints, container.Spec.NodeAffinity, container.Spec.AntiAffinity, container.Spec.Tolerations)
+	}
+	return dcs.scheduler.Select(nodes, container)
+}
+
+// FlushNodes drop statuses of removed nodes and sync node state into statuses.
+// heartbeat of running nodes is recorded if their resource provider finds them alive,
+// then conditions of nodes are computed from their usage and thresholds.
+// resource providers are called without lock, nodes changed while probing are flushed next time.
+func (dcs *DefaultClusterService) FlushNodes() error {
+	type probe struct {
+		node, snapshot *Node
+		status         *NodeStatus
+		err            error
+	}
+	probes := []*probe{}
+	dcs.mu.Lock()
+	nodeStatuses := NodeStatuses{}
+	for _, ns := range dcs.nodeStatuses {
+		node := dcs.findNodeById(ns.Id)
+		if node == nil {
+			continue
+		}
+		if node.NodeState != "" && ns.NodeState != node.NodeState {
+			ns.NodeState = node.NodeState
+			ns.Reason = "flushed by FlushNodes"
+		}
+		if node.NodeState == NodeRunning || node.NodeState == NodeUnreachable {
+			probes = append(probes, &probe{node: node, snapshot: node.Clone(), status: ns})
+		} else {
+			dcs.updateConditions(node, ns, false)
+		}
+		nodeStatuses = append(nodeStatuses, ns)
+	}
+	dcs.nodeStatuses = nodeStatuses
+	dcs.mu.Unlock()
+
+	for _, p := range probes {
+		p.err = probeNode(p.snapshot)
+	}
+
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	for _, p := range probes {
+		if dcs.findNodeById(p.node.Id) != p.node || dcs.findNodeStatusById(p.node.Id) != p.status ||
+			p.node.NodeState != p.snapshot.NodeState {
+			continue
+		}
+		alive := dcs.recordProbe(p.node, p.status, p.err)
+		dcs.updateConditions(p.node, p.status, alive)
+	}
+	return nil
+}
+
+// FlushContainers drop statuses of removed containers and refresh statuses by inspecting runtime.
+func (dcs *DefaultClusterService) FlushContainers() error {
+	return dcs.FlushContainersContext(context.Background())
+}
+
+// FlushContainersContext is FlushContainers which passes ctx to inspect runtime.
+// runtime is inspected without lock, containers changed or in flight while inspecting are refreshed next time.
+func (dcs *DefaultClusterService) FlushContainersContext(ctx context.Context) error {
+	type inspection struct {
+		container, snapshot *Container
+		node                *Node
+		inspected           *ContainerStatus
+		err                 error
+	}
+	inspections := []*inspection{}
+	dcs.mu.Lock()
+	ids := make(map[UID]bool, len(dcs.containers))
+	for _, c := range dcs.containers {
+		ids[c.Id] = true
+	}
+	containerStatuses := ContainerStatuses{}
+	listed := make(map[*ContainerStatus]bool, len(dcs.containerStatuses))
+	for _, cs := range dcs.containerStatuses {
+		if ids[cs.Id] {
+			containerStatuses = append(containerStatuses, cs)
+			listed[cs] = true
+		}
+	}
+	for _, c := range dcs.containers {
+		if c.ContainerStatus == nil {
+			c.ContainerStatus = NewContainerStatus(c.Id, c.Name, c.NodeName)
+		}
+		if !listed[c.ContainerStatus] {
+			containerStatuses = append(containerStatuses, c.ContainerStatus)
+		}
+		if c.Hash == "" || dcs.inFlight[c.Id] {
+			continue
+		}
+		if node := dcs.findNodeById(c.NodeId); node != nil && node.Client != nil {
+			inspections = append(inspections, &inspection{container: c, snapshot: c.Clone(), node: node.Clone()})
+		}
+	}
+	dcs.containerStatuses = containerStatuses
+	dcs.reindexContainerStatuses()
+	dcs.mu.Unlock()
+
+	for _, i := range inspections {
+		i.inspected, i.err = i.node.Client.Inspect(ctx, i.snapshot)
+	}
+
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	for _, i := range inspections {
+		c := i.container
+		if dcs.findContainerById(c.Id) != c || dcs.inFlight[c.Id] || c.Hash != i.snapshot.Hash ||
+			c.ResourceVersion != i.snapshot.ResourceVersion || c.ContainerStatus == nil {
+			continue
+		}
+		dcs.applyInspection(c, i.inspected, i.err)
+	}
+	return nil
+}
+
+// applyInspection refresh container status by the result of inspecting its node's client, caller must hold lock.
+// errors are recorded into the status, not returned.
+func (dcs *DefaultClusterService) applyInspection(container *Container, inspected *ContainerStatus, err error) {
+	if err != nil {
+		container.ContainerStatus.Error = err
+		return
+	}
+	previous := container.ContainerStatus.ContainerState
+	// health is checked by cluster, not by runtime
+	inspected.Health = container.ContainerStatus.Health
+	inspected.Liveness = container.ContainerStatus.Liveness
+	inspected.Readiness = container.ContainerStatus.Readiness
+	inspected.History = container.ContainerStatus.History
+	if previous != inspected.ContainerState {
+		reason := inspected.Reason
+		if inspected.Error != nil {
+			reason = fmt.Sprintf("%v: %v", reason, inspected.Error)
 		}
+		inspected.recordHistory(StatusEvent{Time: time.Now(), From: previous, To: inspected.ContainerState, Reason: reason})
 	}
-	if valid {
-		return name
-	} else {
+	*container.ContainerStatus = *inspected
+	if previous != ContainerExited && inspected.ContainerState == ContainerExited {
+		dcs.emit(EventContainerExited, container.Id)
+	} else if previous != inspected.ContainerState {
+		dcs.bumpContainer(container)
+	}
+}
+
+// max number used in generated node name, node-1 ... node-99999
+const maxNodeNameI = 100000
+
+// genNodeName returns the next unused name in namespace formatted node-N, or "" if all names are used.
+func (dcs *DefaultClusterService) genNodeName(namespace string) string {
+	names := dcs.genNodeNames(namespace, 1)
+	if names == nil {
 		return ""
 	}
+	return names[0]
+}
+
+// genNodeNames returns count of next unused names in namespace formatted node-N, distinct each other,
+// or nil if not enough names are left. maxNameI is moved only if all names are found.
+func (dcs *DefaultClusterService) genNodeNames(namespace string, count int) []string {
+	used := make(map[string]bool, len(dcs.nodes))
+	for _, node := range dcs.nodes {
+		if node.Namespace == namespace {
+			used[node.Name] = true
+		}
+	}
+	names := make([]string, 0, count)
+	i := dcs.maxNameI
+	for n := 1; n < maxNodeNameI && len(names) < count; n++ {
+		i = i%(maxNodeNameI-1) + 1
+		name := fmt.Sprintf("node-%d", i)
+		if !used[name] {
+			names = append(names, name)
+		}
+	}
+	if len(names) < count {
+		return nil
+	}
+	dcs.maxNameI = i
+	return names
+}
+
+// genContainerName returns name formatted prefix-N unused on the node, prefix is last part of image name.
+func (dcs *DefaultClusterService) genContainerName(nodeId UID, image *Image) string {
+	prefix := "container"
+	if image != nil && image.Name != "" {
+		prefix = image.Name[strings.LastIndex(image.Name, "/")+1:]
+	}
+	names := make(map[string]bool)
+	for _, c := range dcs.containersByNode[nodeId] {
+		names[c.Name] = true
+	}
+	for i := 1; ; i++ {
+		name := fmt.Sprintf("%s-%d", prefix, i)
+		if !names[name] {
+			return name
+		}
+	}
 }
 
 func (dcs *DefaultClusterService) findNodeById(id UID) *Node {
 	return dcs.nodesById[id]
 }
 
-func (dcs *DefaultClusterService) findNodeByName(name string) *Node {
-	return dcs.nodesByName[name]
+func (dcs *DefaultClusterService) findNodeByName(namespace string, name string) *Node {
+	return dcs.nodesByName[nameKey{namespace: namespace, name: name}]
+}
+
+func (dcs *DefaultClusterService) findContainerById(id UID) *Container {
+	return dcs.containersById[id]
+}
+
+// containerStatusKey is key of container status unique in cluster.
+type containerStatusKey struct {
+	nameKey
+	nodeName string
+}
+
+func containerStatusKeyOf(cs *ContainerStatus) containerStatusKey {
+	return containerStatusKey{nameKey: nameKey{namespace: cs.Namespace, name: cs.Name}, nodeName: cs.NodeName}
+}
+
+// findContainerStatus lookup status by uid, then by name and nodeName in default namespace.
+func (dcs *DefaultClusterService) findContainerStatus(uid UID, name string, nodeName string) *ContainerStatus {
+	if cs, ok := dcs.containerStatusesById[uid]; ok {
+		return cs
+	}
+	if name == "" || nodeName == "" {
+		return nil
+	}
+	return dcs.containerStatusesByName[containerStatusKey{nameKey: nameKey{namespace: DefaultNamespace, name: name}, nodeName: nodeName}]
+}
+
+func (dcs *DefaultClusterService) indexContainerStatus(cs *ContainerStatus) {
+	dcs.containerStatusesById[cs.Id] = cs
+	dcs.containerStatusesByName[containerStatusKeyOf(cs)] = cs
+}
+
+func (dcs *DefaultClusterService) unindexContainerStatus(cs *ContainerStatus) {
+	if dcs.containerStatusesById[cs.Id] == cs {
+		delete(dcs.containerStatusesById, cs.Id)
+	}
+	key := containerStatusKeyOf(cs)
+	if dcs.containerStatusesByName[key] == cs {
+		delete(dcs.containerStatusesByName, key)
+	}
+}
+
+func (dcs *DefaultClusterService) indexContainer(c *Container) {
+	dcs.containersByNode[c.NodeId] = append(dcs.containersByNode[c.NodeId], c)
+	dcs.containersById[c.Id] = c
+}
+
+func (dcs *DefaultClusterService) unindexContainer(c *Container) {
+	if dcs.containersById[c.Id] == c {
+		delete(dcs.containersById, c.Id)
+	}
+	containers := Containers{}
+	for _, indexed := range dcs.containersByNode[c.NodeId] {
+		if indexed != c {
+			containers = append(containers, indexed)
+		}
+	}
+	if len(containers) == 0 {
+		delete(dcs.containersByNode, c.NodeId)
+		return
+	}
+	dcs.containersByNode[c.NodeId] = containers
+}
+
+// reindexContainers rebuild index from containers.
+func (dcs *DefaultClusterService) reindexContainers() {
+	dcs.containersByNode = make(map[UID]Containers)
+	dcs.containersById = make(map[UID]*Container, len(dcs.containers))
+	for _, c := range dcs.containers {
+		dcs.indexContainer(c)
+	}
+}
+
+// reindexContainerStatuses rebuild indexes from containerStatuses.
+func (dcs *DefaultClusterService) reindexContainerStatuses() {
+	dcs.containerStatusesById = make(map[UID]*ContainerStatus, len(dcs.containerStatuses))
+	dcs.containerStatusesByName = make(map[containerStatusKey]*ContainerStatus, len(dcs.containerStatuses))
+	for _, cs := range dcs.containerStatuses {
+		dcs.indexContainerStatus(cs)
+	}
+}
+
+func (dcs *DefaultClusterService) findNodeStatusById(id UID) *NodeStatus {
+	for _, ns := range dcs.nodeStatuses {
+		if ns.Id == id {
+			return ns
+		}
+	}
+	return nil
 }
 
 type Image struct {
-	// name of container image
+	// name of container image, same as Repository
 	Name string
-	// full name of container image, formatted: registory/name:tag
+	// full name of container image, formatted: registry/repository:tag@digest
 	FullName string
+	// registry host[:port], empty for default registry
+	Registry string
+	// repository path without registry
+	Repository string
+	// tag, optional
+	Tag string
+	// digest formatted algorithm:hex, optional
+	Digest string
 }
 
+// NewImage parse image reference formatted [registry[:port]/]repository[:tag][@digest].
 func NewImage(fullName string) (*Image, error) {
-	index := strings.Index(fullName, ":")
-	if index < 0 {
-		return nil, errors.New("not found sep \":\"")
+	remain := fullName
+	digest := ""
+	if index := strings.Index(remain, "@"); index >= 0 {
+		digest = remain[index+1:]
+		remain = remain[:index]
+		if !strings.Contains(digest, ":") || strings.HasPrefix(digest, ":") || strings.HasSuffix(digest, ":") {
+			return nil, fmt.Errorf("invalid digest:%v", digest)
+		}
+	}
+
+	registry := ""
+	if index := strings.Index(remain, "/"); index >= 0 {
+		host := remain[:index]
+		if strings.ContainsAny(host, ".:") || host == "localhost" {
+			registry = host
+			remain = remain[index+1:]
+		}
+	}
+
+	tag := ""
+	if index := strings.LastIndex(remain, ":"); index >= 0 && index > strings.LastIndex(remain, "/") {
+		tag = remain[index+1:]
+		remain = remain[:index]
 	}
-	if strings.Index(fullName, ":") == 0 {
+	if remain == "" {
 		return nil, errors.New("name not found")
 	}
-	splitList := strings.Split(fullName, ":")
-	return &Image{
-		Name:     splitList[0],
-		FullName: fullName,
-	}, nil
+
+	image := &Image{
+		Name:       remain,
+		Registry:   registry,
+		Repository: remain,
+		Tag:        tag,
+		Digest:     digest,
+	}
+	image.FullName = image.String()
+	return image, nil
+}
+
+// String returns canonical reference of the image.
+func (i *Image) String() string {
+	ref := i.Repository
+	if i.Registry != "" {
+		ref = i.Registry + "/" + ref
+	}
+	if i.Tag != "" {
+		ref += ":" + i.Tag
+	}
+	if i.Digest != "" {
+		ref += "@" + i.Digest
+	}
+	return ref
 }
 
 type Container struct {
@@ -253,6 +1503,8 @@ type Container struct {
 	Id UID
 	// container name on node
 	Name string
+	// namespace isolating container, DefaultNamespace if not specified
+	Namespace string
 	// container hash on node
 	Hash string
 	// uuid of Node running on
@@ -267,6 +1519,16 @@ type Container struct {
 	ImageId string
 	// options for run
 	ContainerOptions ContainerOptions
+	// typed options for run
+	Spec ContainerSpec
+	// number of restarts by restart policy
+	RestartCount int
+	// killed by KillContainer, not restarted by unless-stopped policy
+	Killed bool
+	// labels to select container
+	Labels map[string]string
+	// bumped by cluster on every change, to detect stale update by UpdateContainerIf
+	ResourceVersion uint64
 }
 
 func NewContainer(id UID, name string, hash string, nodeId UID, nodeName string, image *Image, imageId string, options ContainerOptions) *Container {
@@ -274,6 +1536,7 @@ func NewContainer(id UID, name string, hash string, nodeId UID, nodeName string,
 	return &Container{
 		Id:               id,
 		Name:             name,
+		Namespace:        DefaultNamespace,
 		Hash:             hash,
 		NodeId:           nodeId,
 		NodeName:         nodeName,
@@ -284,6 +1547,11 @@ func NewContainer(id UID, name string, hash string, nodeId UID, nodeName string,
 	}
 }
 
+// SetEnv set environment variable passed to container on run.
+func (c *Container) SetEnv(key, value string) error {
+	return c.Spec.SetEnv(key, value)
+}
+
 type Containers []*Container
 type ContainerOptions map[string]string
 
@@ -292,6 +1560,8 @@ type ContainerStatus struct {
 	Id UID
 	// name
 	Name string
+	// namespace of container
+	Namespace string
 	// nodeName
 	NodeName string
 	// container state
@@ -302,23 +1572,53 @@ type ContainerStatus struct {
 	StartedAt time.Time
 	// container finished
 	FinishedAt time.Time
+	// exit code of process, NoExitCode until container exited
+	ExitCode int
 	// reason of state
 	Reason string
 	// last message in container
 	Message string
-	// last error in container
-	Error error
+	// last error in container, serialized as its message
+	Error error `json:"-"`
+	// ports bound on host
+	Ports []PortMapping
+	// volumes mounted, reported by runtime
+	Volumes []VolumeMount
+	// result of health check, empty if container has no health check
+	Health Health
+	// result of liveness probe, empty if container has no liveness probe
+	Liveness Health
+	// result of readiness probe, empty if container has no readiness probe
+	Readiness Health
+	// recent transitions, oldest first, up to maxStatusHistory
+	History []StatusEvent
+}
+
+// NoExitCode is ExitCode of container which is not exited, or exited with code unknown.
+const NoExitCode = -1
+
+// Failed returns true if container exited with non-zero code, or with error if its code is unknown.
+func (cs *ContainerStatus) Failed() bool {
+	if cs.ContainerState != ContainerExited {
+		return false
+	}
+	if cs.ExitCode != NoExitCode {
+		return cs.ExitCode != 0
+	}
+	return cs.Error != nil
 }
 
 func NewContainerStatus(id UID, name, nodeName string) *ContainerStatus {
 	return &ContainerStatus{
 		Id:             id,
 		Name:           name,
+		Namespace:      DefaultNamespace,
 		NodeName:       nodeName,
 		ContainerState: ContainerUnknown,
 		CreatedAt:      time.Time{},
 		StartedAt:      time.Time{},
 		FinishedAt:     time.Time{},
+		ExitCode:       NoExitCode,
 		Reason:         "created by NewContainerStatus",
 		Message:        "",
 		Error:          nil,
@@ -330,14 +1630,58 @@ type ContainerState string
 
 const (
 	ContainerUnknown ContainerState = "unknown"
+	// created without node, waiting for node which can take it
+	ContainerPending ContainerState = "pending"
 	ContainerCreated ContainerState = "created"
 	ContainerRunning ContainerState = "running"
 	ContainerExited  ContainerState = "exited"
+	// running container whose processes are suspended, it is still alive
+	ContainerPaused ContainerState = "paused"
 )
 
-type ContainerClient interface{}
+// DefaultStopGracePeriod is grace period to stop container used by KillContainers and DrainNode.
+const DefaultStopGracePeriod = 10 * time.Second
 
-//type DefaultContainerClient
+// ContainerClient operates containers on runtime of a node.
+// methods should return ctx.Err() promptly when ctx is done.
+type ContainerClient interface {
+	// create and start container, returns container hash on node
+	Run(ctx context.Context, container *Container) (string, error)
+	// stop running container, force kill it if not stopped in gracePeriod.
+	// gracePeriod 0 means kill immediately.
+	Stop(ctx context.Context, container *Container, gracePeriod time.Duration) error
+	// suspend processes of running container
+	Pause(ctx context.Context, container *Container) error
+	// resume processes of paused container
+	Unpause(ctx context.Context, container *Container) error
+	// get local id of image on node, ErrImageNotFound if it is not pulled yet
+	ImageId(ctx context.Context, image *Image) (string, error)
+	// pull image from its registry to node
+	PullImage(ctx context.Context, image *Image) error
+	// list images on node, by each of their tags or digests if untagged
+	ListImages(ctx context.Context) ([]*Image, error)
+	// remove image from node
+	RemoveImage(ctx context.Context, image *Image) error
+	// get current container status from runtime
+	Inspect(ctx context.Context, container *Container) (*ContainerStatus, error)
+	// remove stopped container
+	Remove(ctx context.Context, container *Container) error
+	// stream stdout and stderr of container selected by options, keep streaming until closed or ctx is done if follow
+	Logs(ctx context.Context, container *Container, options LogsOptions) (io.ReadCloser, error)
+	// run command in running container, returns its output and exit code.
+	// err is returned only if the command could not be run.
+	Exec(ctx context.Context, container *Container, cmd []string) (stdout string, stderr string, exitCode int, err error)
+	// get current resource usage of running container
+	Stats(ctx context.Context, container *Container) (*ContainerStats, error)
+	// change resource limits of running container without restarting it
+	Update(ctx context.Context, container *Container, limits Capacity) error
+	// get version of runtime daemon serving the client
+	RuntimeVersion(ctx context.Context) (Version, error)
+	// stream stdin into main process of running container and its output into stdout and stderr,
+	// until the output is closed or ctx is done. stdin may be nil not to send input,
+	// input is delivered only to container whose spec has Stdin.
+	Attach(ctx context.Context, container *Container, stdin io.Reader, stdout, stderr io.Writer) error
+}
 
 // Node is a machine hosting container.
 type Node struct {
@@ -345,14 +1689,43 @@ type Node struct {
 	Id UID
 	// name for human
 	Name string
+	// namespace isolating node, DefaultNamespace if not specified
+	Namespace string
 	// current state
 	NodeState NodeState
-	// container operation client
-	Client ContainerClient
+	// container operation client, not serialized
+	Client ContainerClient `json:"-"`
 	// resource info for provider, not managed by cluster
 	ResourceInfo ResourceInfo
-	// resource provider
-	ResourceProvider ResourceProvider
+	// resource provider, not serialized
+	ResourceProvider ResourceProvider `json:"-"`
+	// resources for containers
+	Capacity Capacity
+	// sum of resource requests of containers placed on node, updated by cluster
+	Allocated Capacity
+	// number of containers placed on node, updated by cluster
+	ContainerCount int
+	// labels to select node
+	Labels map[string]string
+	// scheduler does not place new containers on node if true
+	Unschedulable bool
+	// taints repelling containers not tolerating them
+	Taints []Taint
+	// max number of concurrent Run calls to runtime of node, 0 for no limit
+	MaxConcurrentLaunches int
+	// bumped by cluster on every change, to detect stale update by UpdateNodeIf
+	ResourceVersion uint64
+}
+
+// Running returns true if node is running, not created, unreachable, draining nor exited.
+// node state is the source of truth, its status follows it by transitions and FlushNodes.
+func (n *Node) Running() bool {
+	return n.NodeState == NodeRunning
+}
+
+// Schedulable returns true if scheduler may place new containers on node.
+func (n *Node) Schedulable() bool {
+	return !n.Unschedulable
 }
 
 // Status of Node
@@ -360,7 +1733,8 @@ type NodeStatus struct {
 	// uuid
 	Id UID
 	// name
-	Name      string
+	Name string
+	// namespace of node
 	Namespace string
 	// node state
 	NodeState NodeState
@@ -370,18 +1744,22 @@ type NodeStatus struct {
 	StartedAt time.Time
 	// node finished
 	FinishedAt time.Time
+	// last time node was found alive by FlushNodes
+	LastHeartbeat time.Time
 	// reason of state
 	Reason string
 	// last message in node
 	Message string
-	// last error in node
-	Error error
+	// last error in node, serialized as its message
+	Error error `json:"-"`
 	// Load
 	LoadAverage float64
-	// memory, MB
+	// available memory, MB
 	Memory int
-	// Disk, GB
+	// available disk, GB
 	Disk int
+	// conditions computed by FlushNodes
+	Conditions []NodeCondition
 }
 
 type Nodes []*Node
@@ -394,8 +1772,13 @@ const (
 	NodeCreated NodeState = "created"
 	NodeRunning NodeState = "running"
 	NodeExited  NodeState = "exited"
+	// running node whose heartbeat is missing, it may recover to running
+	NodeUnreachable NodeState = "unreachable"
+	// running node whose containers are moving to other nodes, before it exits
+	NodeDraining NodeState = "draining"
 )
 
+// ResourceInfo is data of node given by resource provider, use its accessors for well-known keys.
 type ResourceInfo map[string]string
 
 type ResourceProvider interface {
@@ -404,18 +1787,91 @@ type ResourceProvider interface {
 	RemoveNode(*Node) error
 }
 
-// TODO
+// RunContainer run container by the node's client.
 func (n *Node) RunContainer(container *Container) error {
-	//n.Client.Run(contaner)
+	return n.RunContainerContext(context.Background(), container)
+}
+
+// RunContainerContext is RunContainer with ctx passed to the client.
+func (n *Node) RunContainerContext(ctx context.Context, container *Container) error {
+	if err := checkContainerTransition(container.ContainerStatus.ContainerState, ContainerRunning); err != nil {
+		return err
+	}
+	ran, err := n.runOnClient(ctx, container)
+	if err != nil {
+		return err
+	}
+	return ran.apply(container)
+}
+
+// runResult is result of running container on client, applied to container after.
+type runResult struct {
+	hash string
+	// local id of image, empty if image is not set
+	imageId string
+	// bound ports, nil if not inspected
+	ports []PortMapping
+}
+
+// runOnClient run container by the client without changing container,
+// so that it can be called without lock of the service.
+func (n *Node) runOnClient(ctx context.Context, container *Container) (*runResult, error) {
+	if n.Client == nil {
+		return nil, fmt.Errorf("%w:%v", ErrNodeHasNoClient, n.Name)
+	}
+	imageId, err := n.ensureImage(ctx, container.Image)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := n.Client.Run(ctx, container)
+	if err != nil {
+		return nil, err
+	}
+	ran := &runResult{hash: hash, imageId: imageId}
+	if len(container.Spec.Ports) > 0 {
+		// runtime may pick host port, so report back actually bound ports
+		if inspected, err := n.Client.Inspect(ctx, container); err == nil {
+			ran.ports = inspected.Ports
+		}
+	}
+	return ran, nil
+}
+
+// apply record result of run to container, or returns error without changing it if it can not be running.
+func (ran *runResult) apply(container *Container) error {
+	if err := checkContainerTransition(containerStateOf(container), ContainerRunning); err != nil {
+		return err
+	}
+	container.Hash = ran.hash
+	if ran.imageId != "" {
+		container.ImageId = ran.imageId
+	}
+	container.Killed = false
+	if err := TransitionContainer(container.ContainerStatus, ContainerRunning); err != nil {
+		return err
+	}
+	if ran.ports != nil {
+		container.ContainerStatus.Ports = ran.ports
+	}
 	return nil
 }
 
-func genUID() UID {
-	return uuidToUID(uuid.New())
+// KillContainer stop container by the node's client, which kills it if not stopped in gracePeriod.
+func (n *Node) KillContainer(container *Container, gracePeriod time.Duration) error {
+	return n.KillContainerContext(context.Background(), container, gracePeriod)
 }
 
-func uuidToUID(uuid uuid.UUID) UID {
-	var str interface{}
-	str = uuid.String()
-	return str.(UID)
+// KillContainerContext is KillContainer with ctx passed to the client.
+func (n *Node) KillContainerContext(ctx context.Context, container *Container, gracePeriod time.Duration) error {
+	if err := checkContainerTransition(container.ContainerStatus.ContainerState, ContainerExited); err != nil {
+		return err
+	}
+	if n.Client == nil {
+		return fmt.Errorf("%w:%v", ErrNodeHasNoClient, n.Name)
+	}
+	if err := n.Client.Stop(ctx, container, gracePeriod); err != nil {
+		return err
+	}
+	container.Killed = true
+	return transitionContainer(container.ContainerStatus, ContainerExited, "killed by KillContainer")
 }
@@ -1,8 +1,14 @@
 package cluster
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"io"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -19,13 +25,44 @@ type testImageData struct {
 
 func TestNewImage(t *testing.T) {
 	dataList := []testImageData{
-		testImageData{"image:tag", &Image{"image", "image:tag"}, nil},
+		testImageData{"image:tag", &Image{Name: "image", FullName: "image:tag", Repository: "image", Tag: "tag"}, nil},
 		testImageData{":tag", nil, errors.New("name not found")},
-		testImageData{"i:", &Image{"i", "i:"}, nil},
+		testImageData{"i:", &Image{Name: "i", FullName: "i", Repository: "i"}, nil},
+		testImageData{"image", &Image{Name: "image", FullName: "image", Repository: "image"}, nil},
+		testImageData{
+			"registry.example.com:5000/team/app:v1",
+			&Image{Name: "team/app", FullName: "registry.example.com:5000/team/app:v1", Registry: "registry.example.com:5000", Repository: "team/app", Tag: "v1"},
+			nil,
+		},
+		testImageData{
+			"localhost/app",
+			&Image{Name: "app", FullName: "localhost/app", Registry: "localhost", Repository: "app"},
+			nil,
+		},
+		testImageData{
+			"team/app:v1",
+			&Image{Name: "team/app", FullName: "team/app:v1", Repository: "team/app", Tag: "v1"},
+			nil,
+		},
+		testImageData{
+			"app@sha256:abc",
+			&Image{Name: "app", FullName: "app@sha256:abc", Repository: "app", Digest: "sha256:abc"},
+			nil,
+		},
+		testImageData{
+			"registry.example.com:5000/team/app:v1@sha256:abc",
+			&Image{Name: "team/app", FullName: "registry.example.com:5000/team/app:v1@sha256:abc", Registry: "registry.example.com:5000", Repository: "team/app", Tag: "v1", Digest: "sha256:abc"},
+			nil,
+		},
+		testImageData{"app@sha256", nil, errors.New("invalid digest:sha256")},
+		testImageData{"registry.example.com:5000/:v1", nil, errors.New("name not found")},
 	}
 	for _, data := range dataList {
 		image, err := NewImage(data.input)
-		if data.error != nil && err.Error() != data.error.Error() {
+		if data.error != nil && (err == nil || err.Error() != data.error.Error()) {
+			t.Fatalf("err:%v, input:%v", err, data.input)
+		}
+		if data.error == nil && err != nil {
 			t.Fatalf("err:%v, input:%v", err, data.input)
 		}
 		expected := data.output
@@ -54,6 +91,7 @@ var testContiner = &Container{
 		CreatedAt:      time.Time{},
 		StartedAt:      time.Time{},
 		FinishedAt:     time.Time{},
+		ExitCode:       NoExitCode,
 		Reason:         "created by NewContainerStatus",
 		Message:        "",
 		Error:          nil,
@@ -85,15 +123,24 @@ func TestDefaultClusterService_Image(t *testing.T) {
 func TestNewDefaultClusterService(t *testing.T) {
 	clusterService := NewDefaultClusterService("0.0.0", testImage)
 	expected := &DefaultClusterService{
-		version:    "0.0.0",
-		image:      testImage,
-		containers: Containers{},
-		containerStatuses: ContainerStatuses{},
-		nodes: Nodes{},
-		nodeStatuses: NodeStatuses{},
-		nodesById: make(map[UID]*Node),
-		nodesByName: make(map[string]*Node),
-		maxNameI:0,
+		version:                 "0.0.0",
+		image:                   testImage,
+		containers:              Containers{},
+		containerStatuses:       ContainerStatuses{},
+		containersByNode:        make(map[UID]Containers),
+		containersById:          make(map[UID]*Container),
+		containerStatusesById:   make(map[UID]*ContainerStatus),
+		containerStatusesByName: make(map[containerStatusKey]*ContainerStatus),
+		nodes:                   Nodes{},
+		nodeStatuses:            NodeStatuses{},
+		nodesById:               make(map[UID]*Node),
+		nodesByName:             make(map[nameKey]*Node),
+		maxNameI:                0,
+		scheduler:               LeastLoadedScheduler{},
+		idGenerator:             UUIDGenerator{},
+		maxInFlight:             DefaultMaxInFlight,
+		heartbeatTimeout:        DefaultHeartbeatTimeout,
+		readyTimeout:            DefaultReadyTimeout,
 	}
 	if !reflect.DeepEqual(clusterService, expected) {
 		t.Errorf("%v, %v", clusterService, expected)
@@ -123,6 +170,7 @@ func TestDefaultClusterService_Containers(t *testing.T) {
 func TestDefaultClusterService_ContainerStatus(t *testing.T) {
 	clusterService := NewDefaultClusterService("0.0.0", testImage)
 	clusterService.containerStatuses = append(clusterService.containerStatuses, testContainerStatus)
+	clusterService.indexContainerStatus(testContainerStatus)
 	containerStatus, err := clusterService.ContainerStatus("id1", "", "")
 	if err != nil {
 		t.Fatal(err)
@@ -130,11 +178,13 @@ func TestDefaultClusterService_ContainerStatus(t *testing.T) {
 	expected := &ContainerStatus{
 		Id:             "id1",
 		Name:           "name1",
+		Namespace:      DefaultNamespace,
 		NodeName:       "nodeName1",
 		ContainerState: ContainerUnknown,
 		CreatedAt:      time.Time{},
 		StartedAt:      time.Time{},
 		FinishedAt:     time.Time{},
+		ExitCode:       NoExitCode,
 		Reason:         "created by NewContainerStatus",
 		Message:        "",
 		Error:          nil,
@@ -142,7 +192,162 @@ func TestDefaultClusterService_ContainerStatus(t *testing.T) {
 	if !reflect.DeepEqual(expected, containerStatus) {
 		t.Errorf("%v,%v", expected, containerStatus)
 	}
+	byName, err := clusterService.ContainerStatus("", "name1", "nodeName1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(testContainerStatus, byName) {
+		t.Errorf("want:%v,have:%v", testContainerStatus, byName)
+	}
+	if _, err := clusterService.ContainerStatus("id2", "name1", "nodeName2"); err == nil {
+		t.Error("want error for unknown container")
+	}
+
+}
+
+func TestDefaultClusterService_GetNode(t *testing.T) {
+	clusterService, _ := newTestRestartService(t)
+	container, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	nodes, _ := clusterService.Nodes(true)
+	node := nodes[0]
+
+	if have, err := clusterService.GetNode(node.Id); err != nil || !reflect.DeepEqual(node, have) {
+		t.Errorf("want:%v,have:%v,%v", node, have, err)
+	}
+	if have, err := clusterService.GetNodeByName(node.Name); err != nil || !reflect.DeepEqual(node, have) {
+		t.Errorf("want:%v,have:%v,%v", node, have, err)
+	}
+	if have, err := clusterService.GetContainer(container.Id); err != nil || !reflect.DeepEqual(container, have) {
+		t.Errorf("want:%v,have:%v,%v", container, have, err)
+	}
+	if _, err := clusterService.GetNode("unknown"); err == nil {
+		t.Error("want error for unknown node")
+	}
+	if _, err := clusterService.GetNodeByName("unknown"); err == nil {
+		t.Error("want error for unknown node name")
+	}
+	if _, err := clusterService.GetContainer("unknown"); err == nil {
+		t.Error("want error for unknown container")
+	}
+	// removed container leaves index
+	if err := clusterService.RemoveContainer(container.Id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := clusterService.GetContainer(container.Id); !errors.Is(err, ErrContainerNotFound) {
+		t.Errorf("want:%v,have:%v", ErrContainerNotFound, err)
+	}
+}
+
+func TestDefaultClusterService_Errors(t *testing.T) {
+	clusterService, _ := newTestRestartService(t)
+	container, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		err  error
+		want error
+	}{
+		{clusterService.RunContainer(container), ErrAlreadyRunning},
+		{clusterService.RemoveContainer(container.Id), ErrStillRunning},
+		{clusterService.RemoveContainer("unknown"), ErrContainerNotFound},
+		{clusterService.RemoveNode("unknown"), ErrNodeNotFound},
+		{clusterService.RemoveNode(container.NodeId), ErrNodeHasContainers},
+		{clusterService.RunNode(&Node{Name: "node"}), ErrNoResourceProvider},
+	}
+	for _, tt := range tests {
+		if !errors.Is(tt.err, tt.want) {
+			t.Errorf("want:%v,have:%v", tt.want, tt.err)
+		}
+	}
+	if _, err := clusterService.GetNodeByName("unknown"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("want:%v,have:%v", ErrNodeNotFound, err)
+	}
+}
 
+func TestDefaultClusterService_Status(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	if status, _ := clusterService.Status(); status.ClusterState != ClusterDown {
+		t.Errorf("want:%v,have:%v", ClusterDown, status)
+	}
+	client := NewFakeContainerClient("hash1")
+	node := prepareTestNode(t, clusterService, func(node *Node) {
+		node.ResourceProvider = &mockResourceProvider{}
+		node.Client = client
+	})
+	if status, _ := clusterService.Status(); status.ClusterState != ClusterRunning {
+		t.Errorf("want:%v,have:%v", ClusterRunning, status)
+	}
+	container, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	client.SetExited(container.Id, 1)
+	if err := clusterService.FlushContainers(); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := clusterService.Status(); status.ClusterState != ClusterDegraded {
+		t.Errorf("want:%v,have:%v", ClusterDegraded, status)
+	}
+
+	nodeStatus, err := clusterService.NodeStatus("", node.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nodeStatus.Id != node.Id || nodeStatus.NodeState != NodeRunning {
+		t.Errorf("%v", nodeStatus)
+	}
+	if _, err := clusterService.NodeStatus("unknown", ""); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("want:%v,have:%v", ErrNodeNotFound, err)
+	}
+	nodes, _ := clusterService.Nodes(false)
+	if len(nodes) != 1 || !reflect.DeepEqual(node, nodes[0]) {
+		t.Errorf("%v", nodes)
+	}
+}
+
+func TestDefaultClusterService_Nodes(t *testing.T) {
+	clusterService, _, nodes := newTestHeartbeatService(t)
+	created, _ := clusterService.CreateNode()
+	if err := clusterService.KillNode(*nodes[1], 1000); err != nil {
+		t.Fatal(err)
+	}
+	unreachable := prepareTestNode(t, clusterService, func(node *Node) { node.ResourceProvider = nodes[0].ResourceProvider })
+	partition(t, clusterService, unreachable)
+
+	tests := []struct {
+		name     string
+		all      bool
+		expected []UID
+	}{
+		{"all", true, []UID{nodes[0].Id, nodes[1].Id, created.Id, unreachable.Id}},
+		{"running", false, []UID{nodes[0].Id}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			have, err := clusterService.Nodes(tt.all)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids := []UID{}
+			for _, node := range have {
+				ids = append(ids, node.Id)
+			}
+			if !reflect.DeepEqual(ids, tt.expected) {
+				t.Errorf("want:%v,have:%v", tt.expected, ids)
+			}
+		})
+	}
+
+	// only running node is a candidate
+	clusterService.SetOptions(ContainerOptions{})
+	container, err := clusterService.CreateContainer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if container.NodeId != nodes[0].Id {
+		t.Errorf("want:%v,have:%v", nodes[0].Name, container.NodeName)
+	}
 }
 
 func TestNewContainerStatus(t *testing.T) {
@@ -152,11 +357,13 @@ func TestNewContainerStatus(t *testing.T) {
 	expected := &ContainerStatus{
 		Id:             "id1",
 		Name:           "name1",
+		Namespace:      DefaultNamespace,
 		NodeName:       "nodeName1",
 		ContainerState: ContainerUnknown,
 		CreatedAt:      time.Time{},
 		StartedAt:      time.Time{},
 		FinishedAt:     time.Time{},
+		ExitCode:       NoExitCode,
 		Reason:         "created by NewContainerStatus",
 		Message:        "",
 		Error:          nil,
@@ -177,21 +384,24 @@ func TestNewContainer(t *testing.T) {
 	containerOptions := ContainerOptions{"options": "option1"}
 	container := NewContainer(id, name, hash, nodeId, nodeName, testImage, "image1", containerOptions)
 	expected := &Container{
-		Id:       "id1",
-		Name:     "name1",
-		Hash:     "hash1",
-		NodeId:   "node1",
-		NodeName: "nodename1",
-		Image:    testImage,
-		ImageId:  "image1",
+		Id:        "id1",
+		Name:      "name1",
+		Namespace: DefaultNamespace,
+		Hash:      "hash1",
+		NodeId:    "node1",
+		NodeName:  "nodename1",
+		Image:     testImage,
+		ImageId:   "image1",
 		ContainerStatus: &ContainerStatus{
 			Id:             "id1",
 			Name:           "name1",
+			Namespace:      DefaultNamespace,
 			NodeName:       "nodename1",
 			ContainerState: ContainerUnknown,
 			CreatedAt:      time.Time{},
 			StartedAt:      time.Time{},
 			FinishedAt:     time.Time{},
+			ExitCode:       NoExitCode,
 			Reason:         "created by NewContainerStatus",
 			Message:        "",
 			Error:          nil,
@@ -202,3 +412,1106 @@ func TestNewContainer(t *testing.T) {
 		t.Errorf("%v,%v", expected, container)
 	}
 }
+
+type mockContainerClient struct {
+	ContainerClient
+	// guards recorded calls, as runtime is called concurrently
+	mu      sync.Mutex
+	hash    string
+	err     error
+	state   ContainerState
+	ports   []PortMapping
+	runs    Containers
+	stops   Containers
+	removes Containers
+	// env of containers passed to Run, at the time
+	envs [][]string
+	// containers passed to Pause and Unpause
+	pauses   Containers
+	unpauses Containers
+	// grace periods passed to Stop
+	gracePeriods []time.Duration
+}
+
+func (mcc *mockContainerClient) Run(ctx context.Context, container *Container) (string, error) {
+	mcc.mu.Lock()
+	defer mcc.mu.Unlock()
+	mcc.runs = append(mcc.runs, container)
+	mcc.envs = append(mcc.envs, container.Spec.Env)
+	return mcc.hash, mcc.err
+}
+
+func (mcc *mockContainerClient) Stop(ctx context.Context, container *Container, gracePeriod time.Duration) error {
+	mcc.mu.Lock()
+	defer mcc.mu.Unlock()
+	mcc.stops = append(mcc.stops, container)
+	mcc.gracePeriods = append(mcc.gracePeriods, gracePeriod)
+	return mcc.err
+}
+
+// ImageId returns image as already pulled.
+func (mcc *mockContainerClient) ImageId(ctx context.Context, image *Image) (string, error) {
+	return "", nil
+}
+
+func (mcc *mockContainerClient) PullImage(ctx context.Context, image *Image) error {
+	return mcc.err
+}
+
+func (mcc *mockContainerClient) Pause(ctx context.Context, container *Container) error {
+	mcc.mu.Lock()
+	defer mcc.mu.Unlock()
+	mcc.pauses = append(mcc.pauses, container)
+	return mcc.err
+}
+
+func (mcc *mockContainerClient) Unpause(ctx context.Context, container *Container) error {
+	mcc.mu.Lock()
+	defer mcc.mu.Unlock()
+	mcc.unpauses = append(mcc.unpauses, container)
+	return mcc.err
+}
+
+func (mcc *mockContainerClient) Inspect(ctx context.Context, container *Container) (*ContainerStatus, error) {
+	if mcc.err != nil {
+		return nil, mcc.err
+	}
+	status := NewContainerStatus(container.Id, container.Name, container.NodeName)
+	status.ContainerState = mcc.state
+	status.Reason = "inspected by mock"
+	status.Ports = mcc.ports
+	return status, nil
+}
+
+func (mcc *mockContainerClient) Remove(ctx context.Context, container *Container) error {
+	mcc.mu.Lock()
+	defer mcc.mu.Unlock()
+	mcc.removes = append(mcc.removes, container)
+	return nil
+}
+
+func (mcc *mockContainerClient) Logs(ctx context.Context, container *Container, options LogsOptions) (io.ReadCloser, error) {
+	if mcc.err != nil {
+		return nil, mcc.err
+	}
+	return io.NopCloser(strings.NewReader("log of " + container.Name)), nil
+}
+
+func (mcc *mockContainerClient) Exec(ctx context.Context, container *Container, cmd []string) (string, string, int, error) {
+	if mcc.err != nil {
+		return "", "", 0, mcc.err
+	}
+	if cmd[0] == "false" {
+		return "", "failed", 1, nil
+	}
+	return strings.Join(cmd, " "), "", 0, nil
+}
+
+// runTestNode create node running with client, set up by operations of cluster as its users do.
+func runTestNode(t testing.TB, clusterService *DefaultClusterService, client ContainerClient) *Node {
+	t.Helper()
+	return prepareTestNode(t, clusterService, func(node *Node) { node.Client = client })
+}
+
+// prepareTestNode create node prepared by prepare, and run it by FakeResourceProvider unless prepare set provider.
+func prepareTestNode(t testing.TB, clusterService *DefaultClusterService, prepare func(node *Node)) *Node {
+	t.Helper()
+	node, err := clusterService.CreateNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, err = clusterService.PrepareNode(node.Id, func(node *Node) error {
+		node.ResourceProvider = NewFakeResourceProvider(ResourceInfo{})
+		prepare(node)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.RunNode(node); err != nil {
+		t.Fatal(err)
+	}
+	return node
+}
+
+// labelTestContainer set labels of container by UpdateContainerIf, and copy the updated one to container.
+func labelTestContainer(t testing.TB, clusterService *DefaultClusterService, container *Container, labels map[string]string) {
+	t.Helper()
+	updated, err := clusterService.UpdateContainerIf(container.Id, container.ResourceVersion, func(container *Container) error {
+		container.Labels = labels
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	*container = *updated
+}
+
+func TestNode_RunContainer(t *testing.T) {
+	client := &mockContainerClient{hash: "hash1"}
+	node := &Node{Id: "node1", Name: "nodename1", Client: client}
+	container := NewContainer("id1", "name1", "", "node1", "nodename1", testImage, "", nil)
+	if err := node.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.runs) != 1 || client.runs[0] != container {
+		t.Errorf("%v", client.runs)
+	}
+	if container.Hash != "hash1" {
+		t.Errorf("%v", container.Hash)
+	}
+	if container.ContainerStatus.ContainerState != ContainerRunning || container.ContainerStatus.StartedAt.IsZero() {
+		t.Errorf("%v", container.ContainerStatus)
+	}
+
+	published := NewContainer("id3", "name3", "", "node1", "nodename1", testImage, "", nil)
+	published.Spec.Ports = []PortMapping{PortMapping{HostPort: 0, ContainerPort: 8080}}
+	client.ports = []PortMapping{PortMapping{HostPort: 32768, ContainerPort: 8080, Protocol: "tcp"}}
+	if err := node.RunContainer(published); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(client.ports, published.ContainerStatus.Ports) {
+		t.Errorf("want:%v,have:%v", client.ports, published.ContainerStatus.Ports)
+	}
+
+	failed := NewContainer("id2", "name2", "", "node1", "nodename1", testImage, "", nil)
+	client.err = errors.New("run failed")
+	if err := node.RunContainer(failed); err == nil {
+		t.Fatal("want error")
+	}
+	if failed.ContainerStatus.ContainerState != ContainerUnknown {
+		t.Errorf("%v", failed.ContainerStatus)
+	}
+}
+
+func TestDefaultClusterService_KillContainer(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	client := &mockContainerClient{hash: "hash1"}
+	node := &Node{Id: "node1", Name: "nodename1", Client: client}
+	clusterService.nodes = append(clusterService.nodes, node)
+	clusterService.nodesById[node.Id] = node
+	container := NewContainer("id1", "name1", "", "node1", "nodename1", testImage, "", nil)
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.KillContainer(container, 3*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.stops) != 1 || client.stops[0].Id != container.Id {
+		t.Errorf("%v", client.stops)
+	}
+	if client.gracePeriods[0] != 3*time.Second {
+		t.Errorf("want:%v,have:%v", 3*time.Second, client.gracePeriods[0])
+	}
+	if container.ContainerStatus.ContainerState != ContainerExited || container.ContainerStatus.FinishedAt.IsZero() {
+		t.Errorf("%v", container.ContainerStatus)
+	}
+	if err := clusterService.KillContainer(container, DefaultStopGracePeriod); err == nil {
+		t.Fatal("want error for exited container")
+	}
+	if len(client.stops) != 1 {
+		t.Errorf("%v", client.stops)
+	}
+}
+
+func TestDefaultClusterService_NodeHasNoClient(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	client := NewFakeContainerClient("hash1")
+	node := runTestNode(t, clusterService, nil)
+	container, _ := clusterService.CreateContainerWithSpec(ContainerSpec{RestartPolicy: RestartPolicy{Name: RestartAlways}})
+	if err := clusterService.RunContainer(container); !errors.Is(err, ErrNodeHasNoClient) {
+		t.Errorf("want:%v,have:%v", ErrNodeHasNoClient, err)
+	}
+	// client is detached after run
+	clusterService.SetNodeClient(node.Id, client)
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	clusterService.SetNodeClient(node.Id, nil)
+	if err := clusterService.KillContainer(container, DefaultStopGracePeriod); !errors.Is(err, ErrNodeHasNoClient) {
+		t.Errorf("want:%v,have:%v", ErrNodeHasNoClient, err)
+	}
+	if _, _, _, err := clusterService.ExecInContainer(container, []string{"ls"}); !errors.Is(err, ErrNodeHasNoClient) {
+		t.Errorf("want:%v,have:%v", ErrNodeHasNoClient, err)
+	}
+	if err := clusterService.Pause(container); !errors.Is(err, ErrNodeHasNoClient) {
+		t.Errorf("want:%v,have:%v", ErrNodeHasNoClient, err)
+	}
+	if _, err := clusterService.Stats(context.Background(), container); !errors.Is(err, ErrNodeHasNoClient) {
+		t.Errorf("want:%v,have:%v", ErrNodeHasNoClient, err)
+	}
+	if _, err := clusterService.LogsWithOptions(context.Background(), container, LogsOptions{}); !errors.Is(err, ErrNodeHasNoClient) {
+		t.Errorf("want:%v,have:%v", ErrNodeHasNoClient, err)
+	}
+	// clients are not saved by SaveState, so restart after LoadState has no client
+	clusterService.SetNodeClient(node.Id, client)
+	client.SetExited(container.Id, 1)
+	if err := clusterService.FlushContainers(); err != nil {
+		t.Fatal(err)
+	}
+	clusterService.SetNodeClient(node.Id, nil)
+	if _, err := clusterService.RestartContainers(); !errors.Is(err, ErrNodeHasNoClient) {
+		t.Errorf("want:%v,have:%v", ErrNodeHasNoClient, err)
+	}
+}
+
+type mockResourceProvider struct {
+	ResourceProvider
+	// guards recorded calls, as StopNode and RemoveNode may be called concurrently
+	mu           sync.Mutex
+	resourceInfo *ResourceInfo
+	err          error
+	runs         Nodes
+	stops        Nodes
+	removes      Nodes
+	stopDelay    time.Duration
+}
+
+func (mrp *mockResourceProvider) RunNode(node *Node) (*ResourceInfo, error) {
+	mrp.mu.Lock()
+	defer mrp.mu.Unlock()
+	mrp.runs = append(mrp.runs, node)
+	return mrp.resourceInfo, mrp.err
+}
+
+func (mrp *mockResourceProvider) StopNode(node *Node) error {
+	time.Sleep(mrp.stopDelay)
+	mrp.mu.Lock()
+	defer mrp.mu.Unlock()
+	mrp.stops = append(mrp.stops, node)
+	return mrp.err
+}
+
+func (mrp *mockResourceProvider) RemoveNode(node *Node) error {
+	mrp.mu.Lock()
+	defer mrp.mu.Unlock()
+	mrp.removes = append(mrp.removes, node)
+	return mrp.err
+}
+
+func TestDefaultClusterService_RunNode(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	node := &Node{Id: "node1", Name: "nodename1"}
+	if err := clusterService.RunNode(node); err == nil {
+		t.Fatal("want error for node without resource provider")
+	}
+
+	provider := &mockResourceProvider{resourceInfo: &ResourceInfo{"host": "host1"}}
+	node.ResourceProvider = provider
+	if err := clusterService.RunNode(node); err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.runs) != 1 || provider.runs[0].Id != node.Id {
+		t.Errorf("%v", provider.runs)
+	}
+	if node.NodeState != NodeRunning {
+		t.Errorf("%v", node.NodeState)
+	}
+	if !reflect.DeepEqual(node.ResourceInfo, ResourceInfo{"host": "host1"}) {
+		t.Errorf("%v", node.ResourceInfo)
+	}
+	if len(clusterService.nodeStatuses) != 1 {
+		t.Fatalf("%v", clusterService.nodeStatuses)
+	}
+	nodeStatus := clusterService.nodeStatuses[0]
+	if nodeStatus.Id != node.Id || nodeStatus.NodeState != NodeRunning || nodeStatus.CreatedAt.IsZero() || nodeStatus.StartedAt.IsZero() {
+		t.Errorf("%v", nodeStatus)
+	}
+}
+
+// blockingResourceProvider runs node after release is closed.
+type blockingResourceProvider struct {
+	ResourceProvider
+	release chan struct{}
+}
+
+func (brp *blockingResourceProvider) RunNode(node *Node) (*ResourceInfo, error) {
+	<-brp.release
+	return &ResourceInfo{"host": "host1"}, nil
+}
+
+func TestDefaultClusterService_RunNodeContext_Cancel(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	node, err := clusterService.CreateNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider := &blockingResourceProvider{release: make(chan struct{})}
+	node, err = clusterService.PrepareNode(node.Id, func(node *Node) error {
+		node.ResourceProvider = provider
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- clusterService.RunNodeContext(ctx, node) }()
+	// lock is not held while provider runs
+	if _, err := clusterService.Status(); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("want:%v,have:%v", context.Canceled, err)
+	}
+	close(provider.release)
+	// node launched after ctx is done is kept
+	for i := 0; i < 100; i++ {
+		if status, err := clusterService.NodeStatus(node.Id, ""); err == nil && status.NodeState == NodeRunning {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	owned, err := clusterService.GetNode(node.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owned.NodeState != NodeRunning || owned.ResourceInfo["host"] != "host1" {
+		t.Errorf("%v,%v", owned.NodeState, owned.ResourceInfo)
+	}
+}
+
+func TestDefaultClusterService_CreateNode(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	names := map[string]bool{}
+	for i := 1; i <= 5; i++ {
+		node, err := clusterService.CreateNode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := fmt.Sprintf("node-%d", i)
+		if node.Name != expected {
+			t.Errorf("want:%v,have:%v", expected, node.Name)
+		}
+		if names[node.Name] {
+			t.Errorf("duplicated:%v", node.Name)
+		}
+		names[node.Name] = true
+		if owned := clusterService.findNodeById(node.Id); owned == nil || owned == node || clusterService.findNodeByName(DefaultNamespace, node.Name) != owned {
+			t.Errorf("not indexed:%v", node)
+		}
+	}
+	if len(clusterService.nodes) != 5 {
+		t.Errorf("%v", clusterService.nodes)
+	}
+}
+
+func TestDefaultClusterService_CreateNamedNode(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"gpu-1", false},
+		{"gpu-1", true},
+		{"", true},
+		{"GPU", true},
+		{"-gpu", true},
+		{"gpu-", true},
+		{"gpu_1", true},
+		{strings.Repeat("a", 64), true},
+	}
+	for _, tt := range tests {
+		node, err := clusterService.CreateNamedNode(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: want error:%v,have:%v", tt.name, tt.wantErr, err)
+			continue
+		}
+		if err == nil && clusterService.findNodeByName(DefaultNamespace, tt.name) != clusterService.findNodeById(node.Id) {
+			t.Errorf("not indexed:%v", node)
+		}
+	}
+	if len(clusterService.nodes) != 1 {
+		t.Errorf("%v", clusterService.nodes)
+	}
+	// generated name skips used one
+	clusterService.CreateNamedNode("node-1")
+	node, err := clusterService.CreateNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node.Name != "node-2" {
+		t.Errorf("want:%v,have:%v", "node-2", node.Name)
+	}
+}
+
+func TestDefaultClusterService_PrepareNode(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	node, _ := clusterService.CreateNode()
+	client := NewFakeContainerClient("hash1")
+	prepared, err := clusterService.PrepareNode(node.Id, func(node *Node) error {
+		node.Client = client
+		node.Name = "renamed"
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prepared.Client != client || prepared.Name != node.Name {
+		t.Errorf("%v", prepared)
+	}
+	// prepared node is applied to node in cluster, and returned as copy
+	owned := clusterService.findNodeById(node.Id)
+	if owned == prepared || owned.Client != client || node.Client != nil {
+		t.Errorf("want applied:%v,have:%v", prepared, owned)
+	}
+
+	stale := prepared.ResourceVersion
+	if _, err := clusterService.PrepareNode(node.Id, func(node *Node) error {
+		clusterService.SetNodeClient(node.Id, nil)
+		return nil
+	}); !errors.Is(err, ErrConflict) {
+		t.Errorf("want:%v,have:%v", ErrConflict, err)
+	}
+	if owned.ResourceVersion == stale {
+		t.Errorf("want bumped:%v", owned.ResourceVersion)
+	}
+	if _, err := clusterService.PrepareNode("unknown", func(*Node) error { return nil }); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("want:%v,have:%v", ErrNodeNotFound, err)
+	}
+}
+
+func TestDefaultClusterService_SetNodeClient(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	clusterService.SetOptions(ContainerOptions{})
+	node := prepareTestNode(t, clusterService, func(node *Node) { node.ResourceProvider = &mockResourceProvider{} })
+	client := NewFakeContainerClient("hash1")
+	if err := clusterService.SetNodeClient(node.Id, client); err != nil {
+		t.Fatal(err)
+	}
+	if have, _ := clusterService.GetNode(node.Id); have.Client != client {
+		t.Errorf("want:%v,have:%v", client, have.Client)
+	}
+	container, _ := clusterService.CreateContainer()
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.Runs()) != 1 {
+		t.Errorf("want:%v,have:%v", 1, client.Runs())
+	}
+	if err := clusterService.SetNodeClient("unknown", client); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("want:%v,have:%v", ErrNodeNotFound, err)
+	}
+}
+
+func TestDefaultClusterService_CreateContainer_NoValidNode(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	clusterService.SetOptions(ContainerOptions{})
+	if _, err := clusterService.CreateContainer(); !errors.Is(err, ErrNoValidNode) {
+		t.Fatalf("%v", err)
+	}
+	if _, err := clusterService.CreateNode(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := clusterService.CreateContainer(); !errors.Is(err, ErrNoValidNode) {
+		t.Fatalf("%v", err)
+	}
+	if len(clusterService.containers) != 0 {
+		t.Errorf("%v", clusterService.containers)
+	}
+}
+
+func TestDefaultClusterService_minWorkingNode(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	container := NewContainer("id1", "name1", "", "", "", testImage, "", nil)
+	if node, err := clusterService.minWorkingNode(container); node != nil || err == nil {
+		t.Fatalf("%v,%v", node, err)
+	}
+	clusterService.CreateNode()
+	running := runTestNode(t, clusterService, nil)
+	if node, err := clusterService.minWorkingNode(container); node == nil || node.Id != running.Id || err != nil {
+		t.Errorf("want:%v,have:%v,%v", running, node, err)
+	}
+}
+
+func TestDefaultClusterService_FlushContainers(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	client := &mockContainerClient{hash: "hash1", state: ContainerExited}
+	node := runTestNode(t, clusterService, client)
+	container, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	// status left without its container
+	clusterService.mu.Lock()
+	clusterService.containerStatuses = append(clusterService.containerStatuses, NewContainerStatus("orphan", "orphan", node.Name))
+	clusterService.mu.Unlock()
+
+	if err := clusterService.FlushContainers(); err != nil {
+		t.Fatal(err)
+	}
+	if len(clusterService.containerStatuses) != 1 || clusterService.containerStatuses[0].Id != container.Id {
+		t.Fatalf("%v", clusterService.containerStatuses)
+	}
+	if _, err := clusterService.ContainerStatus("orphan", "", ""); err == nil {
+		t.Errorf("orphan status remained")
+	}
+	if status, _ := clusterService.ContainerStatus(container.Id, "", ""); status.ContainerState != ContainerExited || status.Reason != "inspected by mock" {
+		t.Errorf("%v", status)
+	}
+}
+
+func TestDefaultClusterService_FlushContainers_Unlocked(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	client := newBlockingContainerClient()
+	node := runTestNode(t, clusterService, client.FakeContainerClient)
+	container, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	// runtime blocks after container runs
+	if err := clusterService.SetNodeClient(node.Id, client); err != nil {
+		t.Fatal(err)
+	}
+	client.SetExited(container.Id, 1)
+
+	done := make(chan error, 1)
+	go func() { done <- clusterService.FlushContainers() }()
+	if called := <-client.called; called != "Inspect" {
+		t.Fatalf("want:%v,have:%v", "Inspect", called)
+	}
+	assertUnlocked(t, clusterService)
+	close(client.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := clusterService.ContainerStatus(container.Id, "", ""); status.ContainerState != ContainerExited {
+		t.Errorf("want:%v,have:%v", ContainerExited, status.ContainerState)
+	}
+}
+
+func TestDefaultClusterService_FlushNodes(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	node := prepareTestNode(t, clusterService, func(node *Node) { node.ResourceProvider = &mockResourceProvider{} })
+	// status left without its node
+	clusterService.mu.Lock()
+	clusterService.nodeStatuses = append(clusterService.nodeStatuses, &NodeStatus{Id: "orphan", Name: "orphan"})
+	clusterService.mu.Unlock()
+	if err := clusterService.KillNode(*node, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := clusterService.FlushNodes(); err != nil {
+		t.Fatal(err)
+	}
+	if len(clusterService.nodeStatuses) != 1 {
+		t.Fatalf("%v", clusterService.nodeStatuses)
+	}
+	nodeStatus := clusterService.nodeStatuses[0]
+	if nodeStatus.Id != node.Id || nodeStatus.NodeState != NodeExited {
+		t.Errorf("%v", nodeStatus)
+	}
+}
+
+func TestDefaultClusterService_FlushNodes_Unlocked(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	provider := &blockingProber{
+		FakeResourceProvider: NewFakeResourceProvider(ResourceInfo{}),
+		called:               make(chan string, 1),
+		release:              make(chan struct{}),
+	}
+	node := prepareTestNode(t, clusterService, func(node *Node) { node.ResourceProvider = provider })
+
+	done := make(chan error, 1)
+	go func() { done <- clusterService.FlushNodes() }()
+	if called := <-provider.called; called != "ProbeNode" {
+		t.Fatalf("want:%v,have:%v", "ProbeNode", called)
+	}
+	assertUnlocked(t, clusterService)
+	close(provider.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := clusterService.NodeStatus(node.Id, ""); status.LastHeartbeat.IsZero() {
+		t.Errorf("heartbeat is not recorded:%v", status)
+	}
+}
+
+func TestDefaultClusterService_CreateContainer(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	node := runTestNode(t, clusterService, nil)
+	if _, err := clusterService.CreateContainer(); err == nil {
+		t.Fatal("want error for not set options")
+	}
+	clusterService.SetOptions(ContainerOptions{OptionEnv: "A=1", OptionCommand: "run"})
+	container, err := clusterService.CreateContainer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := ContainerSpec{Env: []string{"A=1"}, Command: []string{"run"}}
+	if !reflect.DeepEqual(expected, container.Spec) {
+		t.Errorf("want:%v,have:%v", expected, container.Spec)
+	}
+	if container.NodeId != node.Id || !reflect.DeepEqual(testImage, container.Image) {
+		t.Errorf("%v", container)
+	}
+	if len(clusterService.containers) != 1 || len(clusterService.containerStatuses) != 1 {
+		t.Errorf("%v,%v", clusterService.containers, clusterService.containerStatuses)
+	}
+}
+
+func TestDefaultClusterService_CreateContainerOn(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	clusterService.SetOptions(ContainerOptions{})
+	// scheduler would select first node
+	runTestNode(t, clusterService, nil)
+	pinned := runTestNode(t, clusterService, nil)
+	stopped, _ := clusterService.CreateNode()
+
+	container, err := clusterService.CreateContainerOn(pinned.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if container.NodeId != pinned.Id || container.NodeName != pinned.Name {
+		t.Errorf("want:%v,have:%v", pinned.Name, container.NodeName)
+	}
+	if _, err := clusterService.CreateContainerOn(stopped.Id); err == nil {
+		t.Error("want error for not running node")
+	}
+	if _, err := clusterService.CreateContainerOn("unknown"); err == nil {
+		t.Error("want error for unknown node")
+	}
+	if len(clusterService.containers) != 1 {
+		t.Errorf("%v", clusterService.containers)
+	}
+}
+
+func TestDefaultClusterService_CreateContainer_Name(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	node1 := runTestNode(t, clusterService, nil)
+	first, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	second, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	if first.Name != "image-1" || second.Name != "image-2" {
+		t.Errorf("%v,%v", first.Name, second.Name)
+	}
+	if second.ContainerStatus.Name != second.Name {
+		t.Errorf("want:%v,have:%v", second.Name, second.ContainerStatus.Name)
+	}
+	if cs, err := clusterService.ContainerStatus("", second.Name, node1.Name); err != nil || !reflect.DeepEqual(second.ContainerStatus, cs) {
+		t.Errorf("%v,%v", cs, err)
+	}
+
+	// name may repeat across nodes
+	if err := clusterService.KillNode(*node1, 0); err != nil {
+		t.Fatal(err)
+	}
+	node2 := runTestNode(t, clusterService, nil)
+	other, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	if other.NodeId != node2.Id || other.Name != "image-1" {
+		t.Errorf("%v,%v", other.NodeName, other.Name)
+	}
+}
+
+func TestDefaultClusterService_Logs(t *testing.T) {
+	clusterService, _ := newTestRestartService(t)
+	container, err := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := clusterService.Logs(container, false); err == nil {
+		t.Fatal("want error for not run container")
+	}
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	logs, err := clusterService.Logs(container, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logs.Close()
+	b, err := io.ReadAll(logs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "log of "+container.Name {
+		t.Errorf("%v", string(b))
+	}
+}
+
+func TestDefaultClusterService_ExecInContainer(t *testing.T) {
+	clusterService, client := newTestRestartService(t)
+	container, err := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := clusterService.ExecInContainer(container, []string{"echo", "hello"}); err == nil {
+		t.Fatal("want error for not running container")
+	}
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr, exitCode, err := clusterService.ExecInContainer(container, []string{"echo", "hello"})
+	if err != nil || stdout != "echo hello" || stderr != "" || exitCode != 0 {
+		t.Errorf("%v,%v,%v,%v", stdout, stderr, exitCode, err)
+	}
+	stdout, stderr, exitCode, err = clusterService.ExecInContainer(container, []string{"false"})
+	if err != nil || stdout != "" || stderr != "failed" || exitCode != 1 {
+		t.Errorf("%v,%v,%v,%v", stdout, stderr, exitCode, err)
+	}
+	client.err = errors.New("daemon not reachable")
+	if _, _, _, err := clusterService.ExecInContainer(container, []string{"echo"}); err == nil {
+		t.Errorf("want transport error")
+	}
+}
+
+func TestDefaultClusterService_RuntimeVersion(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	fake := runTestNode(t, clusterService, NewFakeContainerClient("hash1"))
+	memory := runTestNode(t, clusterService, NewInMemoryContainerClient(0))
+	detached, _ := clusterService.CreateNode()
+
+	tests := []struct {
+		name     string
+		node     *Node
+		expected Version
+		err      error
+	}{
+		{"fake", fake, FakeRuntimeVersion, nil},
+		{"memory", memory, InMemoryRuntimeVersion, nil},
+		{"noClient", detached, "", ErrNodeHasNoClient},
+		{"notFound", &Node{Id: "node1"}, "", ErrNodeNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := clusterService.RuntimeVersion(context.Background(), tt.node)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("want:%v,have:%v", tt.err, err)
+			}
+			if version != tt.expected {
+				t.Errorf("want:%v,have:%v", tt.expected, version)
+			}
+		})
+	}
+	// version of cluster is not of runtime
+	if version, _ := clusterService.Version(); version != "0.0.0" {
+		t.Errorf("want:%v,have:%v", "0.0.0", version)
+	}
+}
+
+func TestDefaultClusterService_AttachContainer(t *testing.T) {
+	clusterService := newTestProbeService(t)
+	container, err := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if err := clusterService.AttachContainer(context.Background(), container, strings.NewReader("hello\n"), &stdout, &stderr); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("want:%v,have:%v", ErrNotRunning, err)
+	}
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.AttachContainer(context.Background(), container, strings.NewReader("hello\n"), &stdout, &stderr); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "hello\n" {
+		t.Errorf("want:%v,have:%v", "hello\n", stdout.String())
+	}
+
+	// blocks until ctx is done as stdin is not closed
+	reader, writer := io.Pipe()
+	defer writer.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := clusterService.AttachContainer(ctx, container, reader, io.Discard, io.Discard); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want:%v,have:%v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestDefaultClusterService_KillNode(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	node, _ := clusterService.CreateNode()
+	provider := &mockResourceProvider{}
+	node, err := clusterService.PrepareNode(node.Id, func(node *Node) error {
+		node.ResourceProvider = provider
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.KillNode(*node, 100); err == nil {
+		t.Fatal("want error for not running node")
+	}
+	if err := clusterService.RunNode(node); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.KillNode(*node, 100); err != nil {
+		t.Fatal(err)
+	}
+	node, _ = clusterService.GetNode(node.Id)
+	if node.NodeState != NodeExited || len(provider.removes) != 0 {
+		t.Errorf("%v,%v", node.NodeState, provider.removes)
+	}
+	nodeStatus := clusterService.findNodeStatusById(node.Id)
+	if nodeStatus.NodeState != NodeExited || nodeStatus.FinishedAt.IsZero() {
+		t.Errorf("%v", nodeStatus)
+	}
+}
+
+func TestDefaultClusterService_KillNode_GracePeriod(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	provider := &mockResourceProvider{stopDelay: 50 * time.Millisecond}
+	node := prepareTestNode(t, clusterService, func(node *Node) { node.ResourceProvider = provider })
+	if err := clusterService.KillNode(*node, 1); err != nil {
+		t.Fatal(err)
+	}
+	node, _ = clusterService.GetNode(node.Id)
+	provider.mu.Lock()
+	if node.NodeState != NodeExited || len(provider.removes) != 1 {
+		t.Errorf("%v,%v", node.NodeState, provider.removes)
+	}
+	provider.mu.Unlock()
+
+	// 0 removes node without stopping
+	other := prepareTestNode(t, clusterService, func(node *Node) { node.ResourceProvider = &mockResourceProvider{} })
+	if err := clusterService.KillNode(*other, -1); err == nil {
+		t.Error("want error for negative grace period")
+	}
+	if err := clusterService.KillNode(*other, 0); err != nil {
+		t.Fatal(err)
+	}
+	otherProvider := other.ResourceProvider.(*mockResourceProvider)
+	other, _ = clusterService.GetNode(other.Id)
+	if other.NodeState != NodeExited || len(otherProvider.stops) != 0 || len(otherProvider.removes) != 1 {
+		t.Errorf("%v,%v,%v", other.NodeState, otherProvider.stops, otherProvider.removes)
+	}
+}
+
+func TestDefaultClusterService_KillNodeContext(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	provider := &mockResourceProvider{stopDelay: 50 * time.Millisecond}
+	node := prepareTestNode(t, clusterService, func(node *Node) { node.ResourceProvider = provider })
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if err := clusterService.KillNodeContext(ctx, *node, 1000); err != context.DeadlineExceeded {
+		t.Errorf("want:%v,have:%v", context.DeadlineExceeded, err)
+	}
+	// node stopped after ctx is done is recorded
+	state := NodeRunning
+	for i := 0; i < 100 && state != NodeExited; i++ {
+		time.Sleep(5 * time.Millisecond)
+		owned, err := clusterService.GetNode(node.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		state = owned.NodeState
+	}
+	if state != NodeExited {
+		t.Errorf("want:%v,have:%v", NodeExited, state)
+	}
+}
+
+func TestDefaultClusterService_RemoveContainer(t *testing.T) {
+	clusterService, client := newTestRestartService(t)
+	container, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	other, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.RemoveContainer(container.Id); err == nil {
+		t.Fatal("want error for running container")
+	}
+	if err := clusterService.KillContainer(container, DefaultStopGracePeriod); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.RemoveContainer(container.Id); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.removes) != 1 || client.removes[0].Id != container.Id {
+		t.Errorf("%v", client.removes)
+	}
+	if !reflect.DeepEqual(Containers{other}, clusterService.containers) {
+		t.Errorf("%v", clusterService.containers)
+	}
+	if !reflect.DeepEqual(ContainerStatuses{other.ContainerStatus}, clusterService.containerStatuses) {
+		t.Errorf("%v", clusterService.containerStatuses)
+	}
+	if err := clusterService.RemoveContainer(container.Id); err == nil {
+		t.Errorf("want error for removed container")
+	}
+}
+
+// blockingContainerClient blocks Run, Stop, Remove, Update, Inspect, Pause and Unpause until release is closed, telling calls by called.
+type blockingContainerClient struct {
+	*FakeContainerClient
+	called  chan string
+	release chan struct{}
+}
+
+func newBlockingContainerClient() *blockingContainerClient {
+	return &blockingContainerClient{
+		FakeContainerClient: NewFakeContainerClient("hash1"),
+		called:              make(chan string, 100),
+		release:             make(chan struct{}),
+	}
+}
+
+func (bcc *blockingContainerClient) Run(ctx context.Context, container *Container) (string, error) {
+	bcc.called <- "Run"
+	<-bcc.release
+	return bcc.FakeContainerClient.Run(ctx, container)
+}
+
+func (bcc *blockingContainerClient) Stop(ctx context.Context, container *Container, gracePeriod time.Duration) error {
+	bcc.called <- "Stop"
+	<-bcc.release
+	return bcc.FakeContainerClient.Stop(ctx, container, gracePeriod)
+}
+
+func (bcc *blockingContainerClient) Remove(ctx context.Context, container *Container) error {
+	bcc.called <- "Remove"
+	<-bcc.release
+	return bcc.FakeContainerClient.Remove(ctx, container)
+}
+
+func (bcc *blockingContainerClient) Update(ctx context.Context, container *Container, limits Capacity) error {
+	bcc.called <- "Update"
+	<-bcc.release
+	return bcc.FakeContainerClient.Update(ctx, container, limits)
+}
+
+func (bcc *blockingContainerClient) Inspect(ctx context.Context, container *Container) (*ContainerStatus, error) {
+	bcc.called <- "Inspect"
+	<-bcc.release
+	return bcc.FakeContainerClient.Inspect(ctx, container)
+}
+
+func (bcc *blockingContainerClient) Pause(ctx context.Context, container *Container) error {
+	bcc.called <- "Pause"
+	<-bcc.release
+	return bcc.FakeContainerClient.Pause(ctx, container)
+}
+
+func (bcc *blockingContainerClient) Unpause(ctx context.Context, container *Container) error {
+	bcc.called <- "Unpause"
+	<-bcc.release
+	return bcc.FakeContainerClient.Unpause(ctx, container)
+}
+
+// blockingProber blocks ProbeNode until release is closed, telling calls by called.
+type blockingProber struct {
+	*FakeResourceProvider
+	called  chan string
+	release chan struct{}
+}
+
+func (bp *blockingProber) ProbeNode(node *Node) error {
+	bp.called <- "ProbeNode"
+	<-bp.release
+	return bp.FakeResourceProvider.ProbeNode(node)
+}
+
+// assertUnlocked fails if lock of service is held, by getting status with timeout.
+func assertUnlocked(t *testing.T, clusterService *DefaultClusterService) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		clusterService.Status()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("lock is held while runtime is called")
+	}
+}
+
+func TestDefaultClusterService_RemoveContainer_Unlocked(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	client := newBlockingContainerClient()
+	node := runTestNode(t, clusterService, client.FakeContainerClient)
+	container, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.KillContainer(container, 0); err != nil {
+		t.Fatal(err)
+	}
+	// runtime blocks after container exits
+	if err := clusterService.SetNodeClient(node.Id, client); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- clusterService.RemoveContainer(container.Id) }()
+	if called := <-client.called; called != "Remove" {
+		t.Fatalf("want:%v,have:%v", "Remove", called)
+	}
+	assertUnlocked(t, clusterService)
+	close(client.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if len(clusterService.containers) != 0 || len(clusterService.containerStatuses) != 0 {
+		t.Errorf("%v,%v", clusterService.containers, clusterService.containerStatuses)
+	}
+}
+
+func TestDefaultClusterService_ForceRemoveContainer(t *testing.T) {
+	clusterService, client := newTestRestartService(t)
+	container, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.ForceRemoveContainer(container.Id); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.stops) != 1 || len(client.removes) != 1 {
+		t.Errorf("%v,%v", client.stops, client.removes)
+	}
+	if len(clusterService.containers) != 0 || len(clusterService.containerStatuses) != 0 {
+		t.Errorf("%v,%v", clusterService.containers, clusterService.containerStatuses)
+	}
+}
+
+func TestDefaultClusterService_RemoveNode(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	provider := &mockResourceProvider{}
+	node := prepareTestNode(t, clusterService, func(node *Node) { node.ResourceProvider = provider })
+	other, _ := clusterService.CreateNode()
+	container, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+
+	err := clusterService.RemoveNode(node.Id)
+	if err == nil || !strings.Contains(err.Error(), string(container.Id)) {
+		t.Fatalf("%v", err)
+	}
+	if err := clusterService.RemoveContainer(container.Id); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.RemoveNode(node.Id); err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.removes) != 1 {
+		t.Errorf("%v", provider.removes)
+	}
+	if !reflect.DeepEqual(Nodes{other}, clusterService.nodes) || len(clusterService.nodeStatuses) != 0 {
+		t.Errorf("%v,%v", clusterService.nodes, clusterService.nodeStatuses)
+	}
+	if clusterService.findNodeById(node.Id) != nil || clusterService.findNodeByName(DefaultNamespace, node.Name) != nil {
+		t.Errorf("node is still indexed")
+	}
+	if err := clusterService.RemoveNode(node.Id); err == nil {
+		t.Errorf("want error for removed node")
+	}
+}
+
+func BenchmarkDefaultClusterService_ContainerStatus(b *testing.B) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	runTestNode(b, clusterService, nil)
+	containers := Containers{}
+	for i := 0; i < 10000; i++ {
+		container, err := clusterService.CreateContainerWithSpec(ContainerSpec{})
+		if err != nil {
+			b.Fatal(err)
+		}
+		containers = append(containers, container)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		container := containers[i%len(containers)]
+		if _, err := clusterService.ContainerStatus(container.Id, "", ""); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package cluster
+
+import (
+	"fmt"
+	"time"
+)
+
+// NodeConditionType is aspect of node reported by NodeCondition.
+type NodeConditionType string
+
+const (
+	// node is running and found alive by the last FlushNodes
+	NodeReady NodeConditionType = "Ready"
+	// available memory is below threshold
+	NodeMemoryPressure NodeConditionType = "MemoryPressure"
+	// available disk is below threshold
+	NodeDiskPressure NodeConditionType = "DiskPressure"
+	// load average is above threshold
+	NodeCPUPressure NodeConditionType = "CPUPressure"
+)
+
+// NodeCondition is state of an aspect of node, computed by FlushNodes.
+type NodeCondition struct {
+	Type NodeConditionType
+	// true if node is in the condition
+	Status bool
+	// why it has the status
+	Reason string
+	// last time status changed
+	LastTransition time.Time
+}
+
+// NodeConditionThresholds decide pressure conditions of node from its usage, 0 disables each of them.
+type NodeConditionThresholds struct {
+	// load average above which node is under cpu pressure
+	MaxLoadAverage float64
+	// available memory below which node is under memory pressure, MB
+	MinMemory int
+	// available disk below which node is under disk pressure, GB
+	MinDisk int
+}
+
+// NodeUsageReporter is ResourceProvider which can report usage of node, recorded into its status by FlushNodes.
+// pressure conditions are computed only for nodes whose provider is NodeUsageReporter.
+type NodeUsageReporter interface {
+	// returns load average, available memory in MB and available disk in GB of node
+	NodeUsage(*Node) (loadAverage float64, memory int, disk int, err error)
+}
+
+// SetNodeConditionThresholds set thresholds of pressure conditions, applied from the next FlushNodes.
+func (dcs *DefaultClusterService) SetNodeConditionThresholds(thresholds NodeConditionThresholds) {
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	dcs.nodeThresholds = thresholds
+}
+
+// Condition returns condition of type, false if node status does not have it.
+func (ns *NodeStatus) Condition(conditionType NodeConditionType) (NodeCondition, bool) {
+	for _, c := range ns.Conditions {
+		if c.Type == conditionType {
+			return c, true
+		}
+	}
+	return NodeCondition{}, false
+}
+
+// UnderPressure returns true if node is in any of pressure conditions.
+func (ns *NodeStatus) UnderPressure() bool {
+	for _, c := range ns.Conditions {
+		if c.Status && c.Type != NodeReady {
+			return true
+		}
+	}
+	return false
+}
+
+// setCondition set status of condition type, keeping its last transition if status is not changed.
+func (ns *NodeStatus) setCondition(conditionType NodeConditionType, status bool, reason string, now time.Time) {
+	for i, c := range ns.Conditions {
+		if c.Type != conditionType {
+			continue
+		}
+		if c.Status != status {
+			ns.Conditions[i].LastTransition = now
+		}
+		ns.Conditions[i].Status = status
+		ns.Conditions[i].Reason = reason
+		return
+	}
+	ns.Conditions = append(ns.Conditions, NodeCondition{Type: conditionType, Status: status, Reason: reason, LastTransition: now})
+}
+
+// updateConditions compute conditions of node into status after it is probed, caller must hold lock.
+// node is ready if it is running and alive by the probe.
+func (dcs *DefaultClusterService) updateConditions(node *Node, ns *NodeStatus, alive bool) {
+	now := time.Now()
+	ready, reason := false, fmt.Sprintf("node is %v", node.NodeState)
+	if node.NodeState == NodeRunning {
+		if alive {
+			ready, reason = true, "node is running"
+		} else {
+			reason = ns.Message
+		}
+	}
+	ns.setCondition(NodeReady, ready, reason, now)
+
+	reporter, ok := node.ResourceProvider.(NodeUsageReporter)
+	if !ok || !ready {
+		return
+	}
+	load, memory, disk, err := reporter.NodeUsage(node)
+	if err != nil {
+		ns.Message = fmt.Sprintf("usage not reported:%v", err)
+		return
+	}
+	ns.LoadAverage, ns.Memory, ns.Disk = load, memory, disk
+	thresholds := dcs.nodeThresholds
+	ns.setCondition(NodeCPUPressure, thresholds.MaxLoadAverage > 0 && load > thresholds.MaxLoadAverage,
+		fmt.Sprintf("load average:%v, max:%v", load, thresholds.MaxLoadAverage), now)
+	ns.setCondition(NodeMemoryPressure, thresholds.MinMemory > 0 && memory < thresholds.MinMemory,
+		fmt.Sprintf("available memory:%vMB, min:%vMB", memory, thresholds.MinMemory), now)
+	ns.setCondition(NodeDiskPressure, thresholds.MinDisk > 0 && disk < thresholds.MinDisk,
+		fmt.Sprintf("available disk:%vGB, min:%vGB", disk, thresholds.MinDisk), now)
+}
+
+// underPressure returns true if status of node is in any of pressure conditions.
+func (dcs *DefaultClusterService) underPressure(node *Node) bool {
+	ns := dcs.findNodeStatusById(node.Id)
+	return ns != nil && ns.UnderPressure()
+}
@@ -0,0 +1,79 @@
+package cluster
+
+import (
+	"errors"
+	"testing"
+)
+
+// usageProvider is FakeResourceProvider reporting usage set to it.
+type usageProvider struct {
+	*FakeResourceProvider
+	load   float64
+	memory int
+	disk   int
+}
+
+func (up *usageProvider) NodeUsage(node *Node) (float64, int, int, error) {
+	return up.load, up.memory, up.disk, nil
+}
+
+func TestDefaultClusterService_FlushNodes_Conditions(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	clusterService.SetOptions(ContainerOptions{})
+	clusterService.SetNodeConditionThresholds(NodeConditionThresholds{MaxLoadAverage: 4, MinMemory: 512, MinDisk: 10})
+	provider := &usageProvider{FakeResourceProvider: NewFakeResourceProvider(ResourceInfo{}), load: 1, memory: 2048, disk: 100}
+	node := prepareTestNode(t, clusterService, func(node *Node) {
+		node.ResourceProvider = provider
+		node.Client = NewInMemoryContainerClient(0)
+	})
+
+	tests := []struct {
+		name     string
+		load     float64
+		memory   int
+		disk     int
+		expected map[NodeConditionType]bool
+	}{
+		{"healthy", 1, 2048, 100, map[NodeConditionType]bool{NodeReady: true, NodeCPUPressure: false, NodeMemoryPressure: false, NodeDiskPressure: false}},
+		{"memory", 1, 256, 100, map[NodeConditionType]bool{NodeReady: true, NodeCPUPressure: false, NodeMemoryPressure: true, NodeDiskPressure: false}},
+		{"cpuAndDisk", 8, 2048, 5, map[NodeConditionType]bool{NodeReady: true, NodeCPUPressure: true, NodeMemoryPressure: false, NodeDiskPressure: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider.load, provider.memory, provider.disk = tt.load, tt.memory, tt.disk
+			if err := clusterService.FlushNodes(); err != nil {
+				t.Fatal(err)
+			}
+			status, err := clusterService.NodeStatus(node.Id, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for conditionType, expected := range tt.expected {
+				condition, ok := status.Condition(conditionType)
+				if !ok || condition.Status != expected {
+					t.Errorf("%v want:%v,have:%v", conditionType, expected, condition)
+				}
+			}
+			if status.Memory != tt.memory {
+				t.Errorf("want:%v,have:%v", tt.memory, status.Memory)
+			}
+		})
+	}
+
+	// node under pressure is not scheduled
+	if _, err := clusterService.CreateContainer(); !errors.Is(err, ErrNoValidNode) {
+		t.Errorf("want:%v,have:%v", ErrNoValidNode, err)
+	}
+
+	// last transition is kept while status is not changed
+	status, _ := clusterService.NodeStatus(node.Id, "")
+	ready, _ := status.Condition(NodeReady)
+	provider.StopNode(node)
+	if err := clusterService.FlushNodes(); err != nil {
+		t.Fatal(err)
+	}
+	status, _ = clusterService.NodeStatus(node.Id, "")
+	if have, _ := status.Condition(NodeReady); have.Status || !have.LastTransition.After(ready.LastTransition) {
+		t.Errorf("want not ready after:%v,have:%v", ready.LastTransition, have)
+	}
+}
@@ -0,0 +1,512 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterContainerRuntime(RuntimeContainerd, func(endpoint string) (ContainerClient, error) {
+		return NewContainerdContainerClient(endpoint, "")
+	})
+}
+
+// ContainerdContainerClient is a ContainerClient backed by containerd through nerdctl command,
+// which must be installed on the host running cluster.
+type ContainerdContainerClient struct {
+	// containerd address(ex: /run/containerd/containerd.sock)
+	address string
+	// containerd namespace, default if empty
+	namespace string
+	// command to run, nerdctl by default
+	command string
+	auths   registryAuths
+}
+
+// NewContainerdContainerClient create client for containerd on address(ex: /run/containerd/containerd.sock)
+// with namespace, empty namespace means default.
+func NewContainerdContainerClient(address string, namespace string) (*ContainerdContainerClient, error) {
+	command, err := exec.LookPath("nerdctl")
+	if err != nil {
+		return nil, err
+	}
+	return &ContainerdContainerClient{address: address, namespace: namespace, command: command}, nil
+}
+
+// nerdctl run command with global flags, returns stdout.
+func (ccc *ContainerdContainerClient) nerdctl(ctx context.Context, args ...string) (string, error) {
+	return ccc.nerdctlWithInput(ctx, nil, args...)
+}
+
+// nerdctlWithInput is nerdctl passing stdin to command, used for secret not to be shown in args.
+func (ccc *ContainerdContainerClient) nerdctlWithInput(ctx context.Context, stdin io.Reader, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, ccc.command, ccc.args(args...)...)
+	cmd.Stdin = stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if stderr.Len() > 0 {
+			return "", fmt.Errorf("%v:%v", err, strings.TrimSpace(stderr.String()))
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+func (ccc *ContainerdContainerClient) args(args ...string) []string {
+	global := []string{}
+	if ccc.address != "" {
+		global = append(global, "--address", ccc.address)
+	}
+	if ccc.namespace != "" {
+		global = append(global, "--namespace", ccc.namespace)
+	}
+	return append(global, args...)
+}
+
+// Run create and start container, returns container id on containerd.
+func (ccc *ContainerdContainerClient) Run(ctx context.Context, container *Container) (string, error) {
+	args, err := nerdctlRunArgs(container)
+	if err != nil {
+		return "", err
+	}
+	out, err := ccc.nerdctl(ctx, args...)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func (ccc *ContainerdContainerClient) Stop(ctx context.Context, container *Container, gracePeriod time.Duration) error {
+	if gracePeriod <= 0 {
+		_, err := ccc.nerdctl(ctx, "kill", container.Hash)
+		return err
+	}
+	_, err := ccc.nerdctl(ctx, "stop", "--time", strconv.Itoa(stopTimeout(gracePeriod)), container.Hash)
+	return err
+}
+
+func (ccc *ContainerdContainerClient) Pause(ctx context.Context, container *Container) error {
+	_, err := ccc.nerdctl(ctx, "pause", container.Hash)
+	return err
+}
+
+func (ccc *ContainerdContainerClient) Unpause(ctx context.Context, container *Container) error {
+	_, err := ccc.nerdctl(ctx, "unpause", container.Hash)
+	return err
+}
+
+func (ccc *ContainerdContainerClient) ImageId(ctx context.Context, image *Image) (string, error) {
+	out, err := ccc.nerdctl(ctx, "image", "inspect", "--format", "{{.ID}}", image.FullName)
+	if err != nil {
+		if isNerdctlNotFound(err) {
+			return "", fmt.Errorf("%w:%v", ErrImageNotFound, image.FullName)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// SetRegistryAuth register auth used to pull image from registry, empty registry means DefaultRegistry.
+func (ccc *ContainerdContainerClient) SetRegistryAuth(registry string, auth RegistryAuth) {
+	ccc.auths.set(registry, auth)
+}
+
+// PullImage pull image, logging in to its registry before if auth is registered.
+// nerdctl keeps the login in its config of the host.
+func (ccc *ContainerdContainerClient) PullImage(ctx context.Context, image *Image) error {
+	if auth, ok := ccc.auths.forImage(image); ok {
+		if err := ccc.login(ctx, normalizeRegistry(image.Registry), auth); err != nil {
+			return err
+		}
+	}
+	_, err := ccc.nerdctl(ctx, "pull", "--quiet", image.FullName)
+	return err
+}
+
+// login pass password by stdin, not to be shown in process list.
+func (ccc *ContainerdContainerClient) login(ctx context.Context, registry string, auth RegistryAuth) error {
+	if auth.IdentityToken != "" && auth.Password == "" {
+		return fmt.Errorf("identity token is not supported by nerdctl for registry:%v", registry)
+	}
+	_, err := ccc.nerdctlWithInput(ctx, strings.NewReader(auth.Password),
+		"login", "--username", auth.Username, "--password-stdin", registry)
+	return err
+}
+
+// ListImages parse json lines of nerdctl images, tagged by Repository:Tag or Repository@Digest if untagged.
+func (ccc *ContainerdContainerClient) ListImages(ctx context.Context) ([]*Image, error) {
+	out, err := ccc.nerdctl(ctx, "images", "--format", "{{json .}}")
+	if err != nil {
+		return nil, err
+	}
+	return parseNerdctlImages([]byte(out))
+}
+
+func (ccc *ContainerdContainerClient) RemoveImage(ctx context.Context, image *Image) error {
+	_, err := ccc.nerdctl(ctx, "rmi", image.FullName)
+	if err != nil && isNerdctlNotFound(err) {
+		return fmt.Errorf("%w:%v", ErrImageNotFound, image.FullName)
+	}
+	return err
+}
+
+// nerdctlImage is part of a line of nerdctl images.
+type nerdctlImage struct {
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+func parseNerdctlImages(out []byte) ([]*Image, error) {
+	references := []string{}
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		image := nerdctlImage{}
+		if err := json.Unmarshal([]byte(line), &image); err != nil {
+			return nil, err
+		}
+		if image.Tag != "" && image.Tag != "<none>" {
+			references = append(references, image.Repository+":"+image.Tag)
+		} else if image.Digest != "" {
+			references = append(references, image.Repository+"@"+image.Digest)
+		}
+	}
+	return parseImages(references), nil
+}
+
+// isNerdctlNotFound returns true if nerdctl failed because of missing object, reported only in its message.
+func isNerdctlNotFound(err error) bool {
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "no such") || strings.Contains(message, "not found")
+}
+
+func (ccc *ContainerdContainerClient) Inspect(ctx context.Context, container *Container) (*ContainerStatus, error) {
+	out, err := ccc.nerdctl(ctx, "container", "inspect", container.Hash)
+	if err != nil {
+		return nil, err
+	}
+	return parseNerdctlInspect(container, []byte(out))
+}
+
+func (ccc *ContainerdContainerClient) Remove(ctx context.Context, container *Container) error {
+	_, err := ccc.nerdctl(ctx, "rm", container.Hash)
+	return err
+}
+
+// Logs returns stdout and stderr of container, killing the command on Close.
+func (ccc *ContainerdContainerClient) Logs(ctx context.Context, container *Container, options LogsOptions) (io.ReadCloser, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	cmd := exec.CommandContext(ctx, ccc.command, ccc.args(nerdctlLogsArgs(container, options)...)...)
+	reader, writer := io.Pipe()
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return nil, err
+	}
+	go func() {
+		writer.CloseWithError(cmd.Wait())
+	}()
+	return &nerdctlLogReader{PipeReader: reader, cancel: cancel}, nil
+}
+
+func nerdctlLogsArgs(container *Container, options LogsOptions) []string {
+	args := []string{"logs", "--tail", options.tail()}
+	if options.Follow {
+		args = append(args, "--follow")
+	}
+	if since := options.since(); since != "" {
+		args = append(args, "--since", since)
+	}
+	if options.Timestamps {
+		args = append(args, "--timestamps")
+	}
+	return append(args, container.Hash)
+}
+
+// nerdctlLogReader kill logs command with the pipe to stop following.
+type nerdctlLogReader struct {
+	*io.PipeReader
+	cancel context.CancelFunc
+}
+
+func (nlr *nerdctlLogReader) Close() error {
+	nlr.cancel()
+	return nlr.PipeReader.Close()
+}
+
+// Stats returns stats sampled once by nerdctl.
+func (ccc *ContainerdContainerClient) Stats(ctx context.Context, container *Container) (*ContainerStats, error) {
+	out, err := ccc.nerdctl(ctx, "stats", "--no-stream", "--format", "{{json .}}", container.Hash)
+	if err != nil {
+		return nil, err
+	}
+	return parseNerdctlStats([]byte(out))
+}
+
+// Update change cpu shares and memory of container, 0 keeps current value.
+func (ccc *ContainerdContainerClient) Update(ctx context.Context, container *Container, limits Capacity) error {
+	args := []string{"update"}
+	if limits.CPUShares > 0 {
+		args = append(args, "--cpu-shares", strconv.FormatInt(limits.CPUShares, 10))
+	}
+	if limits.MemoryMB > 0 {
+		args = append(args, "--memory", fmt.Sprintf("%dm", limits.MemoryMB))
+	}
+	if len(args) == 1 {
+		return nil
+	}
+	_, err := ccc.nerdctl(ctx, append(args, container.Hash)...)
+	return err
+}
+
+// nerdctlStats is line of nerdctl stats, values are formatted for human.
+type nerdctlStats struct {
+	// ex: 1.23%
+	CPUPerc string
+	// ex: 12.5MiB / 1GiB
+	MemUsage string
+}
+
+func parseNerdctlStats(out []byte) (*ContainerStats, error) {
+	s := nerdctlStats{}
+	if err := json.Unmarshal(bytes.TrimSpace(out), &s); err != nil {
+		return nil, err
+	}
+	cpu, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s.CPUPerc), "%"), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cpu:%v", s.CPUPerc)
+	}
+	usage, limit, found := strings.Cut(s.MemUsage, "/")
+	if !found {
+		return nil, fmt.Errorf("invalid memory:%v", s.MemUsage)
+	}
+	stats := &ContainerStats{CPUPercent: cpu}
+	if stats.MemoryBytes, err = parseBytes(usage); err != nil {
+		return nil, err
+	}
+	if stats.MemoryLimitBytes, err = parseBytes(limit); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// byteUnits are units of size formatted by runtime CLIs, longer suffix first to match.
+var byteUnits = []struct {
+	suffix string
+	size   float64
+}{
+	{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30}, {"TiB", 1 << 40},
+	{"kB", 1e3}, {"KB", 1e3}, {"MB", 1e6}, {"GB", 1e9}, {"TB", 1e12},
+	{"B", 1},
+}
+
+// parseBytes parse size like 12.5MiB or 1GB into bytes.
+func parseBytes(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	for _, unit := range byteUnits {
+		if number, ok := strings.CutSuffix(value, unit.suffix); ok {
+			n, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid size:%v", value)
+			}
+			return int64(n * unit.size), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid size:%v", value)
+}
+
+// Exec run cmd in running container and wait for it. exitCode is valid only if err is nil.
+func (ccc *ContainerdContainerClient) Exec(ctx context.Context, container *Container, cmd []string) (string, string, int, error) {
+	var stdout, stderr bytes.Buffer
+	c := exec.CommandContext(ctx, ccc.command, ccc.args(append([]string{"exec", container.Hash}, cmd...)...)...)
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+	if err := c.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if ctx.Err() != nil || !errors.As(err, &exitErr) {
+			return "", "", 0, err
+		}
+		return stdout.String(), stderr.String(), exitErr.ExitCode(), nil
+	}
+	return stdout.String(), stderr.String(), 0, nil
+}
+
+// RuntimeVersion returns version of containerd, not of nerdctl.
+func (ccc *ContainerdContainerClient) RuntimeVersion(ctx context.Context) (Version, error) {
+	out, err := ccc.nerdctl(ctx, "version", "--format", `{{range .Server.Components}}{{if eq .Name "containerd"}}{{.Version}}{{end}}{{end}}`)
+	if err != nil {
+		return "", err
+	}
+	version := strings.TrimSpace(out)
+	if version == "" {
+		return "", errors.New("containerd version not reported")
+	}
+	return Version(version), nil
+}
+
+// Attach stream stdio of container by nerdctl attach, which needs container run with Stdin of spec to send input.
+func (ccc *ContainerdContainerClient) Attach(ctx context.Context, container *Container, stdin io.Reader, stdout, stderr io.Writer) error {
+	c := exec.CommandContext(ctx, ccc.command, ccc.args("attach", container.Hash)...)
+	c.Stdin = stdin
+	c.Stdout = stdout
+	c.Stderr = stderr
+	if err := c.Run(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return nil
+}
+
+// nerdctlRunArgs translate container into arguments of nerdctl run.
+func nerdctlRunArgs(container *Container) ([]string, error) {
+	if container.Image == nil {
+		return nil, errors.New("not set image")
+	}
+	args := []string{"run", "--detach", "--name", container.Name}
+	for _, env := range container.Spec.Env {
+		args = append(args, "--env", env)
+	}
+	for _, pm := range container.Spec.Ports {
+		protocol := pm.Protocol
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		// HostPort 0 is left empty so that random port is picked
+		hostPort := ""
+		if pm.HostPort != 0 {
+			hostPort = strconv.Itoa(pm.HostPort)
+		}
+		args = append(args, "--publish", fmt.Sprintf("%v:%d/%v", hostPort, pm.ContainerPort, protocol))
+	}
+	for _, v := range container.Spec.Volumes {
+		volume := v.String()
+		if v.Source == "" {
+			// anonymous volume
+			volume = strings.TrimPrefix(volume, ":")
+		}
+		args = append(args, "--volume", volume)
+	}
+	if limits := container.Spec.ResourceLimits; limits.CPUShares > 0 {
+		args = append(args, "--cpu-shares", strconv.FormatInt(limits.CPUShares, 10))
+	}
+	if limits := container.Spec.ResourceLimits; limits.MemoryMB > 0 {
+		args = append(args, "--memory", fmt.Sprintf("%dm", limits.MemoryMB))
+	}
+	if container.Spec.WorkingDir != "" {
+		args = append(args, "--workdir", container.Spec.WorkingDir)
+	}
+	if container.Spec.Stdin {
+		args = append(args, "--interactive")
+	}
+	args = append(args, container.Image.FullName)
+	return append(args, container.Spec.Command...), nil
+}
+
+// nerdctlInspected is part of docker compatible output of nerdctl container inspect.
+type nerdctlInspected struct {
+	Created string
+	State   struct {
+		Status     string
+		Error      string
+		ExitCode   int
+		StartedAt  string
+		FinishedAt string
+	}
+	NetworkSettings struct {
+		Ports map[string][]struct {
+			HostIp   string
+			HostPort string
+		}
+	}
+	Mounts []struct {
+		Type        string
+		Name        string
+		Source      string
+		Destination string
+		RW          bool
+	}
+}
+
+func parseNerdctlInspect(container *Container, out []byte) (*ContainerStatus, error) {
+	inspected := []nerdctlInspected{}
+	if err := json.Unmarshal(out, &inspected); err != nil {
+		return nil, err
+	}
+	if len(inspected) == 0 {
+		return nil, fmt.Errorf("%w for hash:%v", ErrContainerNotFound, container.Hash)
+	}
+	i := inspected[0]
+	status := NewContainerStatus(container.Id, container.Name, container.NodeName)
+	status.Reason = "inspected by nerdctl"
+	status.CreatedAt = parseRuntimeTime(i.Created)
+	status.StartedAt = parseRuntimeTime(i.State.StartedAt)
+	status.FinishedAt = parseRuntimeTime(i.State.FinishedAt)
+	status.ContainerState = nerdctlContainerState(i.State.Status)
+	if status.ContainerState == ContainerExited {
+		status.ExitCode = i.State.ExitCode
+	}
+	if i.State.Error != "" {
+		status.Error = errors.New(i.State.Error)
+	} else if status.ContainerState == ContainerExited && i.State.ExitCode != 0 {
+		status.Error = fmt.Errorf("exited with code:%d", i.State.ExitCode)
+	}
+	ports := []PortMapping{}
+	for port, bindings := range i.NetworkSettings.Ports {
+		containerPort, protocol := port, "tcp"
+		if j := strings.Index(port, "/"); j >= 0 {
+			containerPort, protocol = port[:j], port[j+1:]
+		}
+		cp, err := strconv.Atoi(containerPort)
+		if err != nil {
+			continue
+		}
+		for _, binding := range bindings {
+			hostPort, err := strconv.Atoi(binding.HostPort)
+			if err != nil {
+				continue
+			}
+			ports = append(ports, PortMapping{HostPort: hostPort, ContainerPort: cp, Protocol: protocol})
+		}
+	}
+	sortPorts(ports)
+	status.Ports = ports
+	status.Volumes = []VolumeMount{}
+	for _, m := range i.Mounts {
+		source := m.Source
+		if m.Type == "volume" {
+			source = m.Name
+		}
+		status.Volumes = append(status.Volumes, VolumeMount{Source: source, Target: m.Destination, ReadOnly: !m.RW})
+	}
+	return status, nil
+}
+
+func nerdctlContainerState(state string) ContainerState {
+	switch state {
+	case "created":
+		return ContainerCreated
+	case "running", "restarting":
+		return ContainerRunning
+	case "paused":
+		return ContainerPaused
+	case "exited", "dead", "removing":
+		return ContainerExited
+	default:
+		return ContainerUnknown
+	}
+}
@@ -0,0 +1,169 @@
+package cluster
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNerdctlRunArgs(t *testing.T) {
+	container := NewContainer("id1", "name1", "", "node1", "nodename1", &Image{FullName: "docker.io/library/nginx:latest"}, "", nil)
+	container.Spec = ContainerSpec{
+		Env:            []string{"KEY=VALUE"},
+		Ports:          []PortMapping{PortMapping{HostPort: 80, ContainerPort: 8080}, PortMapping{ContainerPort: 53, Protocol: "udp"}},
+		Volumes:        []VolumeMount{VolumeMount{Source: "/data", Target: "/var/data", ReadOnly: true}, VolumeMount{Target: "/tmp"}},
+		WorkingDir:     "/app",
+		Stdin:          true,
+		ResourceLimits: Capacity{CPUShares: 512, MemoryMB: 256},
+		Command:        []string{"nginx", "-g", "daemon off;"},
+	}
+	expected := []string{
+		"run", "--detach", "--name", "name1",
+		"--env", "KEY=VALUE",
+		"--publish", "80:8080/tcp",
+		"--publish", ":53/udp",
+		"--volume", "/data:/var/data:ro",
+		"--volume", "/tmp",
+		"--cpu-shares", "512",
+		"--memory", "256m",
+		"--workdir", "/app",
+		"--interactive",
+		"docker.io/library/nginx:latest",
+		"nginx", "-g", "daemon off;",
+	}
+	args, err := nerdctlRunArgs(container)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expected, args) {
+		t.Errorf("want:%v,have:%v", expected, args)
+	}
+	container.Image = nil
+	if _, err := nerdctlRunArgs(container); err == nil {
+		t.Error("want error for no image")
+	}
+}
+
+func TestParseNerdctlInspect(t *testing.T) {
+	container := NewContainer("id1", "name1", "hash1", "node1", "nodename1", testImage, "", nil)
+	out := `[{
+		"Created": "2020-01-01T00:00:00Z",
+		"State": {"Status": "exited", "ExitCode": 1, "StartedAt": "2020-01-01T00:00:01Z", "FinishedAt": "2020-01-01T00:00:02Z"},
+		"NetworkSettings": {"Ports": {"8080/tcp": [{"HostIp": "0.0.0.0", "HostPort": "80"}], "53/udp": [{"HostIp": "0.0.0.0", "HostPort": "32768"}]}},
+		"Mounts": [{"Type": "bind", "Source": "/data", "Destination": "/var/data", "RW": false}, {"Type": "volume", "Name": "cache", "Source": "/var/lib/cache", "Destination": "/cache", "RW": true}]
+	}]`
+	status, err := parseNerdctlInspect(container, []byte(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.ContainerState != ContainerExited || status.ExitCode != 1 || status.Error == nil {
+		t.Errorf("want:%v,have:%v", ContainerExited, status)
+	}
+	if !status.FinishedAt.Equal(time.Date(2020, 1, 1, 0, 0, 2, 0, time.UTC)) {
+		t.Errorf("want:%v,have:%v", "2020-01-01T00:00:02Z", status.FinishedAt)
+	}
+	expectedPorts := []PortMapping{
+		PortMapping{HostPort: 32768, ContainerPort: 53, Protocol: "udp"},
+		PortMapping{HostPort: 80, ContainerPort: 8080, Protocol: "tcp"},
+	}
+	if !reflect.DeepEqual(expectedPorts, status.Ports) {
+		t.Errorf("want:%v,have:%v", expectedPorts, status.Ports)
+	}
+	expectedVolumes := []VolumeMount{
+		VolumeMount{Source: "/data", Target: "/var/data", ReadOnly: true},
+		VolumeMount{Source: "cache", Target: "/cache"},
+	}
+	if !reflect.DeepEqual(expectedVolumes, status.Volumes) {
+		t.Errorf("want:%v,have:%v", expectedVolumes, status.Volumes)
+	}
+	if _, err := parseNerdctlInspect(container, []byte(`[]`)); err == nil {
+		t.Error("want error for no container")
+	}
+}
+
+func TestIsNerdctlNotFound(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{errors.New("exit status 1:time=\"\" level=fatal msg=\"no such image: nginx\""), true},
+		{errors.New("exit status 1:image \"nginx\": not found"), true},
+		{errors.New("exit status 1:permission denied"), false},
+	}
+	for _, tt := range tests {
+		if have := isNerdctlNotFound(tt.err); have != tt.want {
+			t.Errorf("%v want:%v,have:%v", tt.err, tt.want, have)
+		}
+	}
+}
+
+func TestParseNerdctlImages(t *testing.T) {
+	out := `{"Repository":"docker.io/library/nginx","Tag":"latest","Digest":"sha256:abc"}
+{"Repository":"registry.example.com/app","Tag":"<none>","Digest":"sha256:def"}
+{"Repository":"<none>","Tag":"<none>","Digest":""}
+`
+	images, err := parseNerdctlImages([]byte(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"docker.io/library/nginx:latest", "registry.example.com/app@sha256:def"}
+	if len(images) != len(expected) {
+		t.Fatalf("want:%v,have:%v", expected, images)
+	}
+	for i, image := range images {
+		if image.FullName != expected[i] {
+			t.Errorf("want:%v,have:%v", expected[i], image.FullName)
+		}
+	}
+}
+
+func TestParseNerdctlStats(t *testing.T) {
+	tests := []struct {
+		name     string
+		out      string
+		expected *ContainerStats
+	}{
+		{"binary", `{"CPUPerc":"12.50%","MemUsage":"512KiB / 1GiB"}`, &ContainerStats{CPUPercent: 12.5, MemoryBytes: 512 << 10, MemoryLimitBytes: 1 << 30}},
+		{"decimal", `{"CPUPerc":"0.00%","MemUsage":"1.5MB / 2GB"}` + "\n", &ContainerStats{MemoryBytes: 1500000, MemoryLimitBytes: 2000000000}},
+		{"invalid", `{"CPUPerc":"--","MemUsage":"-- / --"}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats, err := parseNerdctlStats([]byte(tt.out))
+			if tt.expected == nil {
+				if err == nil {
+					t.Errorf("want:error,have:%v", stats)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tt.expected, stats) {
+				t.Errorf("want:%v,have:%v", tt.expected, stats)
+			}
+		})
+	}
+}
+
+func TestNerdctlLogsArgs(t *testing.T) {
+	container := &Container{Hash: "hash1"}
+	since := time.Unix(1700000000, 5)
+	tests := []struct {
+		name     string
+		options  LogsOptions
+		expected []string
+	}{
+		{"all", LogsOptions{}, []string{"logs", "--tail", "all", "hash1"}},
+		{"tail", LogsOptions{Follow: true, Tail: 10}, []string{"logs", "--tail", "10", "--follow", "hash1"}},
+		{"since", LogsOptions{Since: since, Timestamps: true}, []string{"logs", "--tail", "all", "--since", "1700000000.000000005", "--timestamps", "hash1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if have := nerdctlLogsArgs(container, tt.options); !reflect.DeepEqual(tt.expected, have) {
+				t.Errorf("want:%v,have:%v", tt.expected, have)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultReadyTimeout is duration a container may take to be ready when it is waited for.
+const DefaultReadyTimeout = 5 * time.Minute
+
+// SetReadyTimeout set duration a container may take to be ready when it is waited for, 0 disables it.
+func (dcs *DefaultClusterService) SetReadyTimeout(timeout time.Duration) {
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	dcs.readyTimeout = timeout
+}
+
+// RunContainersOrdered run containers after their dependencies in Spec.DependsOn.
+// Each container starts after its dependencies are running, or healthy if they have health check.
+// Dependencies not in containers are waited for but not run. Already running containers are skipped.
+// It stops at first error, and returns ErrDependencyCycle without running any container if dependencies form a cycle.
+func (dcs *DefaultClusterService) RunContainersOrdered(containers Containers) error {
+	return dcs.RunContainersOrderedContext(context.Background(), containers)
+}
+
+// RunContainersOrderedContext is RunContainersOrdered which gives up when ctx is done.
+func (dcs *DefaultClusterService) RunContainersOrderedContext(ctx context.Context, containers Containers) error {
+	ordered, err := sortByDependencies(containers)
+	if err != nil {
+		return err
+	}
+	for _, c := range ordered {
+		for _, dependency := range c.Spec.DependsOn {
+			if err := dcs.waitForReady(ctx, dependency); err != nil {
+				return fmt.Errorf("dependency of %v: %w", c.Name, err)
+			}
+		}
+		if err := dcs.RunContainerContext(ctx, c); err != nil && !errors.Is(err, ErrAlreadyRunning) {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortByDependencies returns containers sorted topologically, keeping order of independent ones.
+func sortByDependencies(containers Containers) (Containers, error) {
+	byId := make(map[UID]*Container, len(containers))
+	for _, c := range containers {
+		byId[c.Id] = c
+	}
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	marks := make(map[UID]int, len(containers))
+	sorted := make(Containers, 0, len(containers))
+	var path []string
+	var visit func(c *Container) error
+	visit = func(c *Container) error {
+		switch marks[c.Id] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("%w:%v->%v", ErrDependencyCycle, strings.Join(path, "->"), c.Name)
+		}
+		marks[c.Id] = visiting
+		path = append(path, c.Name)
+		for _, dependency := range c.Spec.DependsOn {
+			if d, ok := byId[dependency]; ok {
+				if err := visit(d); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		marks[c.Id] = visited
+		sorted = append(sorted, c)
+		return nil
+	}
+	for _, c := range containers {
+		if err := visit(c); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
+
+// waitForReady blocks until container is running and healthy if it has health check, or ctx is done.
+// It fails if container exits or gets unhealthy, or is not ready within ready timeout.
+func (dcs *DefaultClusterService) waitForReady(ctx context.Context, uid UID) error {
+	dcs.mu.RLock()
+	timeout := dcs.readyTimeout
+	dcs.mu.RUnlock()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	events, unwatch := dcs.Watch()
+	defer unwatch()
+	ticker := time.NewTicker(waitPollInterval)
+	defer ticker.Stop()
+	for {
+		ready, err := dcs.containerReady(uid)
+		if err != nil || ready {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-events:
+		case <-ticker.C:
+		}
+	}
+}
+
+// containerReady returns true if container is running, and healthy and ready if it has health check and readiness probe.
+// It returns error if container exited, or failed its health check or readiness probe.
+func (dcs *DefaultClusterService) containerReady(uid UID) (bool, error) {
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
+	c := dcs.findContainerById(uid)
+	if c == nil {
+		return false, fmt.Errorf("%w for uid:%v", ErrContainerNotFound, uid)
+	}
+	state := containerStateOf(c)
+	if state == ContainerExited {
+		return false, fmt.Errorf("%w for uid:%v, want:%v, have:%v", ErrUnexpectedState, uid, ContainerRunning, state)
+	}
+	if state != ContainerRunning {
+		return false, nil
+	}
+	if c.Spec.HealthCheck != nil && c.ContainerStatus.Health == Unhealthy {
+		return false, fmt.Errorf("%w for uid:%v, want:%v, have health:%v", ErrUnexpectedState, uid, Healthy, Unhealthy)
+	}
+	if c.Spec.ReadinessProbe != nil && c.ContainerStatus.Readiness == Unhealthy {
+		return false, fmt.Errorf("%w for uid:%v, want:%v, have readiness:%v", ErrUnexpectedState, uid, Healthy, Unhealthy)
+	}
+	return (c.Spec.HealthCheck == nil || c.ContainerStatus.Health == Healthy) &&
+		(c.Spec.ReadinessProbe == nil || c.ContainerStatus.Readiness == Healthy), nil
+}
@@ -0,0 +1,81 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDefaultClusterService_RunContainersOrdered(t *testing.T) {
+	clusterService, client := newTestRestartService(t)
+	db, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	app, _ := clusterService.CreateContainerWithSpec(ContainerSpec{DependsOn: []UID{db.Id}})
+	worker, _ := clusterService.CreateContainerWithSpec(ContainerSpec{DependsOn: []UID{app.Id, db.Id}})
+	if err := clusterService.RunContainersOrdered(Containers{worker, app, db}); err != nil {
+		t.Fatal(err)
+	}
+	expected := []UID{db.Id, app.Id, worker.Id}
+	have := []UID{}
+	for _, c := range client.runs {
+		have = append(have, c.Id)
+	}
+	if !reflect.DeepEqual(expected, have) {
+		t.Errorf("want:%v,have:%v", expected, have)
+	}
+}
+
+func TestDefaultClusterService_RunContainersOrdered_Healthy(t *testing.T) {
+	clusterService, _ := newTestRestartService(t)
+	db, _ := clusterService.CreateContainerWithSpec(ContainerSpec{HealthCheck: &HealthCheck{Command: []string{"true"}, Interval: 10 * time.Millisecond}})
+	app, _ := clusterService.CreateContainerWithSpec(ContainerSpec{DependsOn: []UID{db.Id}})
+	clusterService.RunContainer(db)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := clusterService.RunContainersOrderedContext(ctx, Containers{app}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want:%v,have:%v", context.DeadlineExceeded, err)
+	}
+
+	// db gets healthy by health check
+	healthCtx, healthCancel := context.WithCancel(context.Background())
+	defer healthCancel()
+	clusterService.StartHealthCheck(healthCtx)
+	if err := clusterService.RunContainersOrdered(Containers{app}); err != nil {
+		t.Fatal(err)
+	}
+	if app.ContainerStatus.ContainerState != ContainerRunning {
+		t.Errorf("want:%v,have:%v", ContainerRunning, app.ContainerStatus.ContainerState)
+	}
+}
+
+func TestSortByDependencies(t *testing.T) {
+	a := &Container{Id: "a", Name: "a", Spec: ContainerSpec{DependsOn: []UID{"b"}}}
+	b := &Container{Id: "b", Name: "b", Spec: ContainerSpec{DependsOn: []UID{"c"}}}
+	c := &Container{Id: "c", Name: "c"}
+	cyclic := &Container{Id: "c", Name: "c", Spec: ContainerSpec{DependsOn: []UID{"a"}}}
+	outside := &Container{Id: "d", Name: "d", Spec: ContainerSpec{DependsOn: []UID{"unknown"}}}
+	tests := []struct {
+		name       string
+		containers Containers
+		expected   Containers
+		err        error
+	}{
+		{"chain", Containers{a, b, c}, Containers{c, b, a}, nil},
+		{"independent", Containers{outside, c}, Containers{outside, c}, nil},
+		{"cycle", Containers{a, b, cyclic}, nil, ErrDependencyCycle},
+		{"self", Containers{{Id: "e", Name: "e", Spec: ContainerSpec{DependsOn: []UID{"e"}}}}, nil, ErrDependencyCycle},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sorted, err := sortByDependencies(tt.containers)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("want:%v,have:%v", tt.err, err)
+			}
+			if !reflect.DeepEqual(tt.expected, sorted) {
+				t.Errorf("want:%v,have:%v", tt.expected, sorted)
+			}
+		})
+	}
+}
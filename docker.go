@@ -0,0 +1,481 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	imagetypes "github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/mount"
+	registrytypes "github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/docker/go-connections/nat"
+)
+
+func init() {
+	RegisterContainerRuntime(RuntimeDocker, func(endpoint string) (ContainerClient, error) {
+		return NewDockerContainerClient(endpoint, "")
+	})
+}
+
+// DockerContainerClient is a ContainerClient backed by docker daemon.
+type DockerContainerClient struct {
+	client *client.Client
+	auths  registryAuths
+}
+
+// NewDockerContainerClient create client for docker daemon on host(ex: unix:///var/run/docker.sock)
+// with api version(ex: 1.39), empty version means default of client.
+func NewDockerContainerClient(host string, version string) (*DockerContainerClient, error) {
+	cli, err := client.NewClientWithOpts(client.WithHost(host), client.WithVersion(version))
+	if err != nil {
+		return nil, err
+	}
+	return &DockerContainerClient{client: cli}, nil
+}
+
+// Run create and start container, returns container id on daemon.
+func (dcc *DockerContainerClient) Run(ctx context.Context, container *Container) (string, error) {
+	if container.Image == nil {
+		return "", errors.New("not set image")
+	}
+	exposedPorts, portBindings, err := dockerPortBindings(container.Spec.Ports)
+	if err != nil {
+		return "", err
+	}
+	config := &containertypes.Config{
+		Image:        container.Image.FullName,
+		Env:          container.Spec.Env,
+		Cmd:          container.Spec.Command,
+		WorkingDir:   container.Spec.WorkingDir,
+		ExposedPorts: exposedPorts,
+		// stdin is kept open after attached sessions are closed, as container is run detached
+		OpenStdin:   container.Spec.Stdin,
+		AttachStdin: container.Spec.Stdin,
+	}
+	hostConfig := &containertypes.HostConfig{
+		PortBindings: portBindings,
+		Mounts:       dockerMounts(container.Spec.Volumes),
+		Resources:    dockerResources(container.Spec.ResourceLimits),
+	}
+	created, err := dcc.client.ContainerCreate(ctx, config, hostConfig, nil, nil, container.Name)
+	if err != nil {
+		return "", err
+	}
+	if err := dcc.client.ContainerStart(ctx, created.ID, containertypes.StartOptions{}); err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+// RuntimeVersion returns version of docker engine, or podman serving docker compatible api.
+func (dcc *DockerContainerClient) RuntimeVersion(ctx context.Context) (Version, error) {
+	v, err := dcc.client.ServerVersion(ctx)
+	if err != nil {
+		return "", err
+	}
+	return Version(v.Version), nil
+}
+
+// Close release connection to docker daemon.
+func (dcc *DockerContainerClient) Close() error {
+	return dcc.client.Close()
+}
+
+func (dcc *DockerContainerClient) Stop(ctx context.Context, container *Container, gracePeriod time.Duration) error {
+	if gracePeriod <= 0 {
+		return dcc.client.ContainerKill(ctx, container.Hash, "SIGKILL")
+	}
+	timeout := stopTimeout(gracePeriod)
+	return dcc.client.ContainerStop(ctx, container.Hash, containertypes.StopOptions{Timeout: &timeout})
+}
+
+// stopTimeout returns grace period in seconds rounded up, as runtime kills immediately on 0.
+func stopTimeout(gracePeriod time.Duration) int {
+	return int(math.Ceil(gracePeriod.Seconds()))
+}
+
+func (dcc *DockerContainerClient) Pause(ctx context.Context, container *Container) error {
+	return dcc.client.ContainerPause(ctx, container.Hash)
+}
+
+func (dcc *DockerContainerClient) Unpause(ctx context.Context, container *Container) error {
+	return dcc.client.ContainerUnpause(ctx, container.Hash)
+}
+
+func (dcc *DockerContainerClient) ImageId(ctx context.Context, image *Image) (string, error) {
+	inspected, err := dcc.client.ImageInspect(ctx, image.FullName)
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return "", fmt.Errorf("%w:%v", ErrImageNotFound, image.FullName)
+		}
+		return "", err
+	}
+	return inspected.ID, nil
+}
+
+// SetRegistryAuth register auth used to pull image from registry, empty registry means DefaultRegistry.
+func (dcc *DockerContainerClient) SetRegistryAuth(registry string, auth RegistryAuth) {
+	dcc.auths.set(registry, auth)
+}
+
+// PullImage pull image and wait until it is done, returning error reported in progress of pull.
+// auth registered for registry of image is sent if any.
+func (dcc *DockerContainerClient) PullImage(ctx context.Context, image *Image) error {
+	options := imagetypes.PullOptions{}
+	if auth, ok := dcc.auths.forImage(image); ok {
+		encoded, err := registrytypes.EncodeAuthConfig(registrytypes.AuthConfig{
+			Username:      auth.Username,
+			Password:      auth.Password,
+			IdentityToken: auth.IdentityToken,
+			ServerAddress: normalizeRegistry(image.Registry),
+		})
+		if err != nil {
+			return err
+		}
+		options.RegistryAuth = encoded
+	}
+	progress, err := dcc.client.ImagePull(ctx, image.FullName, options)
+	if err != nil {
+		return err
+	}
+	defer progress.Close()
+	return jsonmessage.DisplayJSONMessagesStream(progress, io.Discard, 0, false, nil)
+}
+
+func (dcc *DockerContainerClient) ListImages(ctx context.Context) ([]*Image, error) {
+	summaries, err := dcc.client.ImageList(ctx, imagetypes.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	images := []*Image{}
+	for _, summary := range summaries {
+		references := summary.RepoTags
+		if len(references) == 0 {
+			references = summary.RepoDigests
+		}
+		images = append(images, parseImages(references)...)
+	}
+	return images, nil
+}
+
+func (dcc *DockerContainerClient) RemoveImage(ctx context.Context, image *Image) error {
+	_, err := dcc.client.ImageRemove(ctx, image.FullName, imagetypes.RemoveOptions{})
+	if client.IsErrNotFound(err) {
+		return fmt.Errorf("%w:%v", ErrImageNotFound, image.FullName)
+	}
+	return err
+}
+
+func (dcc *DockerContainerClient) Inspect(ctx context.Context, container *Container) (*ContainerStatus, error) {
+	inspected, err := dcc.client.ContainerInspect(ctx, container.Hash)
+	if err != nil {
+		return nil, err
+	}
+	status := NewContainerStatus(container.Id, container.Name, container.NodeName)
+	status.Reason = "inspected by docker"
+	if inspected.ContainerJSONBase == nil || inspected.State == nil {
+		return status, nil
+	}
+	status.CreatedAt = parseRuntimeTime(inspected.Created)
+	status.StartedAt = parseRuntimeTime(inspected.State.StartedAt)
+	status.FinishedAt = parseRuntimeTime(inspected.State.FinishedAt)
+	status.ContainerState = dockerContainerState(inspected.State.Status)
+	if status.ContainerState == ContainerExited {
+		status.ExitCode = inspected.State.ExitCode
+	}
+	if inspected.State.Error != "" {
+		status.Error = errors.New(inspected.State.Error)
+	} else if status.ContainerState == ContainerExited && inspected.State.ExitCode != 0 {
+		status.Error = fmt.Errorf("exited with code:%d", inspected.State.ExitCode)
+	}
+	if inspected.NetworkSettings != nil {
+		status.Ports = dockerBoundPorts(inspected.NetworkSettings.Ports)
+	}
+	status.Volumes = dockerMountedVolumes(inspected.Mounts)
+	return status, nil
+}
+
+func (dcc *DockerContainerClient) Remove(ctx context.Context, container *Container) error {
+	return dcc.client.ContainerRemove(ctx, container.Hash, containertypes.RemoveOptions{})
+}
+
+// Logs returns stdout and stderr of container, demultiplexed from daemon stream.
+func (dcc *DockerContainerClient) Logs(ctx context.Context, container *Container, options LogsOptions) (io.ReadCloser, error) {
+	logs, err := dcc.client.ContainerLogs(ctx, container.Hash, dockerLogsOptions(options))
+	if err != nil {
+		return nil, err
+	}
+	reader, writer := io.Pipe()
+	go func() {
+		_, err := stdcopy.StdCopy(writer, writer, logs)
+		logs.Close()
+		writer.CloseWithError(err)
+	}()
+	return &dockerLogReader{PipeReader: reader, logs: logs}, nil
+}
+
+func dockerLogsOptions(options LogsOptions) containertypes.LogsOptions {
+	return containertypes.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     options.Follow,
+		Tail:       options.tail(),
+		Since:      options.since(),
+		Timestamps: options.Timestamps,
+	}
+}
+
+// dockerLogReader close daemon stream with the pipe to stop following.
+type dockerLogReader struct {
+	*io.PipeReader
+	logs io.Closer
+}
+
+func (dlr *dockerLogReader) Close() error {
+	dlr.logs.Close()
+	return dlr.PipeReader.Close()
+}
+
+// dockerResources translate limits into resources of host config, zero field is unlimited.
+func dockerResources(limits Capacity) containertypes.Resources {
+	return containertypes.Resources{
+		CPUShares: limits.CPUShares,
+		Memory:    limits.MemoryMB * 1024 * 1024,
+	}
+}
+
+// dockerPortBindings translate port mappings into exposed ports and bindings.
+// HostPort 0 is left empty so that daemon picks a random port.
+func dockerPortBindings(ports []PortMapping) (nat.PortSet, nat.PortMap, error) {
+	if len(ports) == 0 {
+		return nil, nil, nil
+	}
+	exposedPorts := nat.PortSet{}
+	portBindings := nat.PortMap{}
+	for _, pm := range ports {
+		protocol := pm.Protocol
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		port, err := nat.NewPort(protocol, strconv.Itoa(pm.ContainerPort))
+		if err != nil {
+			return nil, nil, err
+		}
+		hostPort := ""
+		if pm.HostPort != 0 {
+			hostPort = strconv.Itoa(pm.HostPort)
+		}
+		exposedPorts[port] = struct{}{}
+		portBindings[port] = append(portBindings[port], nat.PortBinding{HostPort: hostPort})
+	}
+	return exposedPorts, portBindings, nil
+}
+
+// dockerBoundPorts translate bound ports reported by daemon into port mappings.
+func dockerBoundPorts(portMap nat.PortMap) []PortMapping {
+	ports := []PortMapping{}
+	for port, bindings := range portMap {
+		for _, binding := range bindings {
+			hostPort, err := strconv.Atoi(binding.HostPort)
+			if err != nil {
+				continue
+			}
+			ports = append(ports, PortMapping{
+				HostPort:      hostPort,
+				ContainerPort: port.Int(),
+				Protocol:      port.Proto(),
+			})
+		}
+	}
+	sortPorts(ports)
+	return ports
+}
+
+// dockerMounts translate volumes into bind mounts of host path or named volumes.
+func dockerMounts(volumes []VolumeMount) []mount.Mount {
+	if len(volumes) == 0 {
+		return nil
+	}
+	mounts := make([]mount.Mount, 0, len(volumes))
+	for _, v := range volumes {
+		mountType := mount.TypeVolume
+		if v.Bind() {
+			mountType = mount.TypeBind
+		}
+		mounts = append(mounts, mount.Mount{
+			Type:     mountType,
+			Source:   v.Source,
+			Target:   v.Target,
+			ReadOnly: v.ReadOnly,
+		})
+	}
+	return mounts
+}
+
+// dockerMountedVolumes translate mounts reported by daemon into volumes, named by volume name if not bound.
+func dockerMountedVolumes(mountPoints []containertypes.MountPoint) []VolumeMount {
+	volumes := []VolumeMount{}
+	for _, mp := range mountPoints {
+		source := mp.Source
+		if mp.Type == mount.TypeVolume {
+			source = mp.Name
+		}
+		volumes = append(volumes, VolumeMount{Source: source, Target: mp.Destination, ReadOnly: !mp.RW})
+	}
+	return volumes
+}
+
+// Stats returns stats sampled by daemon, which waits for a cycle to compute cpu usage.
+func (dcc *DockerContainerClient) Stats(ctx context.Context, container *Container) (*ContainerStats, error) {
+	reader, err := dcc.client.ContainerStats(ctx, container.Hash, false)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Body.Close()
+	stats := containertypes.StatsResponse{}
+	if err := json.NewDecoder(reader.Body).Decode(&stats); err != nil {
+		return nil, err
+	}
+	return dockerStats(stats), nil
+}
+
+// Update change cpu shares and memory of container, 0 keeps current value.
+func (dcc *DockerContainerClient) Update(ctx context.Context, container *Container, limits Capacity) error {
+	_, err := dcc.client.ContainerUpdate(ctx, container.Hash, containertypes.UpdateConfig{Resources: dockerResources(limits)})
+	return err
+}
+
+// dockerStats translate stats of daemon same as docker stats command.
+func dockerStats(stats containertypes.StatsResponse) *ContainerStats {
+	res := &ContainerStats{
+		MemoryBytes:      int64(stats.MemoryStats.Usage),
+		MemoryLimitBytes: int64(stats.MemoryStats.Limit),
+	}
+	// page cache is not counted, cgroup v1 reports it as total_inactive_file and v2 as inactive_file
+	if cache, ok := stats.MemoryStats.Stats["total_inactive_file"]; ok && cache < stats.MemoryStats.Usage {
+		res.MemoryBytes -= int64(cache)
+	} else if cache, ok := stats.MemoryStats.Stats["inactive_file"]; ok && cache < stats.MemoryStats.Usage {
+		res.MemoryBytes -= int64(cache)
+	}
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+	cpus := float64(stats.CPUStats.OnlineCPUs)
+	if cpus == 0 {
+		cpus = float64(len(stats.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 {
+		res.CPUPercent = cpuDelta / systemDelta * cpus * 100
+	}
+	return res
+}
+
+// Exec run cmd in running container and wait for it. exitCode is valid only if err is nil.
+func (dcc *DockerContainerClient) Exec(ctx context.Context, container *Container, cmd []string) (string, string, int, error) {
+	created, err := dcc.client.ContainerExecCreate(ctx, container.Hash, containertypes.ExecOptions{
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return "", "", 0, err
+	}
+	attached, err := dcc.client.ContainerExecAttach(ctx, created.ID, containertypes.ExecAttachOptions{})
+	if err != nil {
+		return "", "", 0, err
+	}
+	defer attached.Close()
+	var stdout, stderr bytes.Buffer
+	// hijacked connection does not honor ctx, so it is closed on done by defer
+	copied := make(chan error, 1)
+	go func() {
+		_, err := stdcopy.StdCopy(&stdout, &stderr, attached.Reader)
+		copied <- err
+	}()
+	select {
+	case <-ctx.Done():
+		return "", "", 0, ctx.Err()
+	case err := <-copied:
+		if err != nil {
+			return "", "", 0, err
+		}
+	}
+	inspected, err := dcc.client.ContainerExecInspect(ctx, created.ID)
+	if err != nil {
+		return "", "", 0, err
+	}
+	return stdout.String(), stderr.String(), inspected.ExitCode, nil
+}
+
+// Attach stream stdio of container over hijacked connection. container is run without tty,
+// so output is demultiplexed into stdout and stderr.
+func (dcc *DockerContainerClient) Attach(ctx context.Context, container *Container, stdin io.Reader, stdout, stderr io.Writer) error {
+	attached, err := dcc.client.ContainerAttach(ctx, container.Hash, containertypes.AttachOptions{
+		Stream: true,
+		Stdin:  stdin != nil,
+		Stdout: true,
+		Stderr: true,
+	})
+	if err != nil {
+		return err
+	}
+	// hijacked connection does not honor ctx, so it is closed on done by defer
+	defer attached.Close()
+	// copying stdin stops when output ends or ctx is done, not to outlive the connection
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if stdin != nil {
+		go func() {
+			io.Copy(attached.Conn, &contextReader{ctx: ctx, reader: stdin})
+			attached.CloseWrite()
+		}()
+	}
+	copied := make(chan error, 1)
+	go func() {
+		_, err := stdcopy.StdCopy(stdout, stderr, attached.Reader)
+		copied <- err
+	}()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-copied:
+		return err
+	}
+}
+
+// contextReader is reader which fails once ctx is done, so that copy from it stops at the next read.
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (cr *contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.reader.Read(p)
+}
+
+func dockerContainerState(state string) ContainerState {
+	switch state {
+	case containertypes.StateCreated:
+		return ContainerCreated
+	case containertypes.StateRunning, containertypes.StateRestarting:
+		return ContainerRunning
+	case containertypes.StatePaused:
+		return ContainerPaused
+	case containertypes.StateExited, containertypes.StateDead, containertypes.StateRemoving:
+		return ContainerExited
+	default:
+		return ContainerUnknown
+	}
+}
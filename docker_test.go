@@ -0,0 +1,184 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-connections/nat"
+)
+
+func TestDockerPortBindings(t *testing.T) {
+	ports := []PortMapping{
+		PortMapping{HostPort: 80, ContainerPort: 8080},
+		PortMapping{HostPort: 0, ContainerPort: 53, Protocol: "udp"},
+	}
+	exposedPorts, portBindings, err := dockerPortBindings(ports)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedExposed := nat.PortSet{"8080/tcp": struct{}{}, "53/udp": struct{}{}}
+	if !reflect.DeepEqual(expectedExposed, exposedPorts) {
+		t.Errorf("want:%v,have:%v", expectedExposed, exposedPorts)
+	}
+	expectedBindings := nat.PortMap{
+		"8080/tcp": []nat.PortBinding{nat.PortBinding{HostPort: "80"}},
+		"53/udp":   []nat.PortBinding{nat.PortBinding{HostPort: ""}},
+	}
+	if !reflect.DeepEqual(expectedBindings, portBindings) {
+		t.Errorf("want:%v,have:%v", expectedBindings, portBindings)
+	}
+}
+
+func TestDockerResources(t *testing.T) {
+	resources := dockerResources(Capacity{CPUShares: 512, MemoryMB: 256})
+	if resources.CPUShares != 512 || resources.Memory != 256*1024*1024 {
+		t.Errorf("want:%v,have:%v", "512,268435456", resources)
+	}
+	if unlimited := dockerResources(Capacity{}); unlimited.CPUShares != 0 || unlimited.Memory != 0 {
+		t.Errorf("want:%v,have:%v", "0,0", unlimited)
+	}
+}
+
+func TestDockerBoundPorts(t *testing.T) {
+	portMap := nat.PortMap{
+		"8080/tcp": []nat.PortBinding{nat.PortBinding{HostIP: "0.0.0.0", HostPort: "80"}},
+		"53/udp":   []nat.PortBinding{nat.PortBinding{HostIP: "0.0.0.0", HostPort: "32768"}},
+	}
+	expected := []PortMapping{
+		PortMapping{HostPort: 32768, ContainerPort: 53, Protocol: "udp"},
+		PortMapping{HostPort: 80, ContainerPort: 8080, Protocol: "tcp"},
+	}
+	if ports := dockerBoundPorts(portMap); !reflect.DeepEqual(expected, ports) {
+		t.Errorf("want:%v,have:%v", expected, ports)
+	}
+}
+
+func TestDockerMounts(t *testing.T) {
+	volumes := []VolumeMount{
+		VolumeMount{Source: "/data", Target: "/var/data", ReadOnly: true},
+		VolumeMount{Source: "cache", Target: "/cache"},
+	}
+	expected := []mount.Mount{
+		mount.Mount{Type: mount.TypeBind, Source: "/data", Target: "/var/data", ReadOnly: true},
+		mount.Mount{Type: mount.TypeVolume, Source: "cache", Target: "/cache"},
+	}
+	if have := dockerMounts(volumes); !reflect.DeepEqual(expected, have) {
+		t.Errorf("want:%v,have:%v", expected, have)
+	}
+	if have := dockerMounts(nil); have != nil {
+		t.Errorf("want:nil,have:%v", have)
+	}
+}
+
+func TestDockerStats(t *testing.T) {
+	stats := containertypes.StatsResponse{}
+	stats.CPUStats.CPUUsage.TotalUsage = 300
+	stats.CPUStats.SystemUsage = 2000
+	stats.CPUStats.OnlineCPUs = 2
+	stats.PreCPUStats.CPUUsage.TotalUsage = 100
+	stats.PreCPUStats.SystemUsage = 1000
+	stats.MemoryStats.Usage = 3 << 20
+	stats.MemoryStats.Limit = 1 << 30
+	stats.MemoryStats.Stats = map[string]uint64{"inactive_file": 1 << 20}
+	expected := &ContainerStats{CPUPercent: 40, MemoryBytes: 2 << 20, MemoryLimitBytes: 1 << 30}
+	if have := dockerStats(stats); !reflect.DeepEqual(expected, have) {
+		t.Errorf("want:%v,have:%v", expected, have)
+	}
+}
+
+func TestDockerLogsOptions(t *testing.T) {
+	options := LogsOptions{Follow: true, Tail: 5, Since: time.Unix(1700000000, 0), Timestamps: true}
+	expected := containertypes.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     true,
+		Tail:       "5",
+		Since:      "1700000000.000000000",
+		Timestamps: true,
+	}
+	if have := dockerLogsOptions(options); !reflect.DeepEqual(expected, have) {
+		t.Errorf("want:%v,have:%v", expected, have)
+	}
+}
+
+func TestDockerContainerClient_Run_Stdin(t *testing.T) {
+	// fake daemon records config of created container
+	created := make(chan *containertypes.Config, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/containers/create"):
+			request := containertypes.CreateRequest{}
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			created <- request.Config
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"Id":"hash1"}`))
+		case strings.HasSuffix(r.URL.Path, "/start"):
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client, err := NewDockerContainerClient("tcp://"+server.Listener.Addr().String(), "1.41")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	for _, stdin := range []bool{true, false} {
+		container := NewContainer("id1", "name1", "", "node1", "nodename1", testImage, "", nil)
+		container.Spec.Stdin = stdin
+		hash, err := client.Run(context.Background(), container)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hash != "hash1" {
+			t.Errorf("want:%v,have:%v", "hash1", hash)
+		}
+		config := <-created
+		if config.OpenStdin != stdin || config.AttachStdin != stdin {
+			t.Errorf("want:%v,have:%v,%v", stdin, config.OpenStdin, config.AttachStdin)
+		}
+	}
+}
+
+func TestContextReader(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	reader := &contextReader{ctx: ctx, reader: strings.NewReader("input")}
+	p := make([]byte, 2)
+	if n, err := reader.Read(p); n != 2 || err != nil {
+		t.Errorf("want:%v,have:%v,%v", 2, n, err)
+	}
+	cancel()
+	if n, err := reader.Read(p); n != 0 || err != context.Canceled {
+		t.Errorf("want:%v,have:%v,%v", context.Canceled, n, err)
+	}
+}
+
+func TestStopTimeout(t *testing.T) {
+	tests := []struct {
+		gracePeriod time.Duration
+		want        int
+	}{
+		{500 * time.Millisecond, 1},
+		{time.Second, 1},
+		{1500 * time.Millisecond, 2},
+		{10 * time.Second, 10},
+	}
+	for _, tt := range tests {
+		if have := stopTimeout(tt.gracePeriod); have != tt.want {
+			t.Errorf("want:%v,have:%v", tt.want, have)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DrainNode mark node unschedulable and move its running containers to other nodes selected by scheduler.
+// running node becomes draining, which may be killed by KillNode or returned to running by Uncordon.
+// containers which could not be moved are left on the node, and reported by error.
+// runtime is called without lock, so stopping containers in grace period does not block others.
+func (dcs *DefaultClusterService) DrainNode(nodeId UID) error {
+	ctx := context.Background()
+	dcs.mu.Lock()
+	node, drains, reasons, err := dcs.drainNode(nodeId)
+	dcs.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	errs := make([]error, len(drains.containers))
+	for i, snapshot := range drains.snapshots {
+		errs[i] = drainOnClient(ctx, node, snapshot)
+	}
+
+	moved := &rescheduled{}
+	dcs.mu.Lock()
+	for i, c := range drains.containers {
+		dcs.releaseInFlight(c)
+		err := errs[i]
+		if err == nil {
+			err = dcs.applyDrain(nodeId, c, drains.snapshots[i])
+		}
+		if err != nil {
+			reasons = append(reasons, fmt.Sprintf("%v:%v", c.Id, err))
+			continue
+		}
+		moved.add(c)
+	}
+	dcs.mu.Unlock()
+	if len(reasons) > 0 {
+		err = fmt.Errorf("failed to drain containers:%v", strings.Join(reasons, ", "))
+	}
+	// moved containers are run after lock is released
+	if _, runErr := dcs.runRescheduled(ctx, moved); runErr != nil {
+		err = errors.Join(err, runErr)
+	}
+	return err
+}
+
+// drained is containers to be stopped and removed by DrainNode, with their snapshots taken under lock.
+type drained struct {
+	containers Containers
+	snapshots  Containers
+}
+
+// drainNode mark node draining under lock, and returns its snapshot with running containers to drain marked in flight,
+// and reasons of containers which can not be drained.
+func (dcs *DefaultClusterService) drainNode(nodeId UID) (*Node, drained, []string, error) {
+	drains := drained{}
+	node := dcs.findNodeById(nodeId)
+	if node == nil {
+		return nil, drains, nil, fmt.Errorf("%w for uid:%v", ErrNodeNotFound, nodeId)
+	}
+	node.Unschedulable = true
+	dcs.bumpNode(node)
+	if node.NodeState == NodeRunning {
+		if err := dcs.transitionNode(node, NodeDraining, "drained by DrainNode"); err != nil {
+			return nil, drains, nil, err
+		}
+	}
+	reasons := []string{}
+	for _, c := range dcs.containersByNode[nodeId] {
+		if c.ContainerStatus == nil || c.ContainerStatus.ContainerState != ContainerRunning {
+			continue
+		}
+		// keep container running if there is no node to move
+		if _, err := dcs.minWorkingNode(c); err != nil {
+			reasons = append(reasons, fmt.Sprintf("%v:%v", c.Id, err))
+			continue
+		}
+		if err := dcs.acquireInFlight(c); err != nil {
+			reasons = append(reasons, fmt.Sprintf("%v:%v", c.Id, err))
+			continue
+		}
+		drains.containers = append(drains.containers, c)
+		drains.snapshots = append(drains.snapshots, c.Clone())
+	}
+	return node.Clone(), drains, reasons, nil
+}
+
+// drainOnClient stop and remove container on runtime of node, called without lock. container is snapshot.
+func drainOnClient(ctx context.Context, node *Node, container *Container) error {
+	if node.Client == nil {
+		return fmt.Errorf("%w:%v", ErrNodeHasNoClient, node.Name)
+	}
+	if err := node.Client.Stop(ctx, container, DefaultStopGracePeriod); err != nil {
+		return err
+	}
+	return node.Client.Remove(ctx, container)
+}
+
+// applyDrain move container stopped and removed on runtime to other node to be re-run, caller must hold lock.
+func (dcs *DefaultClusterService) applyDrain(nodeId UID, container *Container, snapshot *Container) error {
+	// container may be removed or changed while draining without lock
+	if dcs.findContainerById(container.Id) != container || container.NodeId != nodeId || container.Hash != snapshot.Hash {
+		return fmt.Errorf("%w for uid:%v, changed while draining", ErrConflict, container.Id)
+	}
+	if err := transitionContainer(container.ContainerStatus, ContainerExited, "killed by DrainNode"); err != nil {
+		return err
+	}
+	dcs.emit(EventContainerExited, container.Id)
+	// killed to move, not by user
+	container.Killed = false
+	return dcs.rescheduleContainer(container)
+}
+
+// Cordon mark node unschedulable, containers already on the node are kept.
+func (dcs *DefaultClusterService) Cordon(nodeId UID) error {
+	return dcs.setSchedulable(nodeId, false)
+}
+
+// Uncordon mark node schedulable again, after Cordon or DrainNode. draining node returns to running.
+func (dcs *DefaultClusterService) Uncordon(nodeId UID) error {
+	return dcs.setSchedulable(nodeId, true)
+}
+
+func (dcs *DefaultClusterService) setSchedulable(nodeId UID, schedulable bool) error {
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	node := dcs.findNodeById(nodeId)
+	if node == nil {
+		return fmt.Errorf("%w for uid:%v", ErrNodeNotFound, nodeId)
+	}
+	node.Unschedulable = !schedulable
+	dcs.bumpNode(node)
+	if schedulable && node.NodeState == NodeDraining {
+		return dcs.transitionNode(node, NodeRunning, "drain cancelled by Uncordon")
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package cluster
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDefaultClusterService_DrainNode(t *testing.T) {
+	clusterService, client := newTestRestartService(t)
+	nodes, _ := clusterService.Nodes(true)
+	drained := nodes[0]
+	container, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	created, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+
+	// no other node to move
+	if err := clusterService.DrainNode(drained.Id); err == nil {
+		t.Fatal("want error for no valid node")
+	}
+	container, _ = clusterService.GetContainer(container.Id)
+	if container.NodeId != drained.Id || container.ContainerStatus.ContainerState != ContainerRunning {
+		t.Errorf("%v,%v", container.NodeName, container.ContainerStatus.ContainerState)
+	}
+
+	other := runTestNode(t, clusterService, client)
+	if err := clusterService.DrainNode(drained.Id); err != nil {
+		t.Fatal(err)
+	}
+	drained, _ = clusterService.GetNode(drained.Id)
+	if drained.Schedulable() || drained.NodeState != NodeDraining {
+		t.Errorf("schedulable:%v,%v", drained.Name, drained.NodeState)
+	}
+	container, _ = clusterService.GetContainer(container.Id)
+	if container.NodeId != other.Id || container.ContainerStatus.ContainerState != ContainerRunning {
+		t.Errorf("%v,%v", container.NodeName, container.ContainerStatus.ContainerState)
+	}
+	if len(client.stops) != 1 || len(client.removes) != 1 || len(client.runs) != 2 {
+		t.Errorf("%v,%v,%v", client.stops, client.removes, client.runs)
+	}
+	// not running container is left
+	created, _ = clusterService.GetContainer(created.Id)
+	if created.NodeId != drained.Id {
+		t.Errorf("want:%v,have:%v", drained.Name, created.NodeName)
+	}
+
+	// drained node is not selected even if it is less loaded
+	next, err := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.NodeId != other.Id {
+		t.Errorf("want:%v,have:%v", other.Name, next.NodeName)
+	}
+}
+
+func TestDefaultClusterService_Cordon(t *testing.T) {
+	clusterService, _ := newTestRestartService(t)
+	nodes, _ := clusterService.Nodes(true)
+	cordoned := nodes[0]
+	busy := prepareTestNode(t, clusterService, func(node *Node) {
+		node.Capacity = Capacity{MemoryMB: 1024}
+	})
+	running, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+
+	if err := clusterService.Cordon(cordoned.Id); err != nil {
+		t.Fatal(err)
+	}
+	// cordoned node is the least loaded, but never selected
+	for i := 0; i < 3; i++ {
+		container, err := clusterService.CreateContainerWithSpec(ContainerSpec{ResourceRequests: Capacity{MemoryMB: 256}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if container.NodeId != busy.Id {
+			t.Errorf("want:%v,have:%v", busy.Name, container.NodeName)
+		}
+	}
+	if running.NodeId != cordoned.Id {
+		t.Errorf("want:%v,have:%v", cordoned.Name, running.NodeName)
+	}
+	cordoned, _ = clusterService.GetNode(cordoned.Id)
+	if _, err := (SpreadScheduler{}).Select([]*Node{cordoned}, running); !errors.Is(err, ErrNoValidNode) {
+		t.Errorf("want:%v,have:%v", ErrNoValidNode, err)
+	}
+
+	if err := clusterService.Uncordon(cordoned.Id); err != nil {
+		t.Fatal(err)
+	}
+	container, err := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if container.NodeId != cordoned.Id {
+		t.Errorf("want:%v,have:%v", cordoned.Name, container.NodeName)
+	}
+	if err := clusterService.Cordon("unknown"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("want:%v,have:%v", ErrNodeNotFound, err)
+	}
+}
+
+func TestDefaultClusterService_DrainNode_State(t *testing.T) {
+	clusterService, _, nodes := newTestHeartbeatService(t)
+	if err := clusterService.DrainNode(nodes[0].Id); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := clusterService.NodeStatus(nodes[0].Id, ""); status.NodeState != NodeDraining {
+		t.Errorf("want:%v,have:%v", NodeDraining, status)
+	}
+	// drain is cancelled
+	if err := clusterService.Uncordon(nodes[0].Id); err != nil {
+		t.Fatal(err)
+	}
+	if node, _ := clusterService.GetNode(nodes[0].Id); node.NodeState != NodeRunning || !node.Schedulable() {
+		t.Errorf("want:%v,have:%v", NodeRunning, node.NodeState)
+	}
+
+	// drained node is killed
+	if err := clusterService.DrainNode(nodes[0].Id); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.KillNode(*nodes[0], 1000); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := clusterService.NodeStatus(nodes[0].Id, ""); status.NodeState != NodeExited {
+		t.Errorf("want:%v,have:%v", NodeExited, status)
+	}
+}
+
+func TestDefaultClusterService_DrainNode_Unlocked(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	client := newBlockingContainerClient()
+	drained := runTestNode(t, clusterService, client.FakeContainerClient)
+	container, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	// runtime blocks after container runs
+	if err := clusterService.SetNodeClient(drained.Id, client); err != nil {
+		t.Fatal(err)
+	}
+	other := runTestNode(t, clusterService, client)
+
+	done := make(chan error, 1)
+	go func() { done <- clusterService.DrainNode(drained.Id) }()
+	if called := <-client.called; called != "Stop" {
+		t.Fatalf("want:%v,have:%v", "Stop", called)
+	}
+	assertUnlocked(t, clusterService)
+	if err := clusterService.KillContainer(container, 0); !errors.Is(err, ErrConflict) {
+		t.Errorf("want:%v,have:%v", ErrConflict, err)
+	}
+	close(client.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	container, _ = clusterService.GetContainer(container.Id)
+	if container.NodeId != other.Id || container.ContainerStatus.ContainerState != ContainerRunning {
+		t.Errorf("%v,%v", container.NodeName, container.ContainerStatus.ContainerState)
+	}
+}
@@ -0,0 +1,170 @@
+package clusterec2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/ynishi/cluster"
+)
+
+// keys of ResourceInfo set by RunNode.
+const (
+	ResourceInstanceId = cluster.ResourceInstanceId
+	ResourcePrivateIP  = cluster.ResourcePrivateIP
+)
+
+// tag set on instance to find node.
+const nodeIdTag = "cluster-node-id"
+
+// EC2API is part of ec2.Client used by ResourceProvider.
+type EC2API interface {
+	RunInstances(ctx context.Context, params *ec2.RunInstancesInput, optFns ...func(*ec2.Options)) (*ec2.RunInstancesOutput, error)
+	DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error)
+	StopInstances(ctx context.Context, params *ec2.StopInstancesInput, optFns ...func(*ec2.Options)) (*ec2.StopInstancesOutput, error)
+	TerminateInstances(ctx context.Context, params *ec2.TerminateInstancesInput, optFns ...func(*ec2.Options)) (*ec2.TerminateInstancesOutput, error)
+}
+
+var _ EC2API = (*ec2.Client)(nil)
+
+// ResourceProvider run node as EC2 instance.
+// StopNode waits for the instance stopped, so KillNode terminates it by RemoveNode if it is not stopped in gracePeriod.
+type ResourceProvider struct {
+	client       EC2API
+	imageId      string
+	instanceType types.InstanceType
+	// subnet to launch instance in, default subnet if empty
+	SubnetId string
+	// security groups of instance, default group if empty
+	SecurityGroupIds []string
+	// key pair to login instance, no key if empty
+	KeyName string
+	// wait for instance running or stopped until timeout
+	Timeout time.Duration
+	// interval to describe instance while waiting
+	PollInterval time.Duration
+}
+
+var _ cluster.ResourceProvider = (*ResourceProvider)(nil)
+
+// NewResourceProvider create provider launching instance of imageId(AMI) and instanceType for each node.
+func NewResourceProvider(client EC2API, imageId string, instanceType types.InstanceType) *ResourceProvider {
+	return &ResourceProvider{
+		client:       client,
+		imageId:      imageId,
+		instanceType: instanceType,
+		Timeout:      10 * time.Minute,
+		PollInterval: 5 * time.Second,
+	}
+}
+
+// RunNode launch instance tagged by node, and wait for it running.
+func (rp *ResourceProvider) RunNode(node *cluster.Node) (*cluster.ResourceInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), rp.Timeout)
+	defer cancel()
+	input := &ec2.RunInstancesInput{
+		ImageId:      aws.String(rp.imageId),
+		InstanceType: rp.instanceType,
+		MinCount:     aws.Int32(1),
+		MaxCount:     aws.Int32(1),
+		TagSpecifications: []types.TagSpecification{{
+			ResourceType: types.ResourceTypeInstance,
+			Tags: []types.Tag{
+				{Key: aws.String("Name"), Value: aws.String(node.Name)},
+				{Key: aws.String(nodeIdTag), Value: aws.String(string(node.Id))},
+			},
+		}},
+	}
+	if rp.SubnetId != "" {
+		input.SubnetId = aws.String(rp.SubnetId)
+	}
+	if len(rp.SecurityGroupIds) > 0 {
+		input.SecurityGroupIds = rp.SecurityGroupIds
+	}
+	if rp.KeyName != "" {
+		input.KeyName = aws.String(rp.KeyName)
+	}
+	out, err := rp.client.RunInstances(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if len(out.Instances) == 0 {
+		return nil, errors.New("no instance launched")
+	}
+	instanceId := aws.ToString(out.Instances[0].InstanceId)
+	instance, err := rp.waitFor(ctx, instanceId, types.InstanceStateNameRunning)
+	if err != nil {
+		return nil, err
+	}
+	resourceInfo := cluster.ResourceInfo{}
+	resourceInfo.SetInstanceId(instanceId)
+	resourceInfo.SetPrivateIP(aws.ToString(instance.PrivateIpAddress))
+	return &resourceInfo, nil
+}
+
+// StopNode stop instance of node, and wait for it stopped.
+func (rp *ResourceProvider) StopNode(node *cluster.Node) error {
+	instanceId, err := rp.instanceId(node)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), rp.Timeout)
+	defer cancel()
+	if _, err := rp.client.StopInstances(ctx, &ec2.StopInstancesInput{InstanceIds: []string{instanceId}}); err != nil {
+		return err
+	}
+	_, err = rp.waitFor(ctx, instanceId, types.InstanceStateNameStopped)
+	return err
+}
+
+// RemoveNode terminate instance of node, not waiting for it terminated.
+func (rp *ResourceProvider) RemoveNode(node *cluster.Node) error {
+	instanceId, err := rp.instanceId(node)
+	if err != nil {
+		return err
+	}
+	_, err = rp.client.TerminateInstances(context.Background(), &ec2.TerminateInstancesInput{InstanceIds: []string{instanceId}})
+	return err
+}
+
+func (rp *ResourceProvider) instanceId(node *cluster.Node) (string, error) {
+	instanceId := node.ResourceInfo.InstanceId()
+	if instanceId == "" {
+		return "", fmt.Errorf("no instance id of node:%v", node.Name)
+	}
+	return instanceId, nil
+}
+
+// waitFor describe instance until it reaches state, terminated instance is taken as stopped.
+func (rp *ResourceProvider) waitFor(ctx context.Context, instanceId string, state types.InstanceStateName) (*types.Instance, error) {
+	ticker := time.NewTicker(rp.PollInterval)
+	defer ticker.Stop()
+	for {
+		out, err := rp.client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{InstanceIds: []string{instanceId}})
+		if err != nil {
+			return nil, err
+		}
+		if len(out.Reservations) > 0 && len(out.Reservations[0].Instances) > 0 && out.Reservations[0].Instances[0].State != nil {
+			instance := out.Reservations[0].Instances[0]
+			current := instance.State.Name
+			if current == state {
+				return &instance, nil
+			}
+			switch {
+			case current == types.InstanceStateNameTerminated && state == types.InstanceStateNameStopped:
+				return &instance, nil
+			case current == types.InstanceStateNameTerminated || current == types.InstanceStateNameShuttingDown:
+				return nil, fmt.Errorf("instance:%v %v", instanceId, current)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package clusterec2
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/ynishi/cluster"
+)
+
+// mockEC2 moves instance to next state after describe, stop is ignored if noStop.
+type mockEC2 struct {
+	mu         sync.Mutex
+	state      types.InstanceStateName
+	noStop     bool
+	run        *ec2.RunInstancesInput
+	terminated []string
+}
+
+func (m *mockEC2) RunInstances(ctx context.Context, params *ec2.RunInstancesInput, optFns ...func(*ec2.Options)) (*ec2.RunInstancesOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.run = params
+	m.state = types.InstanceStateNamePending
+	return &ec2.RunInstancesOutput{Instances: []types.Instance{{InstanceId: aws.String("i-1")}}}, nil
+}
+
+func (m *mockEC2) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	instance := types.Instance{
+		InstanceId:       aws.String("i-1"),
+		PrivateIpAddress: aws.String("10.0.0.1"),
+		State:            &types.InstanceState{Name: m.state},
+	}
+	switch m.state {
+	case types.InstanceStateNamePending:
+		m.state = types.InstanceStateNameRunning
+	case types.InstanceStateNameStopping:
+		m.state = types.InstanceStateNameStopped
+	}
+	return &ec2.DescribeInstancesOutput{Reservations: []types.Reservation{{Instances: []types.Instance{instance}}}}, nil
+}
+
+func (m *mockEC2) StopInstances(ctx context.Context, params *ec2.StopInstancesInput, optFns ...func(*ec2.Options)) (*ec2.StopInstancesOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.noStop {
+		m.state = types.InstanceStateNameStopping
+	}
+	return &ec2.StopInstancesOutput{}, nil
+}
+
+func (m *mockEC2) TerminateInstances(ctx context.Context, params *ec2.TerminateInstancesInput, optFns ...func(*ec2.Options)) (*ec2.TerminateInstancesOutput, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.terminated = append(m.terminated, params.InstanceIds...)
+	m.state = types.InstanceStateNameTerminated
+	return &ec2.TerminateInstancesOutput{}, nil
+}
+
+func (m *mockEC2) terminatedIds() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string{}, m.terminated...)
+}
+
+func TestResourceProvider(t *testing.T) {
+	tests := []struct {
+		name           string
+		noStop         bool
+		wantTerminated []string
+	}{
+		{"stopped", false, []string{}},
+		{"terminatedAfterGracePeriod", true, []string{"i-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &mockEC2{noStop: tt.noStop}
+			provider := NewResourceProvider(client, "ami-1", types.InstanceTypeT3Micro)
+			provider.PollInterval = 10 * time.Millisecond
+			provider.Timeout = time.Second
+			clusterService := cluster.NewDefaultClusterService("0.0.1", &cluster.Image{Name: "testImage"})
+			node, err := clusterService.CreateNamedNode("node1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := clusterService.PrepareNode(node.Id, func(node *cluster.Node) error {
+				node.ResourceProvider = provider
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := clusterService.RunNode(node); err != nil {
+				t.Fatal(err)
+			}
+			expected := cluster.ResourceInfo{ResourceInstanceId: "i-1", ResourcePrivateIP: "10.0.0.1"}
+			if !reflect.DeepEqual(expected, node.ResourceInfo) {
+				t.Errorf("want:%v,have:%v", expected, node.ResourceInfo)
+			}
+			if aws.ToString(client.run.ImageId) != "ami-1" || client.run.InstanceType != types.InstanceTypeT3Micro {
+				t.Errorf("want:%v,have:%v", "ami-1", client.run)
+			}
+			if err := clusterService.KillNode(*node, 200); err != nil {
+				t.Fatal(err)
+			}
+			if terminated := client.terminatedIds(); !reflect.DeepEqual(tt.wantTerminated, terminated) {
+				t.Errorf("want:%v,have:%v", tt.wantTerminated, terminated)
+			}
+		})
+	}
+}
+
+func TestResourceProvider_NoInstanceId(t *testing.T) {
+	provider := NewResourceProvider(&mockEC2{}, "ami-1", types.InstanceTypeT3Micro)
+	node := &cluster.Node{Id: "id1", Name: "node1"}
+	if err := provider.StopNode(node); err == nil {
+		t.Error("want error for node without instance id")
+	}
+	if err := provider.RemoveNode(node); err == nil {
+		t.Error("want error for node without instance id")
+	}
+}
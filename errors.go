@@ -0,0 +1,41 @@
+package cluster
+
+import "errors"
+
+// errors returned by cluster, wrapped with detail. test them by errors.Is.
+var (
+	ErrNodeNotFound            = errors.New("node not found")
+	ErrContainerNotFound       = errors.New("container not found")
+	ErrContainerStatusNotFound = errors.New("container status not found")
+	ErrNodeAlreadyExists       = errors.New("node already exists")
+	ErrNoValidNode             = errors.New("no valid node")
+	ErrInsufficientCapacity    = errors.New("insufficient capacity")
+	ErrAlreadyRunning          = errors.New("already running")
+	ErrNotRunning              = errors.New("not running")
+	ErrAlreadyExited           = errors.New("already exited")
+	ErrStillRunning            = errors.New("still running")
+	ErrNodeHasContainers       = errors.New("node has containers")
+	ErrNoResourceProvider      = errors.New("node has no resource provider")
+	ErrIllegalTransition       = errors.New("illegal transition")
+	ErrUnknownRuntime          = errors.New("unknown container runtime")
+	ErrRequestExceedsCapacity  = errors.New("resource request exceeds capacity of every node")
+	ErrInvalidResources        = errors.New("resource limits under requests")
+	ErrInvalidVolume           = errors.New("invalid volume")
+	ErrImageNotFound           = errors.New("image not found")
+	ErrPullImage               = errors.New("failed to pull image")
+	ErrImageInUse              = errors.New("image is used by alive container")
+	ErrUnexpectedState         = errors.New("reached unexpected state")
+	ErrDependencyCycle         = errors.New("dependency cycle")
+	ErrUnsatisfiedConstraints  = errors.New("no node satisfies constraints")
+	ErrNodeLimitReached        = errors.New("node limit reached")
+	ErrConflict                = errors.New("resource version conflict")
+	ErrNodeUnreachable         = errors.New("node unreachable")
+	ErrNodeGroupNotFound       = errors.New("node group not found")
+	ErrNodeGroupAlreadyExists  = errors.New("node group already exists")
+	ErrLivenessFailed          = errors.New("liveness probe failed")
+	ErrReplicaSetNotFound      = errors.New("replica set not found")
+	ErrSecretNotFound          = errors.New("secret not found")
+	ErrNodeHasNoClient         = errors.New("node has no client")
+	ErrImmutableField          = errors.New("immutable field")
+	ErrContainerPending        = errors.New("container is pending")
+)
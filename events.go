@@ -0,0 +1,78 @@
+package cluster
+
+import (
+	"sync"
+	"time"
+)
+
+// EventType is kind of state change.
+type EventType string
+
+const (
+	EventContainerCreated   EventType = "ContainerCreated"
+	EventContainerScheduled EventType = "ContainerScheduled"
+	EventContainerStarted   EventType = "ContainerStarted"
+	EventContainerExited    EventType = "ContainerExited"
+	EventContainerPaused    EventType = "ContainerPaused"
+	EventContainerResumed   EventType = "ContainerResumed"
+	EventNodeJoined         EventType = "NodeJoined"
+	EventNodeLeft           EventType = "NodeLeft"
+)
+
+// Event notifies state change of container or node.
+type Event struct {
+	// kind of state change
+	Type EventType
+	// uid of container or node
+	Id UID
+	// when it happened
+	Time time.Time
+}
+
+// buffer size of each watcher, events are dropped for a watcher whose buffer is full.
+const eventBufferSize = 64
+
+type eventWatchers struct {
+	mu       sync.Mutex
+	nextId   int
+	watchers map[int]chan Event
+}
+
+// Watch returns channel receiving events and func to unsubscribe and close the channel.
+// Events are dropped if the receiver does not keep up.
+func (dcs *DefaultClusterService) Watch() (<-chan Event, func()) {
+	ew := &dcs.watchers
+	ew.mu.Lock()
+	defer ew.mu.Unlock()
+	if ew.watchers == nil {
+		ew.watchers = make(map[int]chan Event)
+	}
+	id := ew.nextId
+	ew.nextId++
+	ch := make(chan Event, eventBufferSize)
+	ew.watchers[id] = ch
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			ew.mu.Lock()
+			defer ew.mu.Unlock()
+			delete(ew.watchers, id)
+			close(ch)
+		})
+	}
+}
+
+func (dcs *DefaultClusterService) emit(eventType EventType, id UID) {
+	// events are emitted on every change of state
+	dcs.bump(id)
+	event := Event{Type: eventType, Id: id, Time: time.Now()}
+	ew := &dcs.watchers
+	ew.mu.Lock()
+	defer ew.mu.Unlock()
+	for _, ch := range ew.watchers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
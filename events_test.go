@@ -0,0 +1,53 @@
+package cluster
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDefaultClusterService_Watch(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	events, unsubscribe := clusterService.Watch()
+
+	node := prepareTestNode(t, clusterService, func(node *Node) {
+		node.ResourceProvider = &mockResourceProvider{}
+		node.Client = &mockContainerClient{hash: "hash1"}
+	})
+	container, err := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.KillContainer(container, DefaultStopGracePeriod); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.KillNode(*node, 100); err != nil {
+		t.Fatal(err)
+	}
+	unsubscribe()
+	unsubscribe()
+
+	expected := []Event{
+		Event{Type: EventNodeJoined, Id: node.Id},
+		Event{Type: EventContainerCreated, Id: container.Id},
+		Event{Type: EventContainerStarted, Id: container.Id},
+		Event{Type: EventContainerExited, Id: container.Id},
+		Event{Type: EventNodeLeft, Id: node.Id},
+	}
+	received := []Event{}
+	for event := range events {
+		if event.Time.IsZero() {
+			t.Errorf("%v", event)
+		}
+		event.Time = expected[0].Time
+		received = append(received, event)
+	}
+	if !reflect.DeepEqual(expected, received) {
+		t.Errorf("want:%v,have:%v", expected, received)
+	}
+
+	// no panic after unsubscribed
+	prepareTestNode(t, clusterService, func(node *Node) { node.ResourceProvider = &mockResourceProvider{} })
+}
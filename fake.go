@@ -0,0 +1,499 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FaultInjector makes container client fail deterministically, for testing restart, rescheduling and health checks.
+// It is implemented by FakeContainerClient and InMemoryContainerClient,
+// node is made unreachable by FakeResourceProvider.SetUnreachable.
+type FaultInjector interface {
+	// ExitContainer make running container exit with exitCode after delay, immediately if 0.
+	ExitContainer(container *Container, exitCode int, after time.Duration)
+	// InjectError make operation, name of method like Run, return err until it is cleared by nil.
+	InjectError(operation string, err error)
+}
+
+var _ FaultInjector = (*FakeContainerClient)(nil)
+var _ FaultInjector = (*InMemoryContainerClient)(nil)
+
+// FakeResourceProvider is ResourceProvider without real infrastructure, for testing.
+// It keeps running flag of each node and counts calls.
+type FakeResourceProvider struct {
+	// returned by RunNode, copied for each node
+	ResourceInfo ResourceInfo
+	// returned by all methods if not nil, flags are not changed
+	Err error
+
+	mu          sync.Mutex
+	running     map[UID]bool
+	unreachable map[UID]bool
+	removed     map[UID]bool
+	runCalls    int
+	stopCalls   int
+	removeCalls int
+}
+
+var _ ResourceProvider = (*FakeResourceProvider)(nil)
+var _ NodeProber = (*FakeResourceProvider)(nil)
+
+// NewFakeResourceProvider create provider returning resourceInfo by RunNode.
+func NewFakeResourceProvider(resourceInfo ResourceInfo) *FakeResourceProvider {
+	return &FakeResourceProvider{ResourceInfo: resourceInfo}
+}
+
+func (frp *FakeResourceProvider) RunNode(node *Node) (*ResourceInfo, error) {
+	frp.mu.Lock()
+	defer frp.mu.Unlock()
+	frp.runCalls++
+	if frp.Err != nil {
+		return nil, frp.Err
+	}
+	if frp.running == nil {
+		frp.running = make(map[UID]bool)
+	}
+	frp.running[node.Id] = true
+	resourceInfo := ResourceInfo{}
+	for k, v := range frp.ResourceInfo {
+		resourceInfo[k] = v
+	}
+	return &resourceInfo, nil
+}
+
+func (frp *FakeResourceProvider) StopNode(node *Node) error {
+	frp.mu.Lock()
+	defer frp.mu.Unlock()
+	frp.stopCalls++
+	if frp.Err != nil {
+		return frp.Err
+	}
+	delete(frp.running, node.Id)
+	return nil
+}
+
+func (frp *FakeResourceProvider) RemoveNode(node *Node) error {
+	frp.mu.Lock()
+	defer frp.mu.Unlock()
+	frp.removeCalls++
+	if frp.Err != nil {
+		return frp.Err
+	}
+	if frp.removed == nil {
+		frp.removed = make(map[UID]bool)
+	}
+	delete(frp.running, node.Id)
+	frp.removed[node.Id] = true
+	return nil
+}
+
+// ProbeNode returns error if node is not running, as if the machine disappeared, or unreachable.
+func (frp *FakeResourceProvider) ProbeNode(node *Node) error {
+	frp.mu.Lock()
+	defer frp.mu.Unlock()
+	if frp.Err != nil {
+		return frp.Err
+	}
+	if !frp.running[node.Id] {
+		return fmt.Errorf("%w for uid:%v", ErrNotRunning, node.Id)
+	}
+	if frp.unreachable[node.Id] {
+		return fmt.Errorf("%w for uid:%v", ErrNodeUnreachable, node.Id)
+	}
+	return nil
+}
+
+// SetUnreachable make ProbeNode of running node fail until it is set reachable, as if network is partitioned.
+func (frp *FakeResourceProvider) SetUnreachable(node *Node, unreachable bool) {
+	frp.mu.Lock()
+	defer frp.mu.Unlock()
+	if frp.unreachable == nil {
+		frp.unreachable = make(map[UID]bool)
+	}
+	frp.unreachable[node.Id] = unreachable
+}
+
+// Running returns true if node is run and not stopped or removed.
+func (frp *FakeResourceProvider) Running(node *Node) bool {
+	frp.mu.Lock()
+	defer frp.mu.Unlock()
+	return frp.running[node.Id]
+}
+
+// Removed returns true if node is removed.
+func (frp *FakeResourceProvider) Removed(node *Node) bool {
+	frp.mu.Lock()
+	defer frp.mu.Unlock()
+	return frp.removed[node.Id]
+}
+
+// Calls returns number of calls of RunNode, StopNode and RemoveNode.
+func (frp *FakeResourceProvider) Calls() (run, stop, remove int) {
+	frp.mu.Lock()
+	defer frp.mu.Unlock()
+	return frp.runCalls, frp.stopCalls, frp.removeCalls
+}
+
+// FakeRuntimeVersion is version reported by FakeContainerClient.
+const FakeRuntimeVersion Version = "fake"
+
+// FakeContainerClient is ContainerClient keeping containers in memory, for testing.
+// It records calls and Inspect returns state programmed by SetState or SetExited.
+type FakeContainerClient struct {
+	// returned by Run
+	Hash string
+	// returned by all methods if not nil, unless error is injected to the method
+	Err error
+	// returned by PullImage if not nil
+	PullErr error
+
+	mu     sync.Mutex
+	states map[UID]ContainerState
+	// local ids of pulled images by full name
+	images map[string]string
+	pulls  []*Image
+	// auth used by each pull, zero if not registered
+	pullAuths []RegistryAuth
+	auths     registryAuths
+	// exit codes of exited containers, 0 if not set
+	exitCodes map[UID]int
+	// stats returned by Stats, zero if not set
+	stats map[UID]ContainerStats
+	// errors injected by InjectError by method name
+	errors   map[string]error
+	runs     Containers
+	stops    Containers
+	inspects Containers
+	removes  Containers
+}
+
+var _ ContainerClient = (*FakeContainerClient)(nil)
+
+// NewFakeContainerClient create client returning hash by Run.
+func NewFakeContainerClient(hash string) *FakeContainerClient {
+	return &FakeContainerClient{Hash: hash}
+}
+
+// SetState set state returned by Inspect for container.
+func (fcc *FakeContainerClient) SetState(uid UID, state ContainerState) {
+	fcc.mu.Lock()
+	defer fcc.mu.Unlock()
+	if fcc.states == nil {
+		fcc.states = make(map[UID]ContainerState)
+	}
+	fcc.states[uid] = state
+}
+
+// SetExited set container exited with exitCode, returned by Inspect.
+func (fcc *FakeContainerClient) SetExited(uid UID, exitCode int) {
+	fcc.SetState(uid, ContainerExited)
+	fcc.mu.Lock()
+	defer fcc.mu.Unlock()
+	if fcc.exitCodes == nil {
+		fcc.exitCodes = make(map[UID]int)
+	}
+	fcc.exitCodes[uid] = exitCode
+}
+
+// ExitContainer make container exited with exitCode by SetExited after delay.
+func (fcc *FakeContainerClient) ExitContainer(container *Container, exitCode int, after time.Duration) {
+	if after == 0 {
+		fcc.SetExited(container.Id, exitCode)
+		return
+	}
+	uid := container.Id
+	time.AfterFunc(after, func() { fcc.SetExited(uid, exitCode) })
+}
+
+// InjectError make operation return err instead of Err until it is cleared by nil.
+func (fcc *FakeContainerClient) InjectError(operation string, err error) {
+	fcc.mu.Lock()
+	defer fcc.mu.Unlock()
+	if err == nil {
+		delete(fcc.errors, operation)
+		return
+	}
+	if fcc.errors == nil {
+		fcc.errors = make(map[string]error)
+	}
+	fcc.errors[operation] = err
+}
+
+func (fcc *FakeContainerClient) Run(ctx context.Context, container *Container) (string, error) {
+	fcc.mu.Lock()
+	defer fcc.mu.Unlock()
+	fcc.runs = append(fcc.runs, container)
+	if err := fcc.injected("Run"); err != nil {
+		return "", err
+	}
+	if fcc.states == nil {
+		fcc.states = make(map[UID]ContainerState)
+	}
+	fcc.states[container.Id] = ContainerRunning
+	delete(fcc.exitCodes, container.Id)
+	return fcc.Hash, nil
+}
+
+func (fcc *FakeContainerClient) Stop(ctx context.Context, container *Container, gracePeriod time.Duration) error {
+	fcc.mu.Lock()
+	defer fcc.mu.Unlock()
+	fcc.stops = append(fcc.stops, container)
+	if err := fcc.injected("Stop"); err != nil {
+		return err
+	}
+	if _, ok := fcc.states[container.Id]; ok {
+		fcc.states[container.Id] = ContainerExited
+	}
+	return nil
+}
+
+// SetImage make image pulled on node with its local id, it is also set by PullImage.
+func (fcc *FakeContainerClient) SetImage(image *Image, imageId string) {
+	fcc.mu.Lock()
+	defer fcc.mu.Unlock()
+	if fcc.images == nil {
+		fcc.images = make(map[string]string)
+	}
+	fcc.images[image.FullName] = imageId
+}
+
+// ImageId returns id set by SetImage or PullImage, ErrImageNotFound if none of them is called.
+func (fcc *FakeContainerClient) ImageId(ctx context.Context, image *Image) (string, error) {
+	fcc.mu.Lock()
+	defer fcc.mu.Unlock()
+	if err := fcc.injected("ImageId"); err != nil {
+		return "", err
+	}
+	imageId, ok := fcc.images[image.FullName]
+	if !ok {
+		return "", fmt.Errorf("%w:%v", ErrImageNotFound, image.FullName)
+	}
+	return imageId, nil
+}
+
+// SetRegistryAuth register auth, recorded by PullImage for registry of image.
+func (fcc *FakeContainerClient) SetRegistryAuth(registry string, auth RegistryAuth) {
+	fcc.auths.set(registry, auth)
+}
+
+// PullImage make image pulled with id formatted sha256:FullName, failing with PullErr if set.
+func (fcc *FakeContainerClient) PullImage(ctx context.Context, image *Image) error {
+	fcc.mu.Lock()
+	defer fcc.mu.Unlock()
+	fcc.pulls = append(fcc.pulls, image)
+	auth, _ := fcc.auths.forImage(image)
+	fcc.pullAuths = append(fcc.pullAuths, auth)
+	if err := fcc.injected("PullImage"); err != nil {
+		return err
+	}
+	if fcc.PullErr != nil {
+		return fcc.PullErr
+	}
+	if fcc.images == nil {
+		fcc.images = make(map[string]string)
+	}
+	fcc.images[image.FullName] = "sha256:" + image.FullName
+	return nil
+}
+
+// PullAuths returns auths used by PullImage in order of Pulls.
+func (fcc *FakeContainerClient) PullAuths() []RegistryAuth {
+	fcc.mu.Lock()
+	defer fcc.mu.Unlock()
+	return append([]RegistryAuth{}, fcc.pullAuths...)
+}
+
+// ListImages returns images set by SetImage or PullImage, sorted by full name.
+func (fcc *FakeContainerClient) ListImages(ctx context.Context) ([]*Image, error) {
+	fcc.mu.Lock()
+	defer fcc.mu.Unlock()
+	if err := fcc.injected("ListImages"); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(fcc.images))
+	for name := range fcc.images {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return parseImages(names), nil
+}
+
+func (fcc *FakeContainerClient) RemoveImage(ctx context.Context, image *Image) error {
+	fcc.mu.Lock()
+	defer fcc.mu.Unlock()
+	if err := fcc.injected("RemoveImage"); err != nil {
+		return err
+	}
+	if _, ok := fcc.images[image.FullName]; !ok {
+		return fmt.Errorf("%w:%v", ErrImageNotFound, image.FullName)
+	}
+	delete(fcc.images, image.FullName)
+	return nil
+}
+
+// Pulls returns images passed to PullImage.
+func (fcc *FakeContainerClient) Pulls() []*Image {
+	fcc.mu.Lock()
+	defer fcc.mu.Unlock()
+	return append([]*Image{}, fcc.pulls...)
+}
+
+func (fcc *FakeContainerClient) Pause(ctx context.Context, container *Container) error {
+	return fcc.setStateIfKnown("Pause", container, ContainerPaused)
+}
+
+func (fcc *FakeContainerClient) Unpause(ctx context.Context, container *Container) error {
+	return fcc.setStateIfKnown("Unpause", container, ContainerRunning)
+}
+
+func (fcc *FakeContainerClient) setStateIfKnown(operation string, container *Container, state ContainerState) error {
+	fcc.mu.Lock()
+	defer fcc.mu.Unlock()
+	if err := fcc.injected(operation); err != nil {
+		return err
+	}
+	if _, ok := fcc.states[container.Id]; ok {
+		fcc.states[container.Id] = state
+	}
+	return nil
+}
+
+// Inspect returns state set by SetState, Run, Stop, Pause or Unpause, current state of container if none of them is called.
+func (fcc *FakeContainerClient) Inspect(ctx context.Context, container *Container) (*ContainerStatus, error) {
+	fcc.mu.Lock()
+	defer fcc.mu.Unlock()
+	fcc.inspects = append(fcc.inspects, container)
+	if err := fcc.injected("Inspect"); err != nil {
+		return nil, err
+	}
+	status := NewContainerStatus(container.Id, container.Name, container.NodeName)
+	status.Reason = "inspected by FakeContainerClient"
+	if state, ok := fcc.states[container.Id]; ok {
+		status.ContainerState = state
+	} else if container.ContainerStatus != nil {
+		status.ContainerState = container.ContainerStatus.ContainerState
+	}
+	if status.ContainerState == ContainerExited {
+		status.ExitCode = fcc.exitCodes[container.Id]
+		if status.ExitCode != 0 {
+			status.Error = fmt.Errorf("exited with code:%d", status.ExitCode)
+		}
+	}
+	return status, nil
+}
+
+func (fcc *FakeContainerClient) Remove(ctx context.Context, container *Container) error {
+	fcc.mu.Lock()
+	defer fcc.mu.Unlock()
+	fcc.removes = append(fcc.removes, container)
+	if err := fcc.injected("Remove"); err != nil {
+		return err
+	}
+	delete(fcc.states, container.Id)
+	return nil
+}
+
+// Logs returns empty log.
+func (fcc *FakeContainerClient) Logs(ctx context.Context, container *Container, options LogsOptions) (io.ReadCloser, error) {
+	if err := fcc.err("Logs"); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(strings.NewReader("")), nil
+}
+
+// Exec returns command joined by space as stdout.
+func (fcc *FakeContainerClient) Exec(ctx context.Context, container *Container, cmd []string) (string, string, int, error) {
+	if err := fcc.err("Exec"); err != nil {
+		return "", "", 0, err
+	}
+	return strings.Join(cmd, " "), "", 0, nil
+}
+
+// SetStats set stats returned by Stats for container.
+func (fcc *FakeContainerClient) SetStats(uid UID, stats ContainerStats) {
+	fcc.mu.Lock()
+	defer fcc.mu.Unlock()
+	if fcc.stats == nil {
+		fcc.stats = make(map[UID]ContainerStats)
+	}
+	fcc.stats[uid] = stats
+}
+
+// Stats returns stats programmed by SetStats.
+func (fcc *FakeContainerClient) Stats(ctx context.Context, container *Container) (*ContainerStats, error) {
+	fcc.mu.Lock()
+	defer fcc.mu.Unlock()
+	if err := fcc.injected("Stats"); err != nil {
+		return nil, err
+	}
+	stats := fcc.stats[container.Id]
+	return &stats, nil
+}
+
+// Update succeeds unless error is injected, limits are not recorded.
+func (fcc *FakeContainerClient) Update(ctx context.Context, container *Container, limits Capacity) error {
+	return fcc.err("Update")
+}
+
+// RuntimeVersion returns FakeRuntimeVersion unless error is injected.
+func (fcc *FakeContainerClient) RuntimeVersion(ctx context.Context) (Version, error) {
+	if err := fcc.err("RuntimeVersion"); err != nil {
+		return "", err
+	}
+	return FakeRuntimeVersion, nil
+}
+
+// Attach echo stdin into stdout unless error is injected.
+func (fcc *FakeContainerClient) Attach(ctx context.Context, container *Container, stdin io.Reader, stdout, stderr io.Writer) error {
+	if err := fcc.err("Attach"); err != nil {
+		return err
+	}
+	return echo(ctx, stdin, stdout)
+}
+
+func (fcc *FakeContainerClient) err(operation string) error {
+	fcc.mu.Lock()
+	defer fcc.mu.Unlock()
+	return fcc.injected(operation)
+}
+
+// injected returns error injected to operation or Err, caller must hold mu.
+func (fcc *FakeContainerClient) injected(operation string) error {
+	if err, ok := fcc.errors[operation]; ok {
+		return err
+	}
+	return fcc.Err
+}
+
+// Runs returns containers passed to Run in order.
+func (fcc *FakeContainerClient) Runs() Containers {
+	fcc.mu.Lock()
+	defer fcc.mu.Unlock()
+	return append(Containers{}, fcc.runs...)
+}
+
+// Stops returns containers passed to Stop in order.
+func (fcc *FakeContainerClient) Stops() Containers {
+	fcc.mu.Lock()
+	defer fcc.mu.Unlock()
+	return append(Containers{}, fcc.stops...)
+}
+
+// Inspects returns containers passed to Inspect in order.
+func (fcc *FakeContainerClient) Inspects() Containers {
+	fcc.mu.Lock()
+	defer fcc.mu.Unlock()
+	return append(Containers{}, fcc.inspects...)
+}
+
+// Removes returns containers passed to Remove in order.
+func (fcc *FakeContainerClient) Removes() Containers {
+	fcc.mu.Lock()
+	defer fcc.mu.Unlock()
+	return append(Containers{}, fcc.removes...)
+}
@@ -0,0 +1,164 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFakeResourceProvider(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	provider := NewFakeResourceProvider(ResourceInfo{"host": "host1"})
+	node := prepareTestNode(t, clusterService, func(node *Node) { node.ResourceProvider = provider })
+	if !provider.Running(node) {
+		t.Errorf("want:%v,have:%v", true, provider.Running(node))
+	}
+	if !reflect.DeepEqual(node.ResourceInfo, ResourceInfo{"host": "host1"}) {
+		t.Errorf("want:%v,have:%v", ResourceInfo{"host": "host1"}, node.ResourceInfo)
+	}
+	if err := clusterService.KillNode(*node, 1000); err != nil {
+		t.Fatal(err)
+	}
+	if provider.Running(node) {
+		t.Errorf("want:%v,have:%v", false, provider.Running(node))
+	}
+	if err := clusterService.RemoveNode(node.Id); err != nil {
+		t.Fatal(err)
+	}
+	if !provider.Removed(node) {
+		t.Errorf("want:%v,have:%v", true, provider.Removed(node))
+	}
+	if run, stop, remove := provider.Calls(); run != 1 || stop != 1 || remove != 1 {
+		t.Errorf("want:%v,have:%v", []int{1, 1, 1}, []int{run, stop, remove})
+	}
+
+	failed, _ := clusterService.CreateNode()
+	failed, err := clusterService.PrepareNode(failed.Id, func(node *Node) error {
+		node.ResourceProvider = provider
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider.Err = errors.New("run failed")
+	if err := clusterService.RunNode(failed); err == nil {
+		t.Fatal("want error")
+	}
+	if provider.Running(failed) || failed.NodeState == NodeRunning {
+		t.Errorf("want:%v,have:%v", NodeCreated, failed.NodeState)
+	}
+	if run, _, _ := provider.Calls(); run != 2 {
+		t.Errorf("want:%v,have:%v", 2, run)
+	}
+}
+
+func TestFakeContainerClient(t *testing.T) {
+	client := NewFakeContainerClient("hash1")
+	node := &Node{Id: "node1", Name: "nodename1", Client: client}
+	container := NewContainer("id1", "name1", "", "node1", "nodename1", testImage, "", nil)
+	if err := node.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	if runs := client.Runs(); len(runs) != 1 || runs[0] != container {
+		t.Errorf("want:%v,have:%v", Containers{container}, runs)
+	}
+	if container.Hash != "hash1" {
+		t.Errorf("want:%v,have:%v", "hash1", container.Hash)
+	}
+
+	tests := []struct {
+		name string
+		set  ContainerState
+		stop bool
+		want ContainerState
+	}{
+		{"run", "", false, ContainerRunning},
+		{"programmed", ContainerExited, false, ContainerExited},
+		{"stop", ContainerRunning, true, ContainerExited},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.set != "" {
+				client.SetState(container.Id, tt.set)
+			}
+			if tt.stop {
+				if err := node.KillContainer(container, DefaultStopGracePeriod); err != nil {
+					t.Fatal(err)
+				}
+			}
+			status, err := client.Inspect(context.Background(), container)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status.ContainerState != tt.want {
+				t.Errorf("want:%v,have:%v", tt.want, status.ContainerState)
+			}
+		})
+	}
+	if len(client.Stops()) != 1 || len(client.Inspects()) != 3 {
+		t.Errorf("want:%v,have:%v", []int{1, 3}, []int{len(client.Stops()), len(client.Inspects())})
+	}
+
+	// image is pulled by the first run
+	if len(client.Pulls()) != 1 || container.ImageId != "sha256:"+testImage.FullName {
+		t.Errorf("want:%v,have:%v,%v", 1, client.Pulls(), container.ImageId)
+	}
+
+	// image is checked before run
+	client.Err = errors.New("run failed")
+	failed := NewContainer("id2", "name2", "", "node1", "nodename1", testImage, "", nil)
+	if err := node.RunContainer(failed); err != client.Err {
+		t.Fatalf("want:%v,have:%v", client.Err, err)
+	}
+	if len(client.Runs()) != 1 {
+		t.Errorf("want:%v,have:%v", 1, len(client.Runs()))
+	}
+}
+
+func TestFakeResourceProvider_SetUnreachable(t *testing.T) {
+	clusterService, provider, nodes := newTestHeartbeatService(t)
+	provider.SetUnreachable(nodes[0], true)
+	if err := provider.ProbeNode(nodes[0]); !errors.Is(err, ErrNodeUnreachable) {
+		t.Errorf("want:%v,have:%v", ErrNodeUnreachable, err)
+	}
+	before := clusterService.findNodeStatusById(nodes[0].Id).LastHeartbeat
+	if err := clusterService.FlushNodes(); err != nil {
+		t.Fatal(err)
+	}
+	if have := clusterService.findNodeStatusById(nodes[0].Id).LastHeartbeat; !have.Equal(before) {
+		t.Errorf("want:%v,have:%v", before, have)
+	}
+	provider.SetUnreachable(nodes[0], false)
+	if err := provider.ProbeNode(nodes[0]); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFakeContainerClient_InjectError(t *testing.T) {
+	client := NewFakeContainerClient("hash1")
+	container := NewContainer("id1", "name1", "", "node1", "nodename1", testImage, "", nil)
+	failure := errors.New("failure")
+	client.Err = errors.New("default")
+	client.InjectError("Run", failure)
+	if _, err := client.Run(context.Background(), container); !errors.Is(err, failure) {
+		t.Errorf("want:%v,have:%v", failure, err)
+	}
+	if err := client.Stop(context.Background(), container, 0); !errors.Is(err, client.Err) {
+		t.Errorf("want:%v,have:%v", client.Err, err)
+	}
+	client.Err = nil
+	client.InjectError("Run", nil)
+	if _, err := client.Run(context.Background(), container); err != nil {
+		t.Fatal(err)
+	}
+
+	client.ExitContainer(container, 2, 0)
+	status, err := client.Inspect(context.Background(), container)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.ContainerState != ContainerExited || status.ExitCode != 2 {
+		t.Errorf("want:%v,have:%v", ContainerExited, status)
+	}
+}
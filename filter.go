@@ -0,0 +1,44 @@
+package cluster
+
+// CountContainers returns number of containers in state by cached statuses, updated by FlushContainers.
+// containers without status are counted as unknown.
+func (dcs *DefaultClusterService) CountContainers(state ContainerState) int {
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
+	count := 0
+	for _, c := range dcs.containers {
+		if containerStateOf(c) == state {
+			count++
+		}
+	}
+	return count
+}
+
+// FilterContainers returns copies of containers for which predicate returns true.
+// predicate is called with copy of each container under lock, so it must not call the service.
+func (dcs *DefaultClusterService) FilterContainers(predicate func(*Container) bool) Containers {
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
+	res := Containers{}
+	for _, c := range dcs.containers {
+		if clone := c.Clone(); predicate(clone) {
+			res = append(res, clone)
+		}
+	}
+	return res
+}
+
+func containerStateOf(c *Container) ContainerState {
+	if c.ContainerStatus == nil || c.ContainerStatus.ContainerState == "" {
+		return ContainerUnknown
+	}
+	return c.ContainerStatus.ContainerState
+}
+
+// RunningContainers returns containers in running state by cached statuses,
+// unlike Containers(false) which also returns created and paused ones.
+func (dcs *DefaultClusterService) RunningContainers() Containers {
+	return dcs.FilterContainers(func(c *Container) bool {
+		return containerStateOf(c) == ContainerRunning
+	})
+}
@@ -0,0 +1,82 @@
+package cluster
+
+import "testing"
+
+func TestDefaultClusterService_CountContainers(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	client := NewFakeContainerClient("hash1")
+	runTestNode(t, clusterService, client)
+	containers := Containers{}
+	for i := 0; i < 3; i++ {
+		container, err := clusterService.CreateContainerWithSpec(ContainerSpec{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		containers = append(containers, container)
+	}
+	for _, c := range (Containers{containers[0], containers[2]}) {
+		if err := clusterService.RunContainer(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// runtime lost the container
+	client.SetState(containers[2].Id, ContainerUnknown)
+	if err := clusterService.FlushContainers(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		state ContainerState
+		want  int
+	}{
+		{ContainerCreated, 1},
+		{ContainerRunning, 1},
+		{ContainerExited, 0},
+		{ContainerUnknown, 1},
+	}
+	for _, tt := range tests {
+		if have := clusterService.CountContainers(tt.state); have != tt.want {
+			t.Errorf("%v want:%v,have:%v", tt.state, tt.want, have)
+		}
+	}
+}
+
+func TestDefaultClusterService_FilterContainers(t *testing.T) {
+	clusterService, _ := newTestRestartService(t)
+	running, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	clusterService.CreateContainerWithSpec(ContainerSpec{})
+	if err := clusterService.RunContainer(running); err != nil {
+		t.Fatal(err)
+	}
+
+	res := clusterService.FilterContainers(func(c *Container) bool {
+		return c.ContainerStatus.ContainerState == ContainerRunning
+	})
+	if len(res) != 1 || res[0].Id != running.Id {
+		t.Fatalf("want:%v,have:%v", running, res)
+	}
+	res[0].Name = "changed"
+	if running.Name == "changed" {
+		t.Error("want copy")
+	}
+	if res := clusterService.FilterContainers(func(*Container) bool { return false }); len(res) != 0 {
+		t.Errorf("want:%v,have:%v", 0, res)
+	}
+}
+
+func TestDefaultClusterService_RunningContainers(t *testing.T) {
+	clusterService, _ := newTestRestartService(t)
+	running, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	clusterService.CreateContainerWithSpec(ContainerSpec{})
+	if err := clusterService.RunContainer(running); err != nil {
+		t.Fatal(err)
+	}
+
+	if res := clusterService.RunningContainers(); len(res) != 1 || res[0].Id != running.Id {
+		t.Errorf("want:%v,have:%v", running, res)
+	}
+	// created container is alive, but not running
+	if res, err := clusterService.Containers(false); err != nil || len(res) != 2 {
+		t.Errorf("want:%v,have:%v,%v", 2, res, err)
+	}
+}
@@ -0,0 +1,175 @@
+package clustergrpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/ynishi/cluster"
+	"google.golang.org/grpc"
+)
+
+// Client is cluster.ClusterService backed by remote Server.
+// containers and nodes passed to it are updated by response of server.
+type Client struct {
+	client ClusterServiceClient
+}
+
+var _ cluster.ClusterService = (*Client)(nil)
+
+// NewClient create client on connection to Server.
+func NewClient(cc grpc.ClientConnInterface) *Client {
+	return &Client{client: NewClusterServiceClient(cc)}
+}
+
+func (c *Client) Version() (cluster.Version, error) {
+	res, err := c.client.Version(context.Background(), &VersionRequest{})
+	if err != nil {
+		return "", fromStatusError(err)
+	}
+	return cluster.Version(res.Version), nil
+}
+
+func (c *Client) Image() (*cluster.Image, error) {
+	res, err := c.client.Image(context.Background(), &ImageRequest{})
+	if err != nil {
+		return nil, fromStatusError(err)
+	}
+	return fromProtoImage(res), nil
+}
+
+func (c *Client) Options() (cluster.ContainerOptions, error) {
+	res, err := c.client.Options(context.Background(), &OptionsRequest{})
+	if err != nil {
+		return nil, fromStatusError(err)
+	}
+	return cluster.ContainerOptions(res.Options), nil
+}
+
+func (c *Client) Containers(all bool) (cluster.Containers, error) {
+	res, err := c.client.Containers(context.Background(), &ContainersRequest{All: all})
+	if err != nil {
+		return nil, fromStatusError(err)
+	}
+	containers := cluster.Containers{}
+	for _, container := range res.Containers {
+		containers = append(containers, fromProtoContainer(container))
+	}
+	return containers, nil
+}
+
+func (c *Client) ContainerStatus(uid cluster.UID, name string, nodeName string) (*cluster.ContainerStatus, error) {
+	res, err := c.client.ContainerStatus(context.Background(), &ContainerStatusRequest{Id: string(uid), Name: name, NodeName: nodeName})
+	if err != nil {
+		return nil, fromStatusError(err)
+	}
+	return fromProtoContainerStatus(res), nil
+}
+
+func (c *Client) CreateContainer() (*cluster.Container, error) {
+	res, err := c.client.CreateContainer(context.Background(), &CreateContainerRequest{})
+	if err != nil {
+		return nil, fromStatusError(err)
+	}
+	return fromProtoContainer(res), nil
+}
+
+func (c *Client) RunContainer(container *cluster.Container) error {
+	res, err := c.client.RunContainer(context.Background(), &RunContainerRequest{Id: string(container.Id)})
+	if err != nil {
+		return fromStatusError(err)
+	}
+	updateContainer(container, res)
+	return nil
+}
+
+func (c *Client) KillContainer(runningContainer *cluster.Container, gracePeriod time.Duration) error {
+	res, err := c.client.KillContainer(context.Background(), &KillContainerRequest{Id: string(runningContainer.Id), GracePeriod: gracePeriod.Milliseconds()})
+	if err != nil {
+		return fromStatusError(err)
+	}
+	updateContainer(runningContainer, res)
+	return nil
+}
+
+func (c *Client) Nodes(all bool) ([]*cluster.Node, error) {
+	res, err := c.client.Nodes(context.Background(), &NodesRequest{All: all})
+	if err != nil {
+		return nil, fromStatusError(err)
+	}
+	nodes := []*cluster.Node{}
+	for _, node := range res.Nodes {
+		nodes = append(nodes, fromProtoNode(node))
+	}
+	return nodes, nil
+}
+
+func (c *Client) CreateNode() (*cluster.Node, error) {
+	res, err := c.client.CreateNode(context.Background(), &CreateNodeRequest{})
+	if err != nil {
+		return nil, fromStatusError(err)
+	}
+	return fromProtoNode(res), nil
+}
+
+// RunNode run node on server, the node on server must have its resource provider.
+func (c *Client) RunNode(node *cluster.Node) error {
+	res, err := c.client.RunNode(context.Background(), &RunNodeRequest{Id: string(node.Id)})
+	if err != nil {
+		return fromStatusError(err)
+	}
+	updateNode(node, res)
+	return nil
+}
+
+func (c *Client) KillNode(runningNode cluster.Node, gracePeriod int) error {
+	_, err := c.client.KillNode(context.Background(), &KillNodeRequest{Id: string(runningNode.Id), GracePeriod: int32(gracePeriod)})
+	return fromStatusError(err)
+}
+
+func (c *Client) Status() (cluster.ClusterStatus, error) {
+	res, err := c.client.Status(context.Background(), &StatusRequest{})
+	if err != nil {
+		return cluster.ClusterStatus{}, fromStatusError(err)
+	}
+	return cluster.ClusterStatus{ClusterState: fromProtoClusterState(res.State), Reason: res.Reason}, nil
+}
+
+func (c *Client) NodeStatus(uid cluster.UID, name string) (cluster.NodeStatus, error) {
+	res, err := c.client.NodeStatus(context.Background(), &NodeStatusRequest{Id: string(uid), Name: name})
+	if err != nil {
+		return cluster.NodeStatus{}, fromStatusError(err)
+	}
+	return fromProtoNodeStatus(res), nil
+}
+
+func (c *Client) FlushNodes() error {
+	_, err := c.client.FlushNodes(context.Background(), &FlushNodesRequest{})
+	return fromStatusError(err)
+}
+
+func (c *Client) FlushContainers() error {
+	_, err := c.client.FlushContainers(context.Background(), &FlushContainersRequest{})
+	return fromStatusError(err)
+}
+
+// Shutdown tear down cluster on server, connection of client is not closed.
+func (c *Client) Shutdown(ctx context.Context) error {
+	_, err := c.client.Shutdown(ctx, &ShutdownRequest{})
+	return fromStatusError(err)
+}
+
+// updateContainer copy response into container, keeping its spec which is not transferred.
+func updateContainer(container *cluster.Container, res *Container) {
+	spec := container.Spec
+	*container = *fromProtoContainer(res)
+	container.Spec = spec
+}
+
+// updateNode copy response into node, keeping its client and resource provider.
+func updateNode(node *cluster.Node, res *Node) {
+	client := node.Client
+	resourceProvider := node.ResourceProvider
+	*node = *fromProtoNode(res)
+	node.Client = client
+	node.ResourceProvider = resourceProvider
+}
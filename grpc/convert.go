@@ -0,0 +1,356 @@
+package clustergrpc
+
+import (
+	"time"
+
+	"github.com/ynishi/cluster"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var containerStates = map[cluster.ContainerState]ContainerState{
+	cluster.ContainerUnknown: ContainerState_CONTAINER_STATE_UNKNOWN,
+	cluster.ContainerCreated: ContainerState_CONTAINER_STATE_CREATED,
+	cluster.ContainerRunning: ContainerState_CONTAINER_STATE_RUNNING,
+	cluster.ContainerExited:  ContainerState_CONTAINER_STATE_EXITED,
+	cluster.ContainerPaused:  ContainerState_CONTAINER_STATE_PAUSED,
+	cluster.ContainerPending: ContainerState_CONTAINER_STATE_PENDING,
+}
+
+var nodeStates = map[cluster.NodeState]NodeState{
+	cluster.NodeUnknown:     NodeState_NODE_STATE_UNKNOWN,
+	cluster.NodeCreated:     NodeState_NODE_STATE_CREATED,
+	cluster.NodeRunning:     NodeState_NODE_STATE_RUNNING,
+	cluster.NodeExited:      NodeState_NODE_STATE_EXITED,
+	cluster.NodeUnreachable: NodeState_NODE_STATE_UNREACHABLE,
+	cluster.NodeDraining:    NodeState_NODE_STATE_DRAINING,
+}
+
+var clusterStates = map[cluster.ClusterState]ClusterState{
+	cluster.ClusterRunning:  ClusterState_CLUSTER_STATE_RUNNING,
+	cluster.ClusterDegraded: ClusterState_CLUSTER_STATE_DEGRADED,
+	cluster.ClusterDown:     ClusterState_CLUSTER_STATE_DOWN,
+}
+
+func toProtoContainerState(state cluster.ContainerState) ContainerState {
+	return containerStates[state]
+}
+
+func fromProtoContainerState(state ContainerState) cluster.ContainerState {
+	for k, v := range containerStates {
+		if v == state {
+			return k
+		}
+	}
+	return ""
+}
+
+func toProtoNodeState(state cluster.NodeState) NodeState {
+	return nodeStates[state]
+}
+
+func fromProtoNodeState(state NodeState) cluster.NodeState {
+	for k, v := range nodeStates {
+		if v == state {
+			return k
+		}
+	}
+	return ""
+}
+
+func toProtoClusterState(state cluster.ClusterState) ClusterState {
+	return clusterStates[state]
+}
+
+func fromProtoClusterState(state ClusterState) cluster.ClusterState {
+	for k, v := range clusterStates {
+		if v == state {
+			return k
+		}
+	}
+	return ""
+}
+
+// toProtoTime returns nil for zero time.
+func toProtoTime(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func fromProtoTime(t *timestamppb.Timestamp) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.AsTime()
+}
+
+func errorMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func toProtoImage(image *cluster.Image) *Image {
+	if image == nil {
+		return nil
+	}
+	return &Image{
+		Name:       image.Name,
+		FullName:   image.FullName,
+		Registry:   image.Registry,
+		Repository: image.Repository,
+		Tag:        image.Tag,
+		Digest:     image.Digest,
+	}
+}
+
+func fromProtoImage(image *Image) *cluster.Image {
+	if image == nil {
+		return nil
+	}
+	return &cluster.Image{
+		Name:       image.Name,
+		FullName:   image.FullName,
+		Registry:   image.Registry,
+		Repository: image.Repository,
+		Tag:        image.Tag,
+		Digest:     image.Digest,
+	}
+}
+
+func toProtoPorts(ports []cluster.PortMapping) []*PortMapping {
+	res := []*PortMapping{}
+	for _, port := range ports {
+		res = append(res, &PortMapping{
+			HostPort:      int32(port.HostPort),
+			ContainerPort: int32(port.ContainerPort),
+			Protocol:      port.Protocol,
+		})
+	}
+	return res
+}
+
+func fromProtoPorts(ports []*PortMapping) []cluster.PortMapping {
+	if len(ports) == 0 {
+		return nil
+	}
+	res := []cluster.PortMapping{}
+	for _, port := range ports {
+		res = append(res, cluster.PortMapping{
+			HostPort:      int(port.HostPort),
+			ContainerPort: int(port.ContainerPort),
+			Protocol:      port.Protocol,
+		})
+	}
+	return res
+}
+
+func toProtoCapacity(capacity cluster.Capacity) *Capacity {
+	return &Capacity{
+		CpuShares: capacity.CPUShares,
+		MemoryMb:  capacity.MemoryMB,
+		DiskGb:    capacity.DiskGB,
+	}
+}
+
+func fromProtoCapacity(capacity *Capacity) cluster.Capacity {
+	if capacity == nil {
+		return cluster.Capacity{}
+	}
+	return cluster.Capacity{
+		CPUShares: capacity.CpuShares,
+		MemoryMB:  capacity.MemoryMb,
+		DiskGB:    capacity.DiskGb,
+	}
+}
+
+func toProtoContainerStatus(status *cluster.ContainerStatus) *ContainerStatus {
+	if status == nil {
+		return nil
+	}
+	return &ContainerStatus{
+		Id:         string(status.Id),
+		Name:       status.Name,
+		Namespace:  status.Namespace,
+		NodeName:   status.NodeName,
+		State:      toProtoContainerState(status.ContainerState),
+		CreatedAt:  toProtoTime(status.CreatedAt),
+		StartedAt:  toProtoTime(status.StartedAt),
+		FinishedAt: toProtoTime(status.FinishedAt),
+		ExitCode:   int32(status.ExitCode),
+		Reason:     status.Reason,
+		Message:    status.Message,
+		Error:      errorMessage(status.Error),
+		Ports:      toProtoPorts(status.Ports),
+		Health:     string(status.Health),
+		Liveness:   string(status.Liveness),
+		Readiness:  string(status.Readiness),
+	}
+}
+
+func fromProtoContainerStatus(status *ContainerStatus) *cluster.ContainerStatus {
+	if status == nil {
+		return nil
+	}
+	res := &cluster.ContainerStatus{
+		Id:             cluster.UID(status.Id),
+		Name:           status.Name,
+		Namespace:      status.Namespace,
+		NodeName:       status.NodeName,
+		ContainerState: fromProtoContainerState(status.State),
+		CreatedAt:      fromProtoTime(status.CreatedAt),
+		StartedAt:      fromProtoTime(status.StartedAt),
+		FinishedAt:     fromProtoTime(status.FinishedAt),
+		ExitCode:       int(status.ExitCode),
+		Reason:         status.Reason,
+		Message:        status.Message,
+		Ports:          fromProtoPorts(status.Ports),
+		Health:         cluster.Health(status.Health),
+		Liveness:       cluster.Health(status.Liveness),
+		Readiness:      cluster.Health(status.Readiness),
+	}
+	if status.Error != "" {
+		res.Error = remoteError(status.Error)
+	}
+	return res
+}
+
+func toProtoContainer(container *cluster.Container) *Container {
+	return &Container{
+		Id:              string(container.Id),
+		Name:            container.Name,
+		Hash:            container.Hash,
+		NodeId:          string(container.NodeId),
+		NodeName:        container.NodeName,
+		Status:          toProtoContainerStatus(container.ContainerStatus),
+		Image:           toProtoImage(container.Image),
+		ImageId:         container.ImageId,
+		Options:         container.ContainerOptions,
+		RestartCount:    int32(container.RestartCount),
+		Killed:          container.Killed,
+		Labels:          container.Labels,
+		Namespace:       container.Namespace,
+		ResourceVersion: container.ResourceVersion,
+	}
+}
+
+func fromProtoContainer(container *Container) *cluster.Container {
+	res := cluster.NewContainer(
+		cluster.UID(container.Id),
+		container.Name,
+		container.Hash,
+		cluster.UID(container.NodeId),
+		container.NodeName,
+		fromProtoImage(container.Image),
+		container.ImageId,
+		container.Options,
+	)
+	if container.Status != nil {
+		res.ContainerStatus = fromProtoContainerStatus(container.Status)
+	}
+	res.RestartCount = int(container.RestartCount)
+	res.Killed = container.Killed
+	res.Labels = container.Labels
+	res.ResourceVersion = container.ResourceVersion
+	if container.Namespace != "" {
+		res.Namespace = container.Namespace
+	}
+	return res
+}
+
+func toProtoNode(node *cluster.Node) *Node {
+	return &Node{
+		Id:              string(node.Id),
+		Name:            node.Name,
+		State:           toProtoNodeState(node.NodeState),
+		ResourceInfo:    node.ResourceInfo,
+		Capacity:        toProtoCapacity(node.Capacity),
+		Allocated:       toProtoCapacity(node.Allocated),
+		ContainerCount:  int32(node.ContainerCount),
+		Labels:          node.Labels,
+		Unschedulable:   node.Unschedulable,
+		Namespace:       node.Namespace,
+		Taints:          toProtoTaints(node.Taints),
+		ResourceVersion: node.ResourceVersion,
+	}
+}
+
+func fromProtoNode(node *Node) *cluster.Node {
+	return &cluster.Node{
+		Id:              cluster.UID(node.Id),
+		Name:            node.Name,
+		NodeState:       fromProtoNodeState(node.State),
+		ResourceInfo:    node.ResourceInfo,
+		Capacity:        fromProtoCapacity(node.Capacity),
+		Allocated:       fromProtoCapacity(node.Allocated),
+		ContainerCount:  int(node.ContainerCount),
+		Labels:          node.Labels,
+		Unschedulable:   node.Unschedulable,
+		Namespace:       node.Namespace,
+		Taints:          fromProtoTaints(node.Taints),
+		ResourceVersion: node.ResourceVersion,
+	}
+}
+
+func toProtoTaints(taints []cluster.Taint) []*Taint {
+	if taints == nil {
+		return nil
+	}
+	res := make([]*Taint, 0, len(taints))
+	for _, taint := range taints {
+		res = append(res, &Taint{Key: taint.Key, Value: taint.Value, Effect: string(taint.Effect)})
+	}
+	return res
+}
+
+func fromProtoTaints(taints []*Taint) []cluster.Taint {
+	if taints == nil {
+		return nil
+	}
+	res := make([]cluster.Taint, 0, len(taints))
+	for _, taint := range taints {
+		res = append(res, cluster.Taint{Key: taint.Key, Value: taint.Value, Effect: cluster.TaintEffect(taint.Effect)})
+	}
+	return res
+}
+
+func toProtoNodeStatus(status cluster.NodeStatus) *NodeStatus {
+	return &NodeStatus{
+		Id:          string(status.Id),
+		Name:        status.Name,
+		Namespace:   status.Namespace,
+		State:       toProtoNodeState(status.NodeState),
+		CreatedAt:   toProtoTime(status.CreatedAt),
+		StartedAt:   toProtoTime(status.StartedAt),
+		FinishedAt:  toProtoTime(status.FinishedAt),
+		Reason:      status.Reason,
+		Message:     status.Message,
+		Error:       errorMessage(status.Error),
+		LoadAverage: status.LoadAverage,
+		Memory:      int32(status.Memory),
+		Disk:        int32(status.Disk),
+	}
+}
+
+func fromProtoNodeStatus(status *NodeStatus) cluster.NodeStatus {
+	res := cluster.NodeStatus{
+		Id:          cluster.UID(status.Id),
+		Name:        status.Name,
+		Namespace:   status.Namespace,
+		NodeState:   fromProtoNodeState(status.State),
+		CreatedAt:   fromProtoTime(status.CreatedAt),
+		StartedAt:   fromProtoTime(status.StartedAt),
+		FinishedAt:  fromProtoTime(status.FinishedAt),
+		Reason:      status.Reason,
+		Message:     status.Message,
+		LoadAverage: status.LoadAverage,
+		Memory:      int(status.Memory),
+		Disk:        int(status.Disk),
+	}
+	if status.Error != "" {
+		res.Error = remoteError(status.Error)
+	}
+	return res
+}
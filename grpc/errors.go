@@ -0,0 +1,73 @@
+package clustergrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ynishi/cluster"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorCodes maps errors of cluster to status codes, the first match is used.
+var errorCodes = []struct {
+	err  error
+	code codes.Code
+}{
+	{cluster.ErrNodeNotFound, codes.NotFound},
+	{cluster.ErrContainerNotFound, codes.NotFound},
+	{cluster.ErrContainerStatusNotFound, codes.NotFound},
+	{cluster.ErrNodeAlreadyExists, codes.AlreadyExists},
+	{cluster.ErrAlreadyRunning, codes.FailedPrecondition},
+	{cluster.ErrNotRunning, codes.FailedPrecondition},
+	{cluster.ErrAlreadyExited, codes.FailedPrecondition},
+	{cluster.ErrStillRunning, codes.FailedPrecondition},
+	{cluster.ErrNodeHasContainers, codes.FailedPrecondition},
+	{cluster.ErrIllegalTransition, codes.FailedPrecondition},
+	{cluster.ErrNoResourceProvider, codes.FailedPrecondition},
+	{cluster.ErrNoValidNode, codes.ResourceExhausted},
+	{cluster.ErrInsufficientCapacity, codes.ResourceExhausted},
+	{cluster.ErrRequestExceedsCapacity, codes.InvalidArgument},
+	{cluster.ErrInvalidResources, codes.InvalidArgument},
+	{context.Canceled, codes.Canceled},
+	{context.DeadlineExceeded, codes.DeadlineExceeded},
+}
+
+// toStatusError convert error returned by cluster into status error with its message.
+func toStatusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	for _, ec := range errorCodes {
+		if errors.Is(err, ec.err) {
+			return status.Error(ec.code, err.Error())
+		}
+	}
+	return status.Error(codes.Unknown, err.Error())
+}
+
+// fromStatusError convert status error into error of cluster, so that it can be tested by errors.Is.
+func fromStatusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	for _, ec := range errorCodes {
+		if st.Code() == ec.code && strings.HasPrefix(st.Message(), ec.err.Error()) {
+			return fmt.Errorf("%w%s", ec.err, strings.TrimPrefix(st.Message(), ec.err.Error()))
+		}
+	}
+	return err
+}
+
+// remoteError is error reported by server, only its message is transferred.
+type remoteError string
+
+func (re remoteError) Error() string {
+	return string(re)
+}
@@ -0,0 +1,173 @@
+package clustergrpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/ynishi/cluster"
+)
+
+// Server serves DefaultClusterService over gRPC.
+type Server struct {
+	UnimplementedClusterServiceServer
+	service *cluster.DefaultClusterService
+}
+
+// NewServer create server, register it by RegisterClusterServiceServer.
+func NewServer(service *cluster.DefaultClusterService) *Server {
+	return &Server{service: service}
+}
+
+func (s *Server) Version(ctx context.Context, req *VersionRequest) (*VersionResponse, error) {
+	version, err := s.service.Version()
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &VersionResponse{Version: string(version)}, nil
+}
+
+func (s *Server) Image(ctx context.Context, req *ImageRequest) (*Image, error) {
+	image, err := s.service.Image()
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return toProtoImage(image), nil
+}
+
+func (s *Server) Options(ctx context.Context, req *OptionsRequest) (*OptionsResponse, error) {
+	options, err := s.service.Options()
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &OptionsResponse{Options: options}, nil
+}
+
+func (s *Server) Containers(ctx context.Context, req *ContainersRequest) (*ContainersResponse, error) {
+	containers, err := s.service.Containers(req.All)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	res := &ContainersResponse{}
+	for _, c := range containers {
+		res.Containers = append(res.Containers, toProtoContainer(c))
+	}
+	return res, nil
+}
+
+func (s *Server) ContainerStatus(ctx context.Context, req *ContainerStatusRequest) (*ContainerStatus, error) {
+	status, err := s.service.ContainerStatus(cluster.UID(req.Id), req.Name, req.NodeName)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return toProtoContainerStatus(status), nil
+}
+
+func (s *Server) CreateContainer(ctx context.Context, req *CreateContainerRequest) (*Container, error) {
+	container, err := s.service.CreateContainer()
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return toProtoContainer(container), nil
+}
+
+func (s *Server) RunContainer(ctx context.Context, req *RunContainerRequest) (*Container, error) {
+	container, err := s.service.GetContainer(cluster.UID(req.Id))
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	if err := s.service.RunContainerContext(ctx, container); err != nil {
+		return nil, toStatusError(err)
+	}
+	return toProtoContainer(container), nil
+}
+
+func (s *Server) KillContainer(ctx context.Context, req *KillContainerRequest) (*Container, error) {
+	container, err := s.service.GetContainer(cluster.UID(req.Id))
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	if err := s.service.KillContainerContext(ctx, container, time.Duration(req.GracePeriod)*time.Millisecond); err != nil {
+		return nil, toStatusError(err)
+	}
+	return toProtoContainer(container), nil
+}
+
+func (s *Server) Nodes(ctx context.Context, req *NodesRequest) (*NodesResponse, error) {
+	nodes, err := s.service.Nodes(req.All)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	res := &NodesResponse{}
+	for _, node := range nodes {
+		res.Nodes = append(res.Nodes, toProtoNode(node))
+	}
+	return res, nil
+}
+
+func (s *Server) CreateNode(ctx context.Context, req *CreateNodeRequest) (*Node, error) {
+	node, err := s.service.CreateNode()
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return toProtoNode(node), nil
+}
+
+// RunNode run node known by server, its resource provider must be set on server.
+func (s *Server) RunNode(ctx context.Context, req *RunNodeRequest) (*Node, error) {
+	node, err := s.service.GetNode(cluster.UID(req.Id))
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	if err := s.service.RunNodeContext(ctx, node); err != nil {
+		return nil, toStatusError(err)
+	}
+	return toProtoNode(node), nil
+}
+
+func (s *Server) KillNode(ctx context.Context, req *KillNodeRequest) (*Node, error) {
+	node, err := s.service.GetNode(cluster.UID(req.Id))
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	if err := s.service.KillNodeContext(ctx, *node, int(req.GracePeriod)); err != nil {
+		return nil, toStatusError(err)
+	}
+	return toProtoNode(node), nil
+}
+
+func (s *Server) Status(ctx context.Context, req *StatusRequest) (*ClusterStatus, error) {
+	status, err := s.service.Status()
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return &ClusterStatus{State: toProtoClusterState(status.ClusterState), Reason: status.Reason}, nil
+}
+
+func (s *Server) NodeStatus(ctx context.Context, req *NodeStatusRequest) (*NodeStatus, error) {
+	status, err := s.service.NodeStatus(cluster.UID(req.Id), req.Name)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return toProtoNodeStatus(status), nil
+}
+
+func (s *Server) FlushNodes(ctx context.Context, req *FlushNodesRequest) (*FlushNodesResponse, error) {
+	if err := s.service.FlushNodes(); err != nil {
+		return nil, toStatusError(err)
+	}
+	return &FlushNodesResponse{}, nil
+}
+
+func (s *Server) FlushContainers(ctx context.Context, req *FlushContainersRequest) (*FlushContainersResponse, error) {
+	if err := s.service.FlushContainersContext(ctx); err != nil {
+		return nil, toStatusError(err)
+	}
+	return &FlushContainersResponse{}, nil
+}
+
+func (s *Server) Shutdown(ctx context.Context, req *ShutdownRequest) (*ShutdownResponse, error) {
+	if err := s.service.Shutdown(ctx); err != nil {
+		return nil, toStatusError(err)
+	}
+	return &ShutdownResponse{}, nil
+}
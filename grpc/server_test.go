@@ -0,0 +1,95 @@
+package clustergrpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/ynishi/cluster"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newTestClient(t *testing.T, service *cluster.DefaultClusterService) *Client {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	RegisterClusterServiceServer(server, NewServer(service))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return NewClient(conn)
+}
+
+func TestClient(t *testing.T) {
+	image := &cluster.Image{Name: "testImage"}
+	service := cluster.NewDefaultClusterService("0.0.1", image)
+	service.SetOptions(cluster.ContainerOptions{"key": "value"})
+	serverNode, err := service.CreateNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.PrepareNode(serverNode.Id, func(node *cluster.Node) error {
+		node.Client = cluster.NewFakeContainerClient("hash1")
+		node.ResourceProvider = cluster.NewFakeResourceProvider(cluster.ResourceInfo{"host": "localhost"})
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	client := newTestClient(t, service)
+
+	version, err := client.Version()
+	if err != nil || version != "0.0.1" {
+		t.Errorf("want:%v,have:%v,%v", "0.0.1", version, err)
+	}
+	nodes, err := client.Nodes(true)
+	if err != nil || len(nodes) != 1 {
+		t.Fatalf("want:%v,have:%v,%v", 1, nodes, err)
+	}
+	node := nodes[0]
+	if err := client.RunNode(node); err != nil {
+		t.Fatal(err)
+	}
+	if node.NodeState != cluster.NodeRunning || node.ResourceInfo["host"] != "localhost" {
+		t.Errorf("want:%v,have:%v", cluster.NodeRunning, node)
+	}
+
+	container, err := client.CreateContainer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if container.NodeId != node.Id || container.Image.Name != image.Name {
+		t.Errorf("want:%v,have:%v", node.Id, container)
+	}
+	if err := client.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	if container.Hash != "hash1" || container.ContainerStatus.ContainerState != cluster.ContainerRunning {
+		t.Errorf("want:%v,have:%v", cluster.ContainerRunning, container.ContainerStatus)
+	}
+	if err := client.RunContainer(container); !errors.Is(err, cluster.ErrAlreadyRunning) {
+		t.Errorf("want:%v,have:%v", cluster.ErrAlreadyRunning, err)
+	}
+
+	status, err := client.ContainerStatus(container.Id, "", "")
+	if err != nil || status.ContainerState != cluster.ContainerRunning {
+		t.Errorf("want:%v,have:%v,%v", cluster.ContainerRunning, status, err)
+	}
+	clusterStatus, err := client.Status()
+	if err != nil || clusterStatus.ClusterState != cluster.ClusterRunning {
+		t.Errorf("want:%v,have:%v,%v", cluster.ClusterRunning, clusterStatus, err)
+	}
+	if _, err := client.NodeStatus("unknown", ""); !errors.Is(err, cluster.ErrNodeNotFound) {
+		t.Errorf("want:%v,have:%v", cluster.ErrNodeNotFound, err)
+	}
+}
@@ -0,0 +1,293 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type Health string
+
+const (
+	// health check is not passed yet
+	HealthStarting Health = "starting"
+	// health check passed
+	Healthy Health = "healthy"
+	// health check failed Retries times in a row
+	Unhealthy Health = "unhealthy"
+)
+
+// defaults of HealthCheck, same as docker.
+const (
+	defaultHealthCheckInterval = 30 * time.Second
+	defaultHealthCheckTimeout  = 30 * time.Second
+	defaultHealthCheckRetries  = 3
+)
+
+// HealthCheck checks running container by command or http endpoint.
+type HealthCheck struct {
+	// command run in container, healthy if it exited with code 0
+	Command []string
+	// url requested from cluster, healthy if status is 2xx or 3xx, used instead of Command if set
+	HTTPGet string
+	// interval between checks, 0 means 30s
+	Interval time.Duration
+	// timeout of a check, 0 means 30s
+	Timeout time.Duration
+	// number of failures in a row to be unhealthy, 0 means 3
+	Retries int
+}
+
+func (hc HealthCheck) interval() time.Duration {
+	if hc.Interval <= 0 {
+		return defaultHealthCheckInterval
+	}
+	return hc.Interval
+}
+
+func (hc HealthCheck) timeout() time.Duration {
+	if hc.Timeout <= 0 {
+		return defaultHealthCheckTimeout
+	}
+	return hc.Timeout
+}
+
+func (hc HealthCheck) retries() int {
+	if hc.Retries <= 0 {
+		return defaultHealthCheckRetries
+	}
+	return hc.Retries
+}
+
+// probe is kind of health check in spec, with its result in status.
+type probe struct {
+	healthCheck func(spec *ContainerSpec) *HealthCheck
+	result      func(status *ContainerStatus) *Health
+	// called without lock when container becomes unhealthy
+	onUnhealthy func(dcs *DefaultClusterService, ctx context.Context, container *Container)
+}
+
+var probes = []probe{
+	{
+		healthCheck: func(spec *ContainerSpec) *HealthCheck { return spec.HealthCheck },
+		result:      func(status *ContainerStatus) *Health { return &status.Health },
+	},
+	{
+		healthCheck: func(spec *ContainerSpec) *HealthCheck { return spec.LivenessProbe },
+		result:      func(status *ContainerStatus) *Health { return &status.Liveness },
+		onUnhealthy: (*DefaultClusterService).killUnhealthy,
+	},
+	{
+		healthCheck: func(spec *ContainerSpec) *HealthCheck { return spec.ReadinessProbe },
+		result:      func(status *ContainerStatus) *Health { return &status.Readiness },
+	},
+}
+
+// hasProbe returns true if container has any health check.
+func (c *Container) hasProbe() bool {
+	for _, p := range probes {
+		if p.healthCheck(&c.Spec) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// StartHealthCheck run health checks, liveness and readiness probes of running containers in background
+// until ctx is done or Shutdown. each check runs every its interval, and checking stops when container exits.
+// container failing liveness probe is killed, to be restarted by its restart policy.
+func (dcs *DefaultClusterService) StartHealthCheck(ctx context.Context) {
+	events, unsubscribe := dcs.Watch()
+	dcs.goLoop(ctx, func(ctx context.Context) {
+		defer unsubscribe()
+		checking := make(map[UID]bool)
+		done := make(chan UID)
+		start := func(container *Container) {
+			if container == nil || checking[container.Id] {
+				return
+			}
+			checking[container.Id] = true
+			go func() {
+				dcs.runHealthCheck(ctx, container)
+				select {
+				case done <- container.Id:
+				case <-ctx.Done():
+				}
+			}()
+		}
+		for _, c := range dcs.healthCheckTargets("") {
+			start(c)
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case id := <-done:
+				// container may be restarted before its check stopped
+				delete(checking, id)
+				for _, c := range dcs.healthCheckTargets(id) {
+					start(c)
+				}
+			case event := <-events:
+				if event.Type == EventContainerStarted {
+					for _, c := range dcs.healthCheckTargets(event.Id) {
+						start(c)
+					}
+				}
+			}
+		}
+	})
+}
+
+// healthCheckTargets returns running containers which have health check, filtered by uid if it is not empty.
+func (dcs *DefaultClusterService) healthCheckTargets(uid UID) Containers {
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
+	res := Containers{}
+	for _, c := range dcs.containers {
+		if uid != "" && c.Id != uid {
+			continue
+		}
+		if c.hasProbe() && c.ContainerStatus != nil && c.ContainerStatus.ContainerState == ContainerRunning {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// runHealthCheck run each check of container concurrently until it exits or ctx is done.
+func (dcs *DefaultClusterService) runHealthCheck(ctx context.Context, container *Container) {
+	var wg sync.WaitGroup
+	for _, p := range probes {
+		dcs.mu.Lock()
+		healthCheck := p.healthCheck(&container.Spec)
+		if healthCheck != nil {
+			*p.result(container.ContainerStatus) = HealthStarting
+		}
+		dcs.mu.Unlock()
+		if healthCheck == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(p probe, hc HealthCheck) {
+			defer wg.Done()
+			dcs.runProbe(ctx, container, p, hc)
+		}(p, *healthCheck)
+	}
+	wg.Wait()
+}
+
+// runProbe check container by hc every its interval until it exits or ctx is done.
+func (dcs *DefaultClusterService) runProbe(ctx context.Context, container *Container, p probe, hc HealthCheck) {
+	ticker := time.NewTicker(hc.interval())
+	defer ticker.Stop()
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		dcs.mu.RLock()
+		running := container.ContainerStatus.ContainerState == ContainerRunning
+		node := dcs.findNodeById(container.NodeId).Clone()
+		// container is owned by service, so runtime is given its snapshot
+		snapshot := container.Clone()
+		dcs.mu.RUnlock()
+		if !running || node == nil {
+			return
+		}
+		err := hc.check(ctx, node, snapshot)
+
+		dcs.mu.Lock()
+		if container.ContainerStatus.ContainerState != ContainerRunning {
+			dcs.mu.Unlock()
+			return
+		}
+		result := p.result(container.ContainerStatus)
+		previous := *result
+		if err == nil {
+			failures = 0
+			*result = Healthy
+		} else {
+			failures++
+			if failures >= hc.retries() {
+				*result = Unhealthy
+			}
+		}
+		becameUnhealthy := *result != previous && *result == Unhealthy
+		if *result != previous {
+			dcs.bumpContainer(container)
+		}
+		dcs.mu.Unlock()
+		if becameUnhealthy && p.onUnhealthy != nil {
+			p.onUnhealthy(dcs, ctx, container)
+		}
+	}
+}
+
+// killUnhealthy stop container failed liveness probe as failed, not killed, so that restart policy re-runs it.
+// runtime is called without lock, so stopping in grace period does not block others.
+func (dcs *DefaultClusterService) killUnhealthy(ctx context.Context, container *Container) {
+	dcs.mu.RLock()
+	node := dcs.findNodeById(container.NodeId).Clone()
+	snapshot := container.Clone()
+	dcs.mu.RUnlock()
+	if node == nil || node.Client == nil {
+		return
+	}
+	err := node.Client.Stop(ctx, snapshot, DefaultStopGracePeriod)
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	// container may be restarted while stopping without lock
+	if container.Hash != snapshot.Hash {
+		return
+	}
+	if err != nil {
+		container.ContainerStatus.Error = err
+		return
+	}
+	if err := transitionContainer(container.ContainerStatus, ContainerExited, "liveness probe failed"); err != nil {
+		return
+	}
+	container.ContainerStatus.Error = fmt.Errorf("%w for uid:%v", ErrLivenessFailed, container.Id)
+	dcs.emit(EventContainerExited, container.Id)
+}
+
+// check run health check once on node, returns error if container is not healthy.
+func (hc HealthCheck) check(ctx context.Context, node *Node, container *Container) error {
+	ctx, cancel := context.WithTimeout(ctx, hc.timeout())
+	defer cancel()
+	if hc.HTTPGet != "" {
+		req, err := http.NewRequest(http.MethodGet, hc.HTTPGet, nil)
+		if err != nil {
+			return err
+		}
+		res, err := http.DefaultClient.Do(req.WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+		if res.StatusCode < 200 || res.StatusCode >= 400 {
+			return fmt.Errorf("health check responded status:%d", res.StatusCode)
+		}
+		return nil
+	}
+	if len(hc.Command) == 0 {
+		return errors.New("health check has no command or http endpoint")
+	}
+	if node.Client == nil {
+		return fmt.Errorf("%w:%v", ErrNodeHasNoClient, node.Name)
+	}
+	_, _, exitCode, err := node.Client.Exec(ctx, container, hc.Command)
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("health check exited with code:%d", exitCode)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// waitHealth poll health of container until it becomes want or timeout.
+func waitHealth(clusterService *DefaultClusterService, container *Container, want Health) Health {
+	deadline := time.Now().Add(time.Second)
+	for {
+		status, _ := clusterService.ContainerStatus(container.Id, "", "")
+		have := status.Health
+		if have == want || time.Now().After(deadline) {
+			return have
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDefaultClusterService_StartHealthCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	clusterService, _ := newTestRestartService(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clusterService.StartHealthCheck(ctx)
+
+	tests := []struct {
+		healthCheck HealthCheck
+		want        Health
+	}{
+		{HealthCheck{Command: []string{"true"}, Interval: 10 * time.Millisecond}, Healthy},
+		{HealthCheck{Command: []string{"false"}, Interval: 10 * time.Millisecond, Retries: 2}, Unhealthy},
+		{HealthCheck{HTTPGet: server.URL + "/health", Interval: 10 * time.Millisecond}, Healthy},
+		{HealthCheck{HTTPGet: server.URL + "/broken", Interval: 10 * time.Millisecond, Retries: 1}, Unhealthy},
+	}
+	for _, tt := range tests {
+		healthCheck := tt.healthCheck
+		container, err := clusterService.CreateContainerWithSpec(ContainerSpec{HealthCheck: &healthCheck})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := clusterService.RunContainer(container); err != nil {
+			t.Fatal(err)
+		}
+		if have := waitHealth(clusterService, container, tt.want); have != tt.want {
+			t.Errorf("want:%v,have:%v", tt.want, have)
+		}
+	}
+}
+
+func TestDefaultClusterService_StartHealthCheck_Exited(t *testing.T) {
+	clusterService, _ := newTestRestartService(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clusterService.StartHealthCheck(ctx)
+
+	healthCheck := &HealthCheck{Command: []string{"false"}, Interval: 50 * time.Millisecond, Retries: 1}
+	container, err := clusterService.CreateContainerWithSpec(ContainerSpec{HealthCheck: healthCheck})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	if have := waitHealth(clusterService, container, HealthStarting); have != HealthStarting {
+		t.Errorf("want:%v,have:%v", HealthStarting, have)
+	}
+	// exited before first check, so it is never checked
+	if err := clusterService.KillContainer(container, DefaultStopGracePeriod); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(150 * time.Millisecond)
+	if have := waitHealth(clusterService, container, HealthStarting); have != HealthStarting {
+		t.Errorf("want:%v,have:%v", HealthStarting, have)
+	}
+}
+
+func newTestProbeService(t *testing.T) *DefaultClusterService {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	runTestNode(t, clusterService, NewInMemoryContainerClient(0))
+	return clusterService
+}
+
+func TestDefaultClusterService_StartHealthCheck_Liveness(t *testing.T) {
+	clusterService := newTestProbeService(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clusterService.StartHealthCheck(ctx)
+
+	container, err := clusterService.CreateContainerWithSpec(ContainerSpec{
+		RestartPolicy: RestartPolicy{Name: RestartOnFailure},
+		LivenessProbe: &HealthCheck{Command: []string{"false"}, Interval: 10 * time.Millisecond, Retries: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	waitCtx, waitCancel := context.WithTimeout(ctx, time.Second)
+	defer waitCancel()
+	if err := clusterService.WaitForContainer(waitCtx, container.Id, ContainerExited); err != nil {
+		t.Fatal(err)
+	}
+	container, _ = clusterService.GetContainer(container.Id)
+	status := container.ContainerStatus
+	if status.Liveness != Unhealthy || !errors.Is(status.Error, ErrLivenessFailed) || container.Killed {
+		t.Errorf("want:%v,have:%v", Unhealthy, status)
+	}
+	restarted, err := clusterService.RestartContainers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(restarted) != 1 {
+		t.Errorf("want:%v,have:%v", 1, restarted)
+	}
+}
+
+func TestDefaultClusterService_StartHealthCheck_Readiness(t *testing.T) {
+	clusterService := newTestProbeService(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clusterService.StartHealthCheck(ctx)
+
+	tests := []struct {
+		name    string
+		command []string
+		want    Health
+	}{
+		{"ready", []string{"true"}, Healthy},
+		{"notReady", []string{"false"}, Unhealthy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			container, err := clusterService.CreateContainerWithSpec(ContainerSpec{
+				RestartPolicy:  RestartPolicy{Name: RestartOnFailure},
+				ReadinessProbe: &HealthCheck{Command: tt.command, Interval: 10 * time.Millisecond, Retries: 1},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := clusterService.RunContainer(container); err != nil {
+				t.Fatal(err)
+			}
+			deadline := time.Now().Add(time.Second)
+			for {
+				status, _ := clusterService.ContainerStatus(container.Id, "", "")
+				have := status.Readiness
+				if have == tt.want || time.Now().After(deadline) {
+					break
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+			// failed readiness probe fails waiting for container, but it keeps running
+			ready, err := clusterService.containerReady(container.Id)
+			if (tt.want == Unhealthy) != errors.Is(err, ErrUnexpectedState) {
+				t.Fatalf("want:%v,have:%v", tt.want, err)
+			}
+			if ready != (tt.want == Healthy) || containerStateOf(container) != ContainerRunning {
+				t.Errorf("want:%v,have:%v", tt.want, container.ContainerStatus)
+			}
+		})
+	}
+}
+
+func TestHealthCheck_check_NoClient(t *testing.T) {
+	hc := HealthCheck{Command: []string{"true"}}
+	node := &Node{Id: "node1", Name: "nodename1"}
+	container := NewContainer("id1", "name1", "", "node1", "nodename1", testImage, "", nil)
+	if err := hc.check(context.Background(), node, container); !errors.Is(err, ErrNodeHasNoClient) {
+		t.Errorf("want:%v,have:%v", ErrNodeHasNoClient, err)
+	}
+}
@@ -0,0 +1,124 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultHeartbeatTimeout is duration a running node may miss heartbeat before it is marked unreachable.
+// node is marked exited when it misses heartbeat for twice of the timeout.
+const DefaultHeartbeatTimeout = 30 * time.Second
+
+// NodeProber is ResourceProvider which can check node is alive.
+// heartbeat of node whose provider is not NodeProber is recorded on every FlushNodes.
+type NodeProber interface {
+	ProbeNode(*Node) error
+}
+
+// SetHeartbeatTimeout set duration a running node may miss heartbeat before it is marked unreachable, 0 disables it.
+func (dcs *DefaultClusterService) SetHeartbeatTimeout(timeout time.Duration) {
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	dcs.heartbeatTimeout = timeout
+}
+
+// probeNode check node is alive by its resource provider, called without lock as provider may be slow.
+// node whose provider is not NodeProber is always alive.
+func probeNode(node *Node) error {
+	if prober, ok := node.ResourceProvider.(NodeProber); ok {
+		return prober.ProbeNode(node)
+	}
+	return nil
+}
+
+// recordProbe record heartbeat of running or unreachable node in its status if the probe succeeded,
+// unreachable node recovers to running. caller must hold lock.
+func (dcs *DefaultClusterService) recordProbe(node *Node, status *NodeStatus, err error) bool {
+	if err != nil {
+		status.Message = fmt.Sprintf("probe failed:%v", err)
+		return false
+	}
+	status.LastHeartbeat = time.Now()
+	if node.NodeState == NodeUnreachable {
+		dcs.transitionNode(node, NodeRunning, "heartbeat recovered")
+	}
+	return true
+}
+
+// CheckHeartbeats mark running nodes unreachable if they have no heartbeat within timeout,
+// and mark running or unreachable nodes exited if they have no heartbeat within twice of timeout,
+// rescheduling their containers. returns nodes marked exited, and errors of rescheduling.
+func (dcs *DefaultClusterService) CheckHeartbeats() (Nodes, error) {
+	return dcs.CheckHeartbeatsContext(context.Background())
+}
+
+// CheckHeartbeatsContext is CheckHeartbeats which gives up re-running rescheduled containers when ctx is done.
+func (dcs *DefaultClusterService) CheckHeartbeatsContext(ctx context.Context) (Nodes, error) {
+	moved := &rescheduled{}
+	dcs.mu.Lock()
+	dead, err := dcs.checkHeartbeats(moved)
+	dcs.mu.Unlock()
+	// containers of dead nodes are run after lock is released
+	if _, runErr := dcs.runRescheduled(ctx, moved); runErr != nil {
+		err = errors.Join(err, runErr)
+	}
+	return dead, err
+}
+
+// checkHeartbeats mark nodes by their heartbeats under lock, adding containers of dead nodes to moved.
+func (dcs *DefaultClusterService) checkHeartbeats(moved *rescheduled) (Nodes, error) {
+	dead := Nodes{}
+	if dcs.heartbeatTimeout <= 0 {
+		return dead, nil
+	}
+	now := time.Now()
+	errs := []error{}
+	for _, ns := range dcs.nodeStatuses {
+		node := dcs.findNodeById(ns.Id)
+		if node == nil || (node.NodeState != NodeRunning && node.NodeState != NodeUnreachable) || ns.LastHeartbeat.IsZero() {
+			continue
+		}
+		missing := now.Sub(ns.LastHeartbeat)
+		reason := fmt.Sprintf("no heartbeat since %v", ns.LastHeartbeat.Format(time.RFC3339))
+		if missing <= dcs.heartbeatTimeout {
+			continue
+		}
+		if missing <= 2*dcs.heartbeatTimeout {
+			if node.NodeState == NodeRunning {
+				if err := dcs.transitionNode(node, NodeUnreachable, reason); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			continue
+		}
+		if err := dcs.transitionNode(node, NodeExited, reason); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		dcs.emit(EventNodeLeft, node.Id)
+		dead = append(dead, node.Clone())
+		dcs.rescheduleContainersFrom(node.Id, moved)
+	}
+	return dead, errors.Join(errs...)
+}
+
+// StartHeartbeatCheck flush nodes to record their heartbeat, then check heartbeats by CheckHeartbeats,
+// every interval until ctx is done or Shutdown. returned channel is closed when the loop exited.
+func (dcs *DefaultClusterService) StartHeartbeatCheck(ctx context.Context, interval time.Duration) <-chan struct{} {
+	return dcs.goLoop(ctx, func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				// errors are retried in the next round
+				dcs.FlushNodes()
+				dcs.CheckHeartbeatsContext(ctx)
+			}
+		}
+	})
+}
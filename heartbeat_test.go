@@ -0,0 +1,142 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func newTestHeartbeatService(t *testing.T) (*DefaultClusterService, *FakeResourceProvider, Nodes) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	provider := NewFakeResourceProvider(ResourceInfo{"host": "localhost"})
+	nodes := Nodes{}
+	for i := 0; i < 2; i++ {
+		node := prepareTestNode(t, clusterService, func(node *Node) {
+			node.ResourceProvider = provider
+			node.Client = NewFakeContainerClient("hash1")
+		})
+		nodes = append(nodes, node)
+	}
+	return clusterService, provider, nodes
+}
+
+// partition make node unreachable by missing its heartbeat.
+func partition(t *testing.T, clusterService *DefaultClusterService, node *Node) {
+	t.Helper()
+	clusterService.SetHeartbeatTimeout(time.Minute)
+	clusterService.findNodeStatusById(node.Id).LastHeartbeat = time.Now().Add(-90 * time.Second)
+	if _, err := clusterService.CheckHeartbeats(); err != nil {
+		t.Fatal(err)
+	}
+	if state, _ := clusterService.nodeState(node.Id); state != NodeUnreachable {
+		t.Fatalf("want:%v,have:%v", NodeUnreachable, state)
+	}
+}
+
+func TestDefaultClusterService_CheckHeartbeats(t *testing.T) {
+	clusterService, provider, nodes := newTestHeartbeatService(t)
+	clusterService.SetOptions(ContainerOptions{})
+	container, err := clusterService.CreateContainerOn(nodes[0].Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+
+	// machine of node disappeared
+	provider.StopNode(nodes[0])
+	before := clusterService.findNodeStatusById(nodes[0].Id).LastHeartbeat
+	if err := clusterService.FlushNodes(); err != nil {
+		t.Fatal(err)
+	}
+	if have := clusterService.findNodeStatusById(nodes[0].Id).LastHeartbeat; !have.Equal(before) {
+		t.Errorf("want:%v,have:%v", before, have)
+	}
+	if have := clusterService.findNodeStatusById(nodes[1].Id).LastHeartbeat; !have.After(before) {
+		t.Errorf("want after:%v,have:%v", before, have)
+	}
+	if dead, err := clusterService.CheckHeartbeats(); err != nil || len(dead) != 0 {
+		t.Errorf("want:%v,have:%v,%v", 0, dead, err)
+	}
+
+	clusterService.findNodeStatusById(nodes[0].Id).LastHeartbeat = time.Now().Add(-time.Hour)
+	dead, err := clusterService.CheckHeartbeats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dead) != 1 || dead[0].Id != nodes[0].Id {
+		t.Fatalf("want:%v,have:%v", nodes[0], dead)
+	}
+	if state, _ := clusterService.nodeState(nodes[0].Id); state != NodeExited {
+		t.Errorf("want:%v,have:%v", NodeExited, state)
+	}
+	container, _ = clusterService.GetContainer(container.Id)
+	if container.NodeId != nodes[1].Id || container.ContainerStatus.ContainerState != ContainerRunning {
+		t.Errorf("want:%v,have:%v", nodes[1].Id, container)
+	}
+}
+
+func TestDefaultClusterService_CheckHeartbeats_Disabled(t *testing.T) {
+	clusterService, _, nodes := newTestHeartbeatService(t)
+	clusterService.SetHeartbeatTimeout(0)
+	clusterService.findNodeStatusById(nodes[0].Id).LastHeartbeat = time.Now().Add(-time.Hour)
+	if dead, err := clusterService.CheckHeartbeats(); err != nil || len(dead) != 0 {
+		t.Errorf("want:%v,have:%v,%v", 0, dead, err)
+	}
+}
+
+func TestDefaultClusterService_StartHeartbeatCheck(t *testing.T) {
+	clusterService, provider, nodes := newTestHeartbeatService(t)
+	clusterService.SetHeartbeatTimeout(50 * time.Millisecond)
+	provider.StopNode(nodes[0])
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	stopped := clusterService.StartHeartbeatCheck(ctx, 10*time.Millisecond)
+	if err := clusterService.WaitForNode(ctx, nodes[0].Id, NodeExited); err != nil {
+		t.Fatal(err)
+	}
+	if state, _ := clusterService.nodeState(nodes[1].Id); state != NodeRunning {
+		t.Errorf("want:%v,have:%v", NodeRunning, state)
+	}
+	cancel()
+	<-stopped
+}
+
+func TestDefaultClusterService_CheckHeartbeats_Unreachable(t *testing.T) {
+	clusterService, provider, nodes := newTestHeartbeatService(t)
+	clusterService.SetHeartbeatTimeout(time.Minute)
+	for _, node := range nodes {
+		clusterService.findNodeStatusById(node.Id).LastHeartbeat = time.Now().Add(-90 * time.Second)
+	}
+	provider.StopNode(nodes[0])
+	if dead, err := clusterService.CheckHeartbeats(); err != nil || len(dead) != 0 {
+		t.Fatalf("want:%v,have:%v,%v", 0, dead, err)
+	}
+	for _, node := range nodes {
+		if state, _ := clusterService.nodeState(node.Id); state != NodeUnreachable {
+			t.Errorf("want:%v,have:%v", NodeUnreachable, state)
+		}
+	}
+	if status, _ := clusterService.Status(); status.ClusterState != ClusterDegraded {
+		t.Errorf("want:%v,have:%v", ClusterDegraded, status)
+	}
+
+	// node whose probe succeeds recovers
+	if err := clusterService.FlushNodes(); err != nil {
+		t.Fatal(err)
+	}
+	unreachable, _ := clusterService.nodeState(nodes[0].Id)
+	running, _ := clusterService.nodeState(nodes[1].Id)
+	if unreachable != NodeUnreachable || running != NodeRunning {
+		t.Errorf("want:%v,%v,have:%v,%v", NodeUnreachable, NodeRunning, unreachable, running)
+	}
+	clusterService.findNodeStatusById(nodes[0].Id).LastHeartbeat = time.Now().Add(-3 * time.Minute)
+	if dead, err := clusterService.CheckHeartbeats(); err != nil || len(dead) != 1 || dead[0].Id != nodes[0].Id {
+		t.Errorf("want:%v,have:%v,%v", nodes[0], dead, err)
+	}
+	if state, _ := clusterService.nodeState(nodes[0].Id); state != NodeExited {
+		t.Errorf("want:%v,have:%v", NodeExited, state)
+	}
+}
@@ -0,0 +1,42 @@
+package cluster
+
+import (
+	"fmt"
+	"time"
+)
+
+// maxStatusHistory is number of transitions kept in history of container status, older ones are dropped.
+const maxStatusHistory = 32
+
+// StatusEvent is transition of container state.
+type StatusEvent struct {
+	// time of transition
+	Time time.Time
+	// state before transition
+	From ContainerState
+	// state after transition
+	To ContainerState
+	// reason of transition, empty if unknown
+	Reason string
+}
+
+// recordHistory append event to history, dropping the oldest if it is full.
+func (cs *ContainerStatus) recordHistory(event StatusEvent) {
+	if len(cs.History) >= maxStatusHistory {
+		// shift in place to keep the backing array bounded
+		n := copy(cs.History, cs.History[len(cs.History)-maxStatusHistory+1:])
+		cs.History = cs.History[:n]
+	}
+	cs.History = append(cs.History, event)
+}
+
+// ContainerHistory returns recent transitions of container, oldest first.
+func (dcs *DefaultClusterService) ContainerHistory(uid UID) ([]StatusEvent, error) {
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
+	container := dcs.findContainerById(uid)
+	if container == nil {
+		return nil, fmt.Errorf("%w for uid:%v", ErrContainerNotFound, uid)
+	}
+	return append([]StatusEvent{}, container.ContainerStatus.History...), nil
+}
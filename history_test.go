@@ -0,0 +1,50 @@
+package cluster
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDefaultClusterService_ContainerHistory(t *testing.T) {
+	clusterService, _ := newTestRestartService(t)
+	container, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	clusterService.RunContainer(container)
+	if err := clusterService.KillContainer(container, time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := clusterService.ContainerHistory(container.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []StatusEvent{
+		{From: ContainerUnknown, To: ContainerCreated},
+		{From: ContainerCreated, To: ContainerRunning},
+		{From: ContainerRunning, To: ContainerExited, Reason: "killed by KillContainer"},
+	}
+	if len(history) != len(expected) {
+		t.Fatalf("want:%v,have:%v", expected, history)
+	}
+	for i, event := range history {
+		if event.From != expected[i].From || event.To != expected[i].To || event.Reason != expected[i].Reason || event.Time.IsZero() {
+			t.Errorf("want:%v,have:%v", expected[i], event)
+		}
+	}
+	if _, err := clusterService.ContainerHistory("unknown"); !errors.Is(err, ErrContainerNotFound) {
+		t.Errorf("want:%v,have:%v", ErrContainerNotFound, err)
+	}
+}
+
+func TestContainerStatus_recordHistory(t *testing.T) {
+	status := &ContainerStatus{}
+	for i := 0; i < maxStatusHistory+3; i++ {
+		status.recordHistory(StatusEvent{Time: time.Unix(int64(i), 0)})
+	}
+	if len(status.History) != maxStatusHistory {
+		t.Fatalf("want:%v,have:%v", maxStatusHistory, len(status.History))
+	}
+	if first := status.History[0].Time; !first.Equal(time.Unix(3, 0)) {
+		t.Errorf("want:%v,have:%v", time.Unix(3, 0), first)
+	}
+}
@@ -0,0 +1,319 @@
+package clusterhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ynishi/cluster"
+)
+
+// Handler serves ClusterService as JSON over HTTP.
+//
+//	GET    /version
+//	GET    /status
+//	GET    /containers?all=true
+//	POST   /containers
+//	POST   /containers/flush
+//	GET    /containers/{id}
+//	POST   /containers/{id}/run
+//	DELETE /containers/{id}?gracePeriod=1000
+//	GET    /nodes?all=true
+//	POST   /nodes
+//	POST   /nodes/flush
+//	GET    /nodes/{id}
+//	POST   /nodes/{id}/run
+//	DELETE /nodes/{id}?gracePeriod=1000
+type Handler struct {
+	service cluster.ClusterService
+	mux     *http.ServeMux
+}
+
+var _ http.Handler = (*Handler)(nil)
+
+// NewHandler create handler wrapping service.
+func NewHandler(service cluster.ClusterService) *Handler {
+	h := &Handler{service: service, mux: http.NewServeMux()}
+	h.mux.HandleFunc("GET /version", h.version)
+	h.mux.HandleFunc("GET /status", h.status)
+	h.mux.HandleFunc("GET /containers", h.containers)
+	h.mux.HandleFunc("POST /containers", h.createContainer)
+	h.mux.HandleFunc("POST /containers/flush", h.flushContainers)
+	h.mux.HandleFunc("GET /containers/{id}", h.container)
+	h.mux.HandleFunc("POST /containers/{id}/run", h.runContainer)
+	h.mux.HandleFunc("DELETE /containers/{id}", h.killContainer)
+	h.mux.HandleFunc("GET /nodes", h.nodes)
+	h.mux.HandleFunc("POST /nodes", h.createNode)
+	h.mux.HandleFunc("POST /nodes/flush", h.flushNodes)
+	h.mux.HandleFunc("GET /nodes/{id}", h.nodeStatus)
+	h.mux.HandleFunc("POST /nodes/{id}/run", h.runNode)
+	h.mux.HandleFunc("DELETE /nodes/{id}", h.killNode)
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) version(w http.ResponseWriter, r *http.Request) {
+	version, err := h.service.Version()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]cluster.Version{"Version": version})
+}
+
+func (h *Handler) status(w http.ResponseWriter, r *http.Request) {
+	status, err := h.service.Status()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (h *Handler) containers(w http.ResponseWriter, r *http.Request) {
+	all, err := queryBool(r, "all")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	containers, err := h.service.Containers(all)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, containers)
+}
+
+func (h *Handler) createContainer(w http.ResponseWriter, r *http.Request) {
+	container, err := h.service.CreateContainer()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, container)
+}
+
+func (h *Handler) flushContainers(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.FlushContainers(); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) container(w http.ResponseWriter, r *http.Request) {
+	container, err := h.findContainer(cluster.UID(r.PathValue("id")))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, container)
+}
+
+func (h *Handler) runContainer(w http.ResponseWriter, r *http.Request) {
+	container, err := h.findContainer(cluster.UID(r.PathValue("id")))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := h.service.RunContainer(container); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, container)
+}
+
+func (h *Handler) killContainer(w http.ResponseWriter, r *http.Request) {
+	container, err := h.findContainer(cluster.UID(r.PathValue("id")))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	// containers are stopped gracefully unless gracePeriod is given
+	gracePeriod := cluster.DefaultStopGracePeriod
+	if s := r.URL.Query().Get("gracePeriod"); s != "" {
+		ms, err := strconv.Atoi(s)
+		if err != nil {
+			writeError(w, badRequest(fmt.Sprintf("invalid gracePeriod:%v", s)))
+			return
+		}
+		gracePeriod = time.Duration(ms) * time.Millisecond
+	}
+	if err := h.service.KillContainer(container, gracePeriod); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, container)
+}
+
+func (h *Handler) nodes(w http.ResponseWriter, r *http.Request) {
+	all, err := queryBool(r, "all")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	nodes, err := h.service.Nodes(all)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, nodes)
+}
+
+func (h *Handler) createNode(w http.ResponseWriter, r *http.Request) {
+	node, err := h.service.CreateNode()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, node)
+}
+
+func (h *Handler) flushNodes(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.FlushNodes(); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) nodeStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := h.service.NodeStatus(cluster.UID(r.PathValue("id")), "")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (h *Handler) runNode(w http.ResponseWriter, r *http.Request) {
+	node, err := h.findNode(cluster.UID(r.PathValue("id")))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if err := h.service.RunNode(node); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, node)
+}
+
+func (h *Handler) killNode(w http.ResponseWriter, r *http.Request) {
+	node, err := h.findNode(cluster.UID(r.PathValue("id")))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	gracePeriod := 0
+	if s := r.URL.Query().Get("gracePeriod"); s != "" {
+		gracePeriod, err = strconv.Atoi(s)
+		if err != nil {
+			writeError(w, badRequest(fmt.Sprintf("invalid gracePeriod:%v", s)))
+			return
+		}
+	}
+	if err := h.service.KillNode(*node, gracePeriod); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// findContainer find container by id through service, which has no lookup by id.
+func (h *Handler) findContainer(uid cluster.UID) (*cluster.Container, error) {
+	containers, err := h.service.Containers(true)
+	if err != nil {
+		return nil, err
+	}
+	for _, container := range containers {
+		if container.Id == uid {
+			return container, nil
+		}
+	}
+	return nil, fmt.Errorf("%w for uid:%v", cluster.ErrContainerNotFound, uid)
+}
+
+// findNode find node by id through service, which has no lookup by id.
+func (h *Handler) findNode(uid cluster.UID) (*cluster.Node, error) {
+	nodes, err := h.service.Nodes(true)
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range nodes {
+		if node.Id == uid {
+			return node, nil
+		}
+	}
+	return nil, fmt.Errorf("%w for uid:%v", cluster.ErrNodeNotFound, uid)
+}
+
+func queryBool(r *http.Request, key string) (bool, error) {
+	s := r.URL.Query().Get(key)
+	if s == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, badRequest(fmt.Sprintf("invalid %v:%v", key, s))
+	}
+	return b, nil
+}
+
+// statusCodes maps errors of cluster to http status codes, the first match is used.
+var statusCodes = []struct {
+	err  error
+	code int
+}{
+	{cluster.ErrNodeNotFound, http.StatusNotFound},
+	{cluster.ErrContainerNotFound, http.StatusNotFound},
+	{cluster.ErrContainerStatusNotFound, http.StatusNotFound},
+	{cluster.ErrNodeAlreadyExists, http.StatusConflict},
+	{cluster.ErrAlreadyRunning, http.StatusConflict},
+	{cluster.ErrNotRunning, http.StatusConflict},
+	{cluster.ErrAlreadyExited, http.StatusConflict},
+	{cluster.ErrStillRunning, http.StatusConflict},
+	{cluster.ErrNodeHasContainers, http.StatusConflict},
+	{cluster.ErrIllegalTransition, http.StatusConflict},
+	{cluster.ErrNoResourceProvider, http.StatusConflict},
+	{cluster.ErrNoValidNode, http.StatusServiceUnavailable},
+	{cluster.ErrInsufficientCapacity, http.StatusServiceUnavailable},
+	{cluster.ErrRequestExceedsCapacity, http.StatusBadRequest},
+	{cluster.ErrInvalidResources, http.StatusBadRequest},
+}
+
+// badRequest is error of invalid request parameter.
+type badRequest string
+
+func (br badRequest) Error() string {
+	return string(br)
+}
+
+func statusCode(err error) int {
+	var br badRequest
+	if errors.As(err, &br) {
+		return http.StatusBadRequest
+	}
+	for _, sc := range statusCodes {
+		if errors.Is(err, sc.err) {
+			return sc.code
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	writeJSON(w, statusCode(err), map[string]string{"Error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
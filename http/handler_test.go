@@ -0,0 +1,97 @@
+package clusterhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ynishi/cluster"
+)
+
+func newTestHandler(t *testing.T) (*Handler, *cluster.DefaultClusterService) {
+	service := cluster.NewDefaultClusterService("0.0.1", &cluster.Image{Name: "testImage"})
+	service.SetOptions(cluster.ContainerOptions{"key": "value"})
+	node, err := service.CreateNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.PrepareNode(node.Id, func(node *cluster.Node) error {
+		node.Client = cluster.NewFakeContainerClient("hash1")
+		node.ResourceProvider = cluster.NewFakeResourceProvider(cluster.ResourceInfo{})
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.RunNode(node); err != nil {
+		t.Fatal(err)
+	}
+	return NewHandler(service), service
+}
+
+func serve(h http.Handler, method, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	return rec
+}
+
+func TestHandler(t *testing.T) {
+	h, service := newTestHandler(t)
+
+	rec := serve(h, http.MethodPost, "/containers")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("want:%v,have:%v,%v", http.StatusCreated, rec.Code, rec.Body)
+	}
+	var created cluster.Container
+	if err := json.NewDecoder(rec.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	if created.Id == "" || created.ContainerStatus.ContainerState != cluster.ContainerCreated {
+		t.Errorf("want:%v,have:%v", cluster.ContainerCreated, created)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		want   int
+	}{
+		{"version", http.MethodGet, "/version", http.StatusOK},
+		{"containers", http.MethodGet, "/containers?all=true", http.StatusOK},
+		{"invalidAll", http.MethodGet, "/containers?all=x", http.StatusBadRequest},
+		{"container", http.MethodGet, "/containers/" + string(created.Id), http.StatusOK},
+		{"containerNotFound", http.MethodGet, "/containers/unknown", http.StatusNotFound},
+		{"run", http.MethodPost, "/containers/" + string(created.Id) + "/run", http.StatusOK},
+		{"alreadyRunning", http.MethodPost, "/containers/" + string(created.Id) + "/run", http.StatusConflict},
+		{"invalidGracePeriod", http.MethodDelete, "/containers/" + string(created.Id) + "?gracePeriod=x", http.StatusBadRequest},
+		{"kill", http.MethodDelete, "/containers/" + string(created.Id) + "?gracePeriod=0", http.StatusOK},
+		{"notRunning", http.MethodDelete, "/containers/" + string(created.Id), http.StatusConflict},
+		{"killNotFound", http.MethodDelete, "/containers/unknown", http.StatusNotFound},
+		{"nodes", http.MethodGet, "/nodes", http.StatusOK},
+		{"createNode", http.MethodPost, "/nodes", http.StatusCreated},
+		{"nodeNotFound", http.MethodGet, "/nodes/unknown", http.StatusNotFound},
+		{"status", http.MethodGet, "/status", http.StatusOK},
+		{"methodNotAllowed", http.MethodPut, "/status", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(h, tt.method, tt.target)
+			if rec.Code != tt.want {
+				t.Errorf("want:%v,have:%v,%v", tt.want, rec.Code, rec.Body)
+			}
+		})
+	}
+
+	nodes, _ := service.Nodes(true)
+	if len(nodes) != 2 {
+		t.Errorf("want:%v,have:%v", 2, len(nodes))
+	}
+	rec = serve(h, http.MethodGet, "/status")
+	var status cluster.ClusterStatus
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+	if status.ClusterState != cluster.ClusterRunning {
+		t.Errorf("want:%v,have:%v", cluster.ClusterRunning, status)
+	}
+}
@@ -0,0 +1,41 @@
+package cluster
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// IDGenerator generates ids of containers and nodes.
+type IDGenerator interface {
+	// returns id unique in the cluster
+	Generate() UID
+}
+
+// UUIDGenerator generates random UUID, the default.
+type UUIDGenerator struct{}
+
+func (UUIDGenerator) Generate() UID {
+	return UID(uuid.New().String())
+}
+
+// SequentialIDGenerator generates ids formatted prefix-N from 1, for deterministic tests.
+type SequentialIDGenerator struct {
+	Prefix string
+
+	mu   sync.Mutex
+	last int
+}
+
+// NewSequentialIDGenerator create generator of ids formatted prefix-N.
+func NewSequentialIDGenerator(prefix string) *SequentialIDGenerator {
+	return &SequentialIDGenerator{Prefix: prefix}
+}
+
+func (sig *SequentialIDGenerator) Generate() UID {
+	sig.mu.Lock()
+	defer sig.mu.Unlock()
+	sig.last++
+	return UID(fmt.Sprintf("%s-%d", sig.Prefix, sig.last))
+}
@@ -0,0 +1,33 @@
+package cluster
+
+import (
+	"testing"
+)
+
+func TestDefaultClusterService_SetIDGenerator(t *testing.T) {
+	clusterService, _ := newTestRestartService(t)
+	clusterService.SetIDGenerator(NewSequentialIDGenerator("id"))
+	container, err := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, err := clusterService.CreateNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		have     UID
+		expected UID
+	}{
+		{"container", container.Id, "id-1"},
+		{"node", node.Id, "id-2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.have != tt.expected {
+				t.Errorf("want:%v,have:%v", tt.expected, tt.have)
+			}
+		})
+	}
+}
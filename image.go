@@ -0,0 +1,132 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ImageExists returns true if image is pulled to node.
+func (dcs *DefaultClusterService) ImageExists(ctx context.Context, node *Node, image *Image) (bool, error) {
+	client, err := dcs.nodeClient(node)
+	if err != nil {
+		return false, err
+	}
+	if _, err := client.ImageId(ctx, image); err != nil {
+		if errors.Is(err, ErrImageNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// PullImage pull image to node, even if it is already pulled to update its tag.
+func (dcs *DefaultClusterService) PullImage(ctx context.Context, node *Node, image *Image) error {
+	client, err := dcs.nodeClient(node)
+	if err != nil {
+		return err
+	}
+	if err := client.PullImage(ctx, image); err != nil {
+		return fmt.Errorf("%w:%v, %v", ErrPullImage, image.FullName, err)
+	}
+	return nil
+}
+
+// ListImages returns images on node.
+func (dcs *DefaultClusterService) ListImages(ctx context.Context, node *Node) ([]*Image, error) {
+	client, err := dcs.nodeClient(node)
+	if err != nil {
+		return nil, err
+	}
+	return client.ListImages(ctx)
+}
+
+// RemoveImage remove image from node, refusing it if created, running or paused container on the node uses it.
+func (dcs *DefaultClusterService) RemoveImage(ctx context.Context, node *Node, image *Image) error {
+	client, err := dcs.nodeClient(node)
+	if err != nil {
+		return err
+	}
+	dcs.mu.RLock()
+	for _, c := range dcs.containersByNode[node.Id] {
+		state := containerStateOf(c)
+		if state == ContainerExited || state == ContainerUnknown {
+			continue
+		}
+		if c.Image != nil && sameImage(c.Image, image) {
+			dcs.mu.RUnlock()
+			return fmt.Errorf("%w:%v by %v", ErrImageInUse, image.FullName, c.Name)
+		}
+	}
+	dcs.mu.RUnlock()
+	return client.RemoveImage(ctx, image)
+}
+
+// sameImage returns true if both refer same image, regardless of default registry, library and latest tag.
+func sameImage(a, b *Image) bool {
+	if a.Digest != "" && b.Digest != "" {
+		return a.Digest == b.Digest
+	}
+	return canonicalImageName(a) == canonicalImageName(b)
+}
+
+func canonicalImageName(image *Image) string {
+	registry := normalizeRegistry(image.Registry)
+	repository := image.Repository
+	if registry == DefaultRegistry && !strings.Contains(repository, "/") {
+		repository = "library/" + repository
+	}
+	tag := image.Tag
+	if tag == "" && image.Digest == "" {
+		tag = "latest"
+	}
+	return registry + "/" + repository + ":" + tag
+}
+
+// parseImages parse references reported by runtime, skipping untagged ones like <none>:<none>.
+func parseImages(references []string) []*Image {
+	images := []*Image{}
+	for _, reference := range references {
+		if strings.Contains(reference, "<none>") {
+			continue
+		}
+		if image, err := NewImage(reference); err == nil {
+			images = append(images, image)
+		}
+	}
+	return images
+}
+
+// nodeClient returns client of node held by cluster, without holding lock while client is called.
+func (dcs *DefaultClusterService) nodeClient(node *Node) (ContainerClient, error) {
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
+	owned := dcs.findNodeById(node.Id)
+	if owned == nil {
+		return nil, fmt.Errorf("%w for uid:%v", ErrNodeNotFound, node.Id)
+	}
+	if owned.Client == nil {
+		return nil, fmt.Errorf("%w:%v", ErrNodeHasNoClient, owned.Name)
+	}
+	return owned.Client, nil
+}
+
+// ensureImage pull image if it is not on node yet, and returns its local id.
+func (n *Node) ensureImage(ctx context.Context, image *Image) (string, error) {
+	if image == nil {
+		return "", nil
+	}
+	imageId, err := n.Client.ImageId(ctx, image)
+	if err == nil {
+		return imageId, nil
+	}
+	if !errors.Is(err, ErrImageNotFound) {
+		return "", err
+	}
+	if err := n.Client.PullImage(ctx, image); err != nil {
+		return "", fmt.Errorf("%w:%v on %v, %v", ErrPullImage, image.FullName, n.Name, err)
+	}
+	return n.Client.ImageId(ctx, image)
+}
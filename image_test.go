@@ -0,0 +1,123 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDefaultClusterService_PullImage(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	client := NewFakeContainerClient("hash1")
+	node := runTestNode(t, clusterService, client)
+
+	ctx := context.Background()
+	if exists, err := clusterService.ImageExists(ctx, node, testImage); err != nil || exists {
+		t.Errorf("want:%v,have:%v,%v", false, exists, err)
+	}
+	if err := clusterService.PullImage(ctx, node, testImage); err != nil {
+		t.Fatal(err)
+	}
+	if exists, err := clusterService.ImageExists(ctx, node, testImage); err != nil || !exists {
+		t.Errorf("want:%v,have:%v,%v", true, exists, err)
+	}
+
+	client.PullErr = errors.New("unauthorized")
+	if err := clusterService.PullImage(ctx, node, testImage); !errors.Is(err, ErrPullImage) {
+		t.Errorf("want:%v,have:%v", ErrPullImage, err)
+	}
+	if _, err := clusterService.ImageExists(ctx, &Node{Id: "unknown"}, testImage); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("want:%v,have:%v", ErrNodeNotFound, err)
+	}
+}
+
+func TestDefaultClusterService_RunContainer_PullImage(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	client := NewFakeContainerClient("hash1")
+	runTestNode(t, clusterService, client)
+
+	client.PullErr = errors.New("unauthorized")
+	failed, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	if err := clusterService.RunContainer(failed); !errors.Is(err, ErrPullImage) {
+		t.Errorf("want:%v,have:%v", ErrPullImage, err)
+	}
+	if failed.ContainerStatus.ContainerState != ContainerCreated || len(client.Runs()) != 0 {
+		t.Errorf("want:%v,have:%v,%v", ContainerCreated, failed.ContainerStatus.ContainerState, client.Runs())
+	}
+
+	// image is pulled only if it is missing
+	client.PullErr = nil
+	for i := 0; i < 2; i++ {
+		container, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+		if err := clusterService.RunContainer(container); err != nil {
+			t.Fatal(err)
+		}
+		if container.ImageId != "sha256:"+testImage.FullName {
+			t.Errorf("want:%v,have:%v", "sha256:"+testImage.FullName, container.ImageId)
+		}
+	}
+	if len(client.Pulls()) != 2 {
+		t.Errorf("want:%v,have:%v", 2, client.Pulls())
+	}
+}
+
+func TestDefaultClusterService_RemoveImage(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	client := NewFakeContainerClient("hash1")
+	node := runTestNode(t, clusterService, client)
+	other, _ := NewImage("docker.io/library/redis:7")
+	client.SetImage(other, "sha256:redis")
+	container, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	images, err := clusterService.ListImages(ctx, node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(images) != 2 || images[0].FullName != other.FullName || images[1].FullName != testImage.FullName {
+		t.Errorf("want:%v,have:%v", []*Image{other, testImage}, images)
+	}
+
+	// same image referred by other name is also in use
+	inUse, _ := NewImage("docker.io/library/" + testImage.FullName)
+	if err := clusterService.RemoveImage(ctx, node, inUse); !errors.Is(err, ErrImageInUse) {
+		t.Errorf("want:%v,have:%v", ErrImageInUse, err)
+	}
+	if err := clusterService.RemoveImage(ctx, node, other); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.RemoveImage(ctx, node, other); !errors.Is(err, ErrImageNotFound) {
+		t.Errorf("want:%v,have:%v", ErrImageNotFound, err)
+	}
+
+	// image of exited container can be removed
+	if err := clusterService.KillContainer(container, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.RemoveImage(ctx, node, testImage); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSameImage(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"nginx", "docker.io/library/nginx:latest", true},
+		{"index.docker.io/library/nginx:1.25", "nginx:1.25", true},
+		{"nginx:1.25", "nginx:1.24", false},
+		{"registry.example.com/app", "app", false},
+		{"app@sha256:abc", "registry.example.com/app@sha256:abc", true},
+	}
+	for _, tt := range tests {
+		a, _ := NewImage(tt.a)
+		b, _ := NewImage(tt.b)
+		if have := sameImage(a, b); have != tt.want {
+			t.Errorf("%v,%v want:%v,have:%v", tt.a, tt.b, tt.want, have)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package cluster
+
+// ClusterInfo is point in time view of cluster, whose numbers are consistent with each other.
+type ClusterInfo struct {
+	ClusterStatus
+	// resource version of cluster when info is taken
+	ResourceVersion uint64
+	// number of nodes in each state
+	Nodes map[NodeState]int
+	// number of containers in each state, including pending ones
+	Containers map[ContainerState]int
+	// sum of capacity of nodes, fields unlimited on a node are not counted
+	Capacity Capacity
+	// sum of resources requested by containers placed on nodes and not exited
+	Allocated Capacity
+}
+
+// ClusterInfo returns nodes and containers counted by state, capacity and status of cluster under one lock.
+func (dcs *DefaultClusterService) ClusterInfo() (*ClusterInfo, error) {
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
+	info := &ClusterInfo{
+		ClusterStatus:   dcs.status(),
+		ResourceVersion: dcs.resourceVersion,
+		Nodes:           make(map[NodeState]int),
+		Containers:      make(map[ContainerState]int),
+	}
+	for _, node := range dcs.nodes {
+		state := node.NodeState
+		if state == "" {
+			state = NodeUnknown
+		}
+		info.Nodes[state]++
+		info.Capacity = info.Capacity.Add(node.Capacity)
+	}
+	for _, c := range dcs.containers {
+		state := containerStateOf(c)
+		info.Containers[state]++
+		// counted from containers as Allocated of nodes is refreshed only on scheduling
+		if state != ContainerExited && dcs.findNodeById(c.NodeId) != nil {
+			info.Allocated = info.Allocated.Add(c.Spec.ResourceRequests)
+		}
+	}
+	return info, nil
+}
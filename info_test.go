@@ -0,0 +1,52 @@
+package cluster
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDefaultClusterService_ClusterInfo(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	prepareTestNode(t, clusterService, func(node *Node) {
+		node.Client = &mockContainerClient{hash: "hash1"}
+		node.Capacity = Capacity{CPUShares: 2048, MemoryMB: 1024}
+	})
+	if _, err := clusterService.CreateNode(); err != nil {
+		t.Fatal(err)
+	}
+	containers := Containers{}
+	for i := 0; i < 2; i++ {
+		container, err := clusterService.CreateContainerWithSpec(ContainerSpec{ResourceRequests: Capacity{MemoryMB: 256}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		containers = append(containers, container)
+	}
+	if err := clusterService.RunContainer(containers[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := clusterService.ClusterInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, _ := clusterService.Status()
+	if info.ClusterStatus != status {
+		t.Errorf("want:%v,have:%v", status, info.ClusterStatus)
+	}
+	if want := map[NodeState]int{NodeRunning: 1, NodeCreated: 1}; !reflect.DeepEqual(info.Nodes, want) {
+		t.Errorf("want:%v,have:%v", want, info.Nodes)
+	}
+	if want := map[ContainerState]int{ContainerRunning: 1, ContainerCreated: 1}; !reflect.DeepEqual(info.Containers, want) {
+		t.Errorf("want:%v,have:%v", want, info.Containers)
+	}
+	if want := (Capacity{CPUShares: 2048, MemoryMB: 1024}); info.Capacity != want {
+		t.Errorf("want:%v,have:%v", want, info.Capacity)
+	}
+	if want := (Capacity{MemoryMB: 512}); info.Allocated != want {
+		t.Errorf("want:%v,have:%v", want, info.Allocated)
+	}
+	if info.ResourceVersion != clusterService.resourceVersion {
+		t.Errorf("want:%v,have:%v", clusterService.resourceVersion, info.ResourceVersion)
+	}
+}
@@ -0,0 +1,75 @@
+package cluster
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Client and ResourceProvider of Node are omitted from JSON by their tags, so Node and Container
+// marshal with default encoding, using MarshalJSON of statuses below.
+
+type containerStatusJSON struct {
+	*containerStatusFields
+	// message of Error, empty if no error
+	Error string `json:",omitempty"`
+}
+
+type containerStatusFields ContainerStatus
+
+// MarshalJSON render Error as its message.
+func (cs ContainerStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(containerStatusJSON{
+		containerStatusFields: (*containerStatusFields)(&cs),
+		Error:                 errorMessage(cs.Error),
+	})
+}
+
+// UnmarshalJSON restore Error from its message, which loses its type.
+func (cs *ContainerStatus) UnmarshalJSON(data []byte) error {
+	decoded := containerStatusJSON{containerStatusFields: (*containerStatusFields)(cs)}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	cs.Error = messageError(decoded.Error)
+	return nil
+}
+
+type nodeStatusJSON struct {
+	*nodeStatusFields
+	// message of Error, empty if no error
+	Error string `json:",omitempty"`
+}
+
+type nodeStatusFields NodeStatus
+
+// MarshalJSON render Error as its message.
+func (ns NodeStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(nodeStatusJSON{
+		nodeStatusFields: (*nodeStatusFields)(&ns),
+		Error:            errorMessage(ns.Error),
+	})
+}
+
+// UnmarshalJSON restore Error from its message, which loses its type.
+func (ns *NodeStatus) UnmarshalJSON(data []byte) error {
+	decoded := nodeStatusJSON{nodeStatusFields: (*nodeStatusFields)(ns)}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	ns.Error = messageError(decoded.Error)
+	return nil
+}
+
+func errorMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func messageError(message string) error {
+	if message == "" {
+		return nil
+	}
+	return errors.New(message)
+}
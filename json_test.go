@@ -0,0 +1,84 @@
+package cluster
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestContainer_JSON(t *testing.T) {
+	container := NewContainer("id1", "name1", "hash1", "nodeId1", "node1", testImage, "imageId1", ContainerOptions{})
+	container.ContainerStatus.ContainerState = ContainerExited
+	container.ContainerStatus.Error = errors.New("exited with code:1")
+
+	data, err := json.Marshal(container)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"Error":"exited with code:1"`) {
+		t.Errorf("want:%v,have:%s", "error message", data)
+	}
+	decoded := &Container{}
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Id != container.Id || decoded.ContainerStatus.ContainerState != ContainerExited {
+		t.Errorf("want:%v,have:%v", container, decoded)
+	}
+	if decoded.ContainerStatus.Error == nil || decoded.ContainerStatus.Error.Error() != "exited with code:1" {
+		t.Errorf("want:%v,have:%v", container.ContainerStatus.Error, decoded.ContainerStatus.Error)
+	}
+}
+
+func TestNode_JSON(t *testing.T) {
+	node := &Node{Id: "id1", Name: "node1", NodeState: NodeRunning, Client: &mockContainerClient{}, ResourceInfo: ResourceInfo{"host": "localhost"}}
+	data, err := json.Marshal(node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "Client") || strings.Contains(string(data), "ResourceProvider") {
+		t.Errorf("want no client,have:%s", data)
+	}
+	decoded := &Node{}
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatal(err)
+	}
+	node.Client = nil
+	if !reflect.DeepEqual(node, decoded) {
+		t.Errorf("want:%v,have:%v", node, decoded)
+	}
+}
+
+func TestNodeStatus_JSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		status NodeStatus
+	}{
+		{"error", NodeStatus{Id: "id1", NodeState: NodeExited, Error: errors.New("failed")}},
+		{"no error", NodeStatus{Id: "id1", NodeState: NodeRunning}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.status)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.status.Error == nil && strings.Contains(string(data), "Error") {
+				t.Errorf("want no error,have:%s", data)
+			}
+			var decoded NodeStatus
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if errorMessage(decoded.Error) != errorMessage(tt.status.Error) {
+				t.Errorf("want:%v,have:%v", tt.status.Error, decoded.Error)
+			}
+			decoded.Error = tt.status.Error
+			if !reflect.DeepEqual(tt.status, decoded) {
+				t.Errorf("want:%v,have:%v", tt.status, decoded)
+			}
+		})
+	}
+}
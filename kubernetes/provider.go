@@ -0,0 +1,159 @@
+package clusterkubernetes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ynishi/cluster"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// keys of ResourceInfo set by RunNode.
+const (
+	ResourcePod       = cluster.ResourcePod
+	ResourceNamespace = cluster.ResourceNamespace
+	// ip of pod, same key as other providers
+	ResourceIP = cluster.ResourcePrivateIP
+)
+
+// label set on pod to find node.
+const nodeIdLabel = "cluster.ynishi.github.io/node-id"
+
+// ResourceProvider run node as pod of kubernetes.
+type ResourceProvider struct {
+	client    kubernetes.Interface
+	namespace string
+	spec      corev1.PodSpec
+	// wait for pod running until timeout in RunNode
+	Timeout time.Duration
+	// interval to get pod while waiting
+	PollInterval time.Duration
+}
+
+var _ cluster.ResourceProvider = (*ResourceProvider)(nil)
+var _ cluster.NodeProber = (*ResourceProvider)(nil)
+
+// NewResourceProvider create provider running pod of spec in namespace for each node.
+func NewResourceProvider(client kubernetes.Interface, namespace string, spec corev1.PodSpec) *ResourceProvider {
+	return &ResourceProvider{
+		client:       client,
+		namespace:    namespace,
+		spec:         spec,
+		Timeout:      5 * time.Minute,
+		PollInterval: time.Second,
+	}
+}
+
+// RunNode create pod named by node name, and wait for it running with ip.
+func (rp *ResourceProvider) RunNode(node *cluster.Node) (*cluster.ResourceInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), rp.Timeout)
+	defer cancel()
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      node.Name,
+			Namespace: rp.namespace,
+			Labels:    map[string]string{nodeIdLabel: string(node.Id)},
+		},
+		Spec: *rp.spec.DeepCopy(),
+	}
+	if _, err := rp.client.CoreV1().Pods(rp.namespace).Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+		return nil, err
+	}
+	ticker := time.NewTicker(rp.PollInterval)
+	defer ticker.Stop()
+	for {
+		created, err := rp.client.CoreV1().Pods(rp.namespace).Get(ctx, node.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case created.Status.Phase == corev1.PodRunning && created.Status.PodIP != "":
+			resourceInfo := cluster.ResourceInfo{}
+			resourceInfo.SetPod(created.Name)
+			resourceInfo.SetNamespace(created.Namespace)
+			resourceInfo.SetPrivateIP(created.Status.PodIP)
+			return &resourceInfo, nil
+		case created.Status.Phase == corev1.PodSucceeded || created.Status.Phase == corev1.PodFailed:
+			return nil, fmt.Errorf("pod:%v %v:%v", created.Name, created.Status.Phase, created.Status.Reason)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// StopNode delete pod of node with its default grace period.
+func (rp *ResourceProvider) StopNode(node *cluster.Node) error {
+	return rp.deletePod(node, nil)
+}
+
+// RemoveNode delete pod immediately, it is no-op if the pod is already deleted by StopNode.
+func (rp *ResourceProvider) RemoveNode(node *cluster.Node) error {
+	gracePeriod := int64(0)
+	return rp.deletePod(node, &gracePeriod)
+}
+
+func (rp *ResourceProvider) deletePod(node *cluster.Node, gracePeriodSeconds *int64) error {
+	err := rp.client.CoreV1().Pods(rp.namespace).Delete(context.Background(), node.Name, metav1.DeleteOptions{GracePeriodSeconds: gracePeriodSeconds})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// NodeStatus returns status of node derived from its pod, node is exited if the pod is not found.
+func (rp *ResourceProvider) NodeStatus(node *cluster.Node) (cluster.NodeStatus, error) {
+	status := cluster.NodeStatus{Id: node.Id, Name: node.Name, Namespace: rp.namespace}
+	pod, err := rp.client.CoreV1().Pods(rp.namespace).Get(context.Background(), node.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		status.NodeState = cluster.NodeExited
+		status.Reason = "pod not found"
+		return status, nil
+	}
+	if err != nil {
+		return cluster.NodeStatus{}, err
+	}
+	status.NodeState = NodeState(pod.Status.Phase)
+	status.CreatedAt = pod.CreationTimestamp.Time
+	if pod.Status.StartTime != nil {
+		status.StartedAt = pod.Status.StartTime.Time
+	}
+	status.Reason = string(pod.Status.Phase)
+	status.Message = pod.Status.Message
+	if pod.Status.Phase == corev1.PodFailed {
+		status.Error = fmt.Errorf("pod failed:%v", pod.Status.Reason)
+	}
+	return status, nil
+}
+
+// ProbeNode returns error unless pod of node is running.
+func (rp *ResourceProvider) ProbeNode(node *cluster.Node) error {
+	status, err := rp.NodeStatus(node)
+	if err != nil {
+		return err
+	}
+	if status.NodeState != cluster.NodeRunning {
+		return fmt.Errorf("pod:%v %v", node.Name, status.Reason)
+	}
+	return nil
+}
+
+// NodeState returns state of node running as pod in phase.
+func NodeState(phase corev1.PodPhase) cluster.NodeState {
+	switch phase {
+	case corev1.PodPending:
+		return cluster.NodeCreated
+	case corev1.PodRunning:
+		return cluster.NodeRunning
+	case corev1.PodSucceeded, corev1.PodFailed:
+		return cluster.NodeExited
+	default:
+		return cluster.NodeUnknown
+	}
+}
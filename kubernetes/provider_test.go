@@ -0,0 +1,121 @@
+package clusterkubernetes
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ynishi/cluster"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newTestProvider returns provider whose pods are created in phase with ip.
+func newTestProvider(phase corev1.PodPhase) (*ResourceProvider, *fake.Clientset) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		pod := action.(k8stesting.CreateAction).GetObject().(*corev1.Pod)
+		pod.Status.Phase = phase
+		pod.Status.PodIP = "10.0.0.1"
+		return false, nil, nil
+	})
+	provider := NewResourceProvider(client, "cluster", corev1.PodSpec{
+		Containers: []corev1.Container{corev1.Container{Name: "node", Image: "docker:dind"}},
+	})
+	provider.Timeout = time.Second
+	provider.PollInterval = 10 * time.Millisecond
+	return provider, client
+}
+
+func TestResourceProvider(t *testing.T) {
+	provider, client := newTestProvider(corev1.PodRunning)
+	clusterService := cluster.NewDefaultClusterService("0.0.1", &cluster.Image{Name: "testImage"})
+	node, err := clusterService.CreateNamedNode("node1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := clusterService.PrepareNode(node.Id, func(node *cluster.Node) error {
+		node.ResourceProvider = provider
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := clusterService.RunNode(node); err != nil {
+		t.Fatal(err)
+	}
+	expected := cluster.ResourceInfo{ResourcePod: "node1", ResourceNamespace: "cluster", ResourceIP: "10.0.0.1"}
+	if !reflect.DeepEqual(expected, node.ResourceInfo) {
+		t.Errorf("want:%v,have:%v", expected, node.ResourceInfo)
+	}
+	pod, err := client.CoreV1().Pods("cluster").Get(context.Background(), "node1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pod.Labels[nodeIdLabel] != string(node.Id) || pod.Spec.Containers[0].Image != "docker:dind" {
+		t.Errorf("want:%v,have:%v", node.Id, pod)
+	}
+	if status, err := provider.NodeStatus(node); err != nil || status.NodeState != cluster.NodeRunning {
+		t.Errorf("want:%v,have:%v,%v", cluster.NodeRunning, status, err)
+	}
+	if err := provider.ProbeNode(node); err != nil {
+		t.Errorf("want:%v,have:%v", nil, err)
+	}
+
+	if err := clusterService.KillNode(*node, 1000); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CoreV1().Pods("cluster").Get(context.Background(), "node1", metav1.GetOptions{}); err == nil {
+		t.Error("want pod deleted")
+	}
+	if err := provider.RemoveNode(node); err != nil {
+		t.Errorf("want:%v,have:%v", nil, err)
+	}
+	if status, err := provider.NodeStatus(node); err != nil || status.NodeState != cluster.NodeExited {
+		t.Errorf("want:%v,have:%v,%v", cluster.NodeExited, status, err)
+	}
+	if err := provider.ProbeNode(node); err == nil {
+		t.Error("want error for deleted pod")
+	}
+}
+
+func TestResourceProvider_RunNodeFailed(t *testing.T) {
+	tests := []struct {
+		name  string
+		phase corev1.PodPhase
+	}{
+		{"failed", corev1.PodFailed},
+		{"timeout", corev1.PodPending},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, _ := newTestProvider(tt.phase)
+			provider.Timeout = 100 * time.Millisecond
+			if _, err := provider.RunNode(&cluster.Node{Id: "id1", Name: "node1"}); err == nil {
+				t.Error("want error")
+			}
+		})
+	}
+}
+
+func TestNodeState(t *testing.T) {
+	tests := []struct {
+		phase corev1.PodPhase
+		want  cluster.NodeState
+	}{
+		{corev1.PodPending, cluster.NodeCreated},
+		{corev1.PodRunning, cluster.NodeRunning},
+		{corev1.PodSucceeded, cluster.NodeExited},
+		{corev1.PodFailed, cluster.NodeExited},
+		{corev1.PodUnknown, cluster.NodeUnknown},
+	}
+	for _, tt := range tests {
+		if have := NodeState(tt.phase); have != tt.want {
+			t.Errorf("want:%v,have:%v", tt.want, have)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package cluster
+
+// SelectContainers returns containers whose labels include all of selector. empty selector selects all.
+func (dcs *DefaultClusterService) SelectContainers(selector map[string]string) Containers {
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
+	res := Containers{}
+	for _, c := range dcs.containers {
+		if matchLabels(c.Labels, selector) {
+			res = append(res, c.Clone())
+		}
+	}
+	return res
+}
+
+// SelectNodes returns nodes whose labels include all of selector. empty selector selects all.
+func (dcs *DefaultClusterService) SelectNodes(selector map[string]string) Nodes {
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
+	res := Nodes{}
+	for _, node := range dcs.nodes {
+		if matchLabels(node.Labels, selector) {
+			res = append(res, node.Clone())
+		}
+	}
+	return res
+}
+
+// matchLabels returns true if labels is a superset of selector.
+func matchLabels(labels map[string]string, selector map[string]string) bool {
+	for key, value := range selector {
+		if v, ok := labels[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,49 @@
+package cluster
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDefaultClusterService_SelectContainers(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	runTestNode(t, clusterService, nil)
+	web, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	labelTestContainer(t, clusterService, web, map[string]string{"app": "web", "tier": "front"})
+	db, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	labelTestContainer(t, clusterService, db, map[string]string{"app": "db"})
+	clusterService.CreateContainerWithSpec(ContainerSpec{})
+
+	if res := clusterService.SelectContainers(map[string]string{"app": "web"}); !reflect.DeepEqual(Containers{web}, res) {
+		t.Errorf("%v", res)
+	}
+	if res := clusterService.SelectContainers(map[string]string{"app": "web", "tier": "back"}); len(res) != 0 {
+		t.Errorf("%v", res)
+	}
+	if res := clusterService.SelectContainers(map[string]string{"app": ""}); len(res) != 0 {
+		t.Errorf("%v", res)
+	}
+	if res := clusterService.SelectContainers(nil); len(res) != 3 {
+		t.Errorf("%v", res)
+	}
+}
+
+func TestDefaultClusterService_SelectNodes(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	gpu, _ := clusterService.CreateNode()
+	gpu, err := clusterService.UpdateNodeIf(gpu.Id, gpu.ResourceVersion, func(node *Node) error {
+		node.Labels = map[string]string{"gpu": "true"}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clusterService.CreateNode()
+
+	if res := clusterService.SelectNodes(map[string]string{"gpu": "true"}); !reflect.DeepEqual(Nodes{gpu}, res) {
+		t.Errorf("%v", res)
+	}
+	if res := clusterService.SelectNodes(map[string]string{}); len(res) != 2 {
+		t.Errorf("%v", res)
+	}
+}
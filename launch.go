@@ -0,0 +1,44 @@
+package cluster
+
+import (
+	"context"
+	"sync"
+)
+
+// launchLimits throttles Run calls to runtime of each node by its MaxConcurrentLaunches,
+// shared by single and batch operations.
+type launchLimits struct {
+	mu    sync.Mutex
+	slots map[UID]chan struct{}
+}
+
+// acquire wait a launch slot of node with limit, and returns func to release it. limit under 1 is not throttled.
+// slots are renewed if limit is changed, launches holding old slots are not counted.
+func (ll *launchLimits) acquire(ctx context.Context, nodeId UID, limit int) (func(), error) {
+	if limit < 1 {
+		return func() {}, nil
+	}
+	ll.mu.Lock()
+	slot := ll.slots[nodeId]
+	if slot == nil || cap(slot) != limit {
+		if ll.slots == nil {
+			ll.slots = make(map[UID]chan struct{})
+		}
+		slot = make(chan struct{}, limit)
+		ll.slots[nodeId] = slot
+	}
+	ll.mu.Unlock()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case slot <- struct{}{}:
+		return func() { <-slot }, nil
+	}
+}
+
+// forget drop slots of removed node.
+func (ll *launchLimits) forget(nodeId UID) {
+	ll.mu.Lock()
+	defer ll.mu.Unlock()
+	delete(ll.slots, nodeId)
+}
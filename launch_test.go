@@ -0,0 +1,76 @@
+package cluster
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// concurrencyClient records max number of concurrent Run calls.
+type concurrencyClient struct {
+	*InMemoryContainerClient
+
+	mu      sync.Mutex
+	running int
+	max     int
+}
+
+func (cc *concurrencyClient) Run(ctx context.Context, container *Container) (string, error) {
+	cc.mu.Lock()
+	cc.running++
+	if cc.running > cc.max {
+		cc.max = cc.running
+	}
+	cc.mu.Unlock()
+	defer func() {
+		cc.mu.Lock()
+		cc.running--
+		cc.mu.Unlock()
+	}()
+	return cc.InMemoryContainerClient.Run(ctx, container)
+}
+
+func TestDefaultClusterService_RunContainers_MaxConcurrentLaunches(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	clusterService.SetMaxInFlight(8)
+	client := &concurrencyClient{InMemoryContainerClient: NewInMemoryContainerClient(20 * time.Millisecond)}
+	node := prepareTestNode(t, clusterService, func(node *Node) {
+		node.Client = client
+		node.MaxConcurrentLaunches = 2
+	})
+	containers := Containers{}
+	for i := 0; i < 7; i++ {
+		container, err := clusterService.CreateContainerWithSpec(ContainerSpec{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		containers = append(containers, container)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if _, err := clusterService.RunContainers(containers[:6]); err != nil {
+			t.Error(err)
+		}
+	}()
+	// single launch shares slots with batch
+	go func() {
+		defer wg.Done()
+		if err := clusterService.RunContainer(containers[6]); err != nil {
+			t.Error(err)
+		}
+	}()
+	wg.Wait()
+
+	if client.max != node.MaxConcurrentLaunches {
+		t.Errorf("want:%v,have:%v", node.MaxConcurrentLaunches, client.max)
+	}
+	for _, c := range containers {
+		if c.ContainerStatus.ContainerState != ContainerRunning {
+			t.Errorf("want:%v,have:%v", ContainerRunning, c.ContainerStatus.ContainerState)
+		}
+	}
+}
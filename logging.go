@@ -0,0 +1,132 @@
+package cluster
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// LoggingClusterService is ClusterService logging each call by slog, delegating it to wrapped service.
+// entry is logged in debug level with arguments, exit in info level with duration, or error level with error.
+type LoggingClusterService struct {
+	service ClusterService
+	logger  *slog.Logger
+}
+
+var _ ClusterService = (*LoggingClusterService)(nil)
+
+// NewLoggingClusterService wraps service to log calls to logger, slog.Default() if nil.
+func NewLoggingClusterService(service ClusterService, logger *slog.Logger) *LoggingClusterService {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &LoggingClusterService{service: service, logger: logger}
+}
+
+// call log entry of method with args, and returns func to log exit with error set by then.
+func (lcs *LoggingClusterService) call(method string, args ...any) func(err *error) {
+	attrs := append([]any{"method", method}, args...)
+	lcs.logger.Debug("call", attrs...)
+	start := time.Now()
+	return func(err *error) {
+		attrs := append(attrs, "duration", time.Since(start))
+		if *err != nil {
+			lcs.logger.Error("failed", append(attrs, "error", *err)...)
+			return
+		}
+		lcs.logger.Info("done", attrs...)
+	}
+}
+
+func (lcs *LoggingClusterService) Version() (version Version, err error) {
+	defer lcs.call("Version")(&err)
+	return lcs.service.Version()
+}
+
+func (lcs *LoggingClusterService) Image() (image *Image, err error) {
+	defer lcs.call("Image")(&err)
+	return lcs.service.Image()
+}
+
+func (lcs *LoggingClusterService) Options() (options ContainerOptions, err error) {
+	defer lcs.call("Options")(&err)
+	return lcs.service.Options()
+}
+
+func (lcs *LoggingClusterService) Containers(all bool) (containers Containers, err error) {
+	defer lcs.call("Containers", "all", all)(&err)
+	return lcs.service.Containers(all)
+}
+
+func (lcs *LoggingClusterService) ContainerStatus(uid UID, name string, nodeName string) (status *ContainerStatus, err error) {
+	defer lcs.call("ContainerStatus", "uid", uid, "name", name, "nodeName", nodeName)(&err)
+	return lcs.service.ContainerStatus(uid, name, nodeName)
+}
+
+func (lcs *LoggingClusterService) CreateContainer() (container *Container, err error) {
+	defer lcs.call("CreateContainer")(&err)
+	container, err = lcs.service.CreateContainer()
+	if err == nil {
+		lcs.logger.Debug("created", "method", "CreateContainer", "uid", container.Id, "name", container.Name)
+	}
+	return container, err
+}
+
+func (lcs *LoggingClusterService) RunContainer(container *Container) (err error) {
+	defer lcs.call("RunContainer", "uid", container.Id, "name", container.Name)(&err)
+	return lcs.service.RunContainer(container)
+}
+
+func (lcs *LoggingClusterService) KillContainer(runningContainer *Container, gracePeriod time.Duration) (err error) {
+	defer lcs.call("KillContainer", "uid", runningContainer.Id, "name", runningContainer.Name, "gracePeriod", gracePeriod)(&err)
+	return lcs.service.KillContainer(runningContainer, gracePeriod)
+}
+
+func (lcs *LoggingClusterService) Nodes(all bool) (nodes []*Node, err error) {
+	defer lcs.call("Nodes", "all", all)(&err)
+	return lcs.service.Nodes(all)
+}
+
+func (lcs *LoggingClusterService) CreateNode() (node *Node, err error) {
+	defer lcs.call("CreateNode")(&err)
+	node, err = lcs.service.CreateNode()
+	if err == nil {
+		lcs.logger.Debug("created", "method", "CreateNode", "uid", node.Id, "name", node.Name)
+	}
+	return node, err
+}
+
+func (lcs *LoggingClusterService) RunNode(node *Node) (err error) {
+	defer lcs.call("RunNode", "uid", node.Id, "name", node.Name)(&err)
+	return lcs.service.RunNode(node)
+}
+
+func (lcs *LoggingClusterService) KillNode(runningNode Node, gracePeriod int) (err error) {
+	defer lcs.call("KillNode", "uid", runningNode.Id, "name", runningNode.Name, "gracePeriod", gracePeriod)(&err)
+	return lcs.service.KillNode(runningNode, gracePeriod)
+}
+
+func (lcs *LoggingClusterService) Status() (status ClusterStatus, err error) {
+	defer lcs.call("Status")(&err)
+	return lcs.service.Status()
+}
+
+func (lcs *LoggingClusterService) NodeStatus(uid UID, name string) (status NodeStatus, err error) {
+	defer lcs.call("NodeStatus", "uid", uid, "name", name)(&err)
+	return lcs.service.NodeStatus(uid, name)
+}
+
+func (lcs *LoggingClusterService) FlushNodes() (err error) {
+	defer lcs.call("FlushNodes")(&err)
+	return lcs.service.FlushNodes()
+}
+
+func (lcs *LoggingClusterService) FlushContainers() (err error) {
+	defer lcs.call("FlushContainers")(&err)
+	return lcs.service.FlushContainers()
+}
+
+func (lcs *LoggingClusterService) Shutdown(ctx context.Context) (err error) {
+	defer lcs.call("Shutdown")(&err)
+	return lcs.service.Shutdown(ctx)
+}
@@ -0,0 +1,49 @@
+package cluster
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoggingClusterService(t *testing.T) {
+	clusterService, _ := newTestRestartService(t)
+	clusterService.SetOptions(ContainerOptions{})
+	var buf bytes.Buffer
+	service := NewLoggingClusterService(clusterService, slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	container, err := service.CreateContainer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	service.KillContainer(container, time.Second)
+	// fails as already exited
+	service.KillContainer(container, time.Second)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	tests := []struct {
+		name     string
+		line     int
+		expected []string
+	}{
+		{"entry", 0, []string{"level=DEBUG", "msg=call", "method=CreateContainer"}},
+		{"created", 1, []string{"msg=created", "uid=" + string(container.Id)}},
+		{"exit", 2, []string{"level=INFO", "msg=done", "method=CreateContainer", "duration="}},
+		{"args", 3, []string{"method=RunContainer", "uid=" + string(container.Id), "name=" + container.Name}},
+		{"error", len(lines) - 1, []string{"level=ERROR", "msg=failed", "method=KillContainer", "error="}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, expected := range tt.expected {
+				if !strings.Contains(lines[tt.line], expected) {
+					t.Errorf("want:%v,have:%v", expected, lines[tt.line])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package cluster
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// LogsOptions selects logs to stream, same for all runtimes.
+type LogsOptions struct {
+	// keep streaming new logs until closed
+	Follow bool
+	// number of lines from the end, 0 means all
+	Tail int
+	// only logs after it, zero means from the beginning
+	Since time.Time
+	// prefix each line with its time
+	Timestamps bool
+}
+
+// tail returns Tail formatted for runtime CLI and API.
+func (options LogsOptions) tail() string {
+	if options.Tail <= 0 {
+		return "all"
+	}
+	return strconv.Itoa(options.Tail)
+}
+
+// since returns Since as unix timestamp with nanoseconds accepted by runtimes, empty if zero.
+func (options LogsOptions) since() string {
+	if options.Since.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%d.%09d", options.Since.Unix(), options.Since.Nanosecond())
+}
@@ -0,0 +1,408 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+func init() {
+	RegisterContainerRuntime(RuntimeInMemory, func(endpoint string) (ContainerClient, error) {
+		return NewInMemoryContainerClient(0), nil
+	})
+}
+
+// InMemoryRuntimeVersion is version reported by InMemoryContainerClient.
+const InMemoryRuntimeVersion Version = "memory"
+
+// InMemoryContainerClient is ContainerClient simulating runtime in memory, to run cluster without runtime
+// for demo and test. Containers are tracked by their hash, images are pulled instantly.
+// Error of each operation can be injected by InjectError.
+type InMemoryContainerClient struct {
+	// time Run takes until container is running
+	runDelay time.Duration
+
+	mu         sync.Mutex
+	containers map[string]*memoryContainer
+	// local ids of images by full name
+	images map[string]string
+	// errors returned by operations by their method name
+	errors  map[string]error
+	lastSeq int
+}
+
+// memoryContainer is container tracked by InMemoryContainerClient.
+type memoryContainer struct {
+	status *ContainerStatus
+	logs   []memoryLog
+}
+
+type memoryLog struct {
+	time time.Time
+	line string
+}
+
+var _ ContainerClient = (*InMemoryContainerClient)(nil)
+
+// NewInMemoryContainerClient create client whose Run takes runDelay.
+func NewInMemoryContainerClient(runDelay time.Duration) *InMemoryContainerClient {
+	return &InMemoryContainerClient{
+		runDelay:   runDelay,
+		containers: make(map[string]*memoryContainer),
+		images:     make(map[string]string),
+		errors:     make(map[string]error),
+	}
+}
+
+// InjectError make operation, name of method like Run, return err until it is cleared by nil.
+func (imc *InMemoryContainerClient) InjectError(operation string, err error) {
+	imc.mu.Lock()
+	defer imc.mu.Unlock()
+	if err == nil {
+		delete(imc.errors, operation)
+		return
+	}
+	imc.errors[operation] = err
+}
+
+// injected returns error injected to operation, caller must hold mu.
+func (imc *InMemoryContainerClient) injected(operation string) error {
+	return imc.errors[operation]
+}
+
+// Run wait runDelay or until ctx is done, then start container with new hash.
+func (imc *InMemoryContainerClient) Run(ctx context.Context, container *Container) (string, error) {
+	if imc.runDelay > 0 {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(imc.runDelay):
+		}
+	}
+	imc.mu.Lock()
+	defer imc.mu.Unlock()
+	if err := imc.injected("Run"); err != nil {
+		return "", err
+	}
+	imc.lastSeq++
+	hash := fmt.Sprintf("memory-%d", imc.lastSeq)
+	now := time.Now()
+	status := NewContainerStatus(container.Id, container.Name, container.NodeName)
+	status.Namespace = container.Namespace
+	status.ContainerState = ContainerRunning
+	status.CreatedAt = now
+	status.StartedAt = now
+	status.Ports = clonePorts(container.Spec.Ports)
+	status.Volumes = cloneVolumes(container.Spec.Volumes)
+	imc.containers[hash] = &memoryContainer{
+		status: status,
+		logs:   []memoryLog{{time: now, line: "started " + container.Image.FullName}},
+	}
+	return hash, nil
+}
+
+// find returns container of hash, caller must hold mu.
+func (imc *InMemoryContainerClient) find(container *Container) (*memoryContainer, error) {
+	mc, ok := imc.containers[container.Hash]
+	if !ok {
+		return nil, fmt.Errorf("%w for hash:%v", ErrContainerNotFound, container.Hash)
+	}
+	return mc, nil
+}
+
+// Stop exit container immediately with code 0, or 137 as killed if gracePeriod is 0.
+func (imc *InMemoryContainerClient) Stop(ctx context.Context, container *Container, gracePeriod time.Duration) error {
+	imc.mu.Lock()
+	defer imc.mu.Unlock()
+	if err := imc.injected("Stop"); err != nil {
+		return err
+	}
+	mc, err := imc.find(container)
+	if err != nil {
+		return err
+	}
+	if mc.status.ContainerState == ContainerExited {
+		return nil
+	}
+	exitCode := 0
+	if gracePeriod == 0 {
+		exitCode = 137
+	}
+	imc.exit(mc, exitCode)
+	return nil
+}
+
+// ExitContainer make container exit with exitCode after delay, unless it is exited or removed by then.
+func (imc *InMemoryContainerClient) ExitContainer(container *Container, exitCode int, after time.Duration) {
+	hash := container.Hash
+	exit := func() {
+		imc.mu.Lock()
+		defer imc.mu.Unlock()
+		if mc, ok := imc.containers[hash]; ok && mc.status.ContainerState != ContainerExited {
+			imc.exit(mc, exitCode)
+		}
+	}
+	if after == 0 {
+		exit()
+		return
+	}
+	time.AfterFunc(after, exit)
+}
+
+// exit make container exited with exitCode, caller must hold mu.
+func (imc *InMemoryContainerClient) exit(mc *memoryContainer, exitCode int) {
+	now := time.Now()
+	mc.status.ContainerState = ContainerExited
+	mc.status.FinishedAt = now
+	mc.status.ExitCode = exitCode
+	if exitCode != 0 {
+		mc.status.Error = fmt.Errorf("exited with code:%d", exitCode)
+	}
+	mc.logs = append(mc.logs, memoryLog{time: now, line: fmt.Sprintf("exited with code %d", exitCode)})
+}
+
+func (imc *InMemoryContainerClient) Pause(ctx context.Context, container *Container) error {
+	return imc.transition("Pause", container, ContainerRunning, ContainerPaused)
+}
+
+func (imc *InMemoryContainerClient) Unpause(ctx context.Context, container *Container) error {
+	return imc.transition("Unpause", container, ContainerPaused, ContainerRunning)
+}
+
+func (imc *InMemoryContainerClient) transition(operation string, container *Container, from, to ContainerState) error {
+	imc.mu.Lock()
+	defer imc.mu.Unlock()
+	if err := imc.injected(operation); err != nil {
+		return err
+	}
+	mc, err := imc.find(container)
+	if err != nil {
+		return err
+	}
+	if mc.status.ContainerState != from {
+		return fmt.Errorf("%w, container:%v is %v", ErrIllegalTransition, container.Name, mc.status.ContainerState)
+	}
+	mc.status.ContainerState = to
+	return nil
+}
+
+func (imc *InMemoryContainerClient) ImageId(ctx context.Context, image *Image) (string, error) {
+	imc.mu.Lock()
+	defer imc.mu.Unlock()
+	if err := imc.injected("ImageId"); err != nil {
+		return "", err
+	}
+	imageId, ok := imc.images[image.FullName]
+	if !ok {
+		return "", fmt.Errorf("%w:%v", ErrImageNotFound, image.FullName)
+	}
+	return imageId, nil
+}
+
+// PullImage make image pulled with id formatted sha256:FullName.
+func (imc *InMemoryContainerClient) PullImage(ctx context.Context, image *Image) error {
+	imc.mu.Lock()
+	defer imc.mu.Unlock()
+	if err := imc.injected("PullImage"); err != nil {
+		return err
+	}
+	imc.images[image.FullName] = "sha256:" + image.FullName
+	return nil
+}
+
+func (imc *InMemoryContainerClient) ListImages(ctx context.Context) ([]*Image, error) {
+	imc.mu.Lock()
+	defer imc.mu.Unlock()
+	if err := imc.injected("ListImages"); err != nil {
+		return nil, err
+	}
+	references := []string{}
+	for fullName := range imc.images {
+		references = append(references, fullName)
+	}
+	sort.Strings(references)
+	return parseImages(references), nil
+}
+
+func (imc *InMemoryContainerClient) RemoveImage(ctx context.Context, image *Image) error {
+	imc.mu.Lock()
+	defer imc.mu.Unlock()
+	if err := imc.injected("RemoveImage"); err != nil {
+		return err
+	}
+	if _, ok := imc.images[image.FullName]; !ok {
+		return fmt.Errorf("%w:%v", ErrImageNotFound, image.FullName)
+	}
+	delete(imc.images, image.FullName)
+	return nil
+}
+
+// Inspect returns tracked status of container.
+func (imc *InMemoryContainerClient) Inspect(ctx context.Context, container *Container) (*ContainerStatus, error) {
+	imc.mu.Lock()
+	defer imc.mu.Unlock()
+	if err := imc.injected("Inspect"); err != nil {
+		return nil, err
+	}
+	mc, err := imc.find(container)
+	if err != nil {
+		return nil, err
+	}
+	status := mc.status.Clone()
+	status.Reason = "inspected by InMemoryContainerClient"
+	return status, nil
+}
+
+// Remove forget not running container.
+func (imc *InMemoryContainerClient) Remove(ctx context.Context, container *Container) error {
+	imc.mu.Lock()
+	defer imc.mu.Unlock()
+	if err := imc.injected("Remove"); err != nil {
+		return err
+	}
+	mc, err := imc.find(container)
+	if err != nil {
+		return err
+	}
+	if state := mc.status.ContainerState; state == ContainerRunning || state == ContainerPaused {
+		return fmt.Errorf("%w:%v", ErrStillRunning, container.Name)
+	}
+	delete(imc.containers, container.Hash)
+	return nil
+}
+
+// Logs returns lifecycle events of container as log lines. Follow is not supported, it returns lines so far.
+func (imc *InMemoryContainerClient) Logs(ctx context.Context, container *Container, options LogsOptions) (io.ReadCloser, error) {
+	imc.mu.Lock()
+	defer imc.mu.Unlock()
+	if err := imc.injected("Logs"); err != nil {
+		return nil, err
+	}
+	mc, err := imc.find(container)
+	if err != nil {
+		return nil, err
+	}
+	logs := []memoryLog{}
+	for _, log := range mc.logs {
+		if log.time.Before(options.Since) {
+			continue
+		}
+		logs = append(logs, log)
+	}
+	if options.Tail > 0 && len(logs) > options.Tail {
+		logs = logs[len(logs)-options.Tail:]
+	}
+	var buf bytes.Buffer
+	for _, log := range logs {
+		if options.Timestamps {
+			buf.WriteString(log.time.Format(time.RFC3339Nano) + " ")
+		}
+		buf.WriteString(log.line + "\n")
+	}
+	return io.NopCloser(&buf), nil
+}
+
+// Exec simulates true and false by exit code, other commands print themselves joined by space.
+func (imc *InMemoryContainerClient) Exec(ctx context.Context, container *Container, cmd []string) (string, string, int, error) {
+	imc.mu.Lock()
+	defer imc.mu.Unlock()
+	if err := imc.injected("Exec"); err != nil {
+		return "", "", 0, err
+	}
+	mc, err := imc.find(container)
+	if err != nil {
+		return "", "", 0, err
+	}
+	if mc.status.ContainerState != ContainerRunning {
+		return "", "", 0, fmt.Errorf("%w:%v", ErrNotRunning, container.Name)
+	}
+	switch cmd[0] {
+	case "true":
+		return "", "", 0, nil
+	case "false":
+		return "", "", 1, nil
+	default:
+		return strings.Join(cmd, " ") + "\n", "", 0, nil
+	}
+}
+
+// Stats returns zero usage of running container, limited by its resource limits.
+func (imc *InMemoryContainerClient) Stats(ctx context.Context, container *Container) (*ContainerStats, error) {
+	imc.mu.Lock()
+	defer imc.mu.Unlock()
+	if err := imc.injected("Stats"); err != nil {
+		return nil, err
+	}
+	if _, err := imc.find(container); err != nil {
+		return nil, err
+	}
+	return &ContainerStats{MemoryLimitBytes: container.Spec.ResourceLimits.MemoryMB << 20}, nil
+}
+
+// Update change resource limits of alive container, they are not enforced.
+func (imc *InMemoryContainerClient) Update(ctx context.Context, container *Container, limits Capacity) error {
+	imc.mu.Lock()
+	defer imc.mu.Unlock()
+	if err := imc.injected("Update"); err != nil {
+		return err
+	}
+	mc, err := imc.find(container)
+	if err != nil {
+		return err
+	}
+	if mc.status.ContainerState == ContainerExited {
+		return fmt.Errorf("%w:%v", ErrNotRunning, container.Name)
+	}
+	return nil
+}
+
+// RuntimeVersion returns InMemoryRuntimeVersion unless error is injected.
+func (imc *InMemoryContainerClient) RuntimeVersion(ctx context.Context) (Version, error) {
+	imc.mu.Lock()
+	defer imc.mu.Unlock()
+	if err := imc.injected("RuntimeVersion"); err != nil {
+		return "", err
+	}
+	return InMemoryRuntimeVersion, nil
+}
+
+// Attach echo stdin into stdout as main process of container, until stdin is closed or ctx is done.
+func (imc *InMemoryContainerClient) Attach(ctx context.Context, container *Container, stdin io.Reader, stdout, stderr io.Writer) error {
+	imc.mu.Lock()
+	if err := imc.injected("Attach"); err != nil {
+		imc.mu.Unlock()
+		return err
+	}
+	mc, err := imc.find(container)
+	if err == nil && mc.status.ContainerState != ContainerRunning {
+		err = fmt.Errorf("%w:%v", ErrNotRunning, container.Name)
+	}
+	imc.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return echo(ctx, stdin, stdout)
+}
+
+// echo copy stdin into stdout until stdin is closed or ctx is done, nil stdin returns immediately.
+func echo(ctx context.Context, stdin io.Reader, stdout io.Writer) error {
+	if stdin == nil {
+		return nil
+	}
+	copied := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(stdout, stdin)
+		copied <- err
+	}()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-copied:
+		return err
+	}
+}
@@ -0,0 +1,133 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInMemoryContainerClient_EndToEnd(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	clusterService.SetOptions(ContainerOptions{})
+	client, err := NewContainerClient(RuntimeInMemory, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	node := runTestNode(t, clusterService, client)
+
+	container, err := clusterService.CreateContainerOn(node.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	if container.ContainerStatus.ContainerState != ContainerRunning || container.Hash == "" {
+		t.Fatalf("want:%v,have:%v", ContainerRunning, container.ContainerStatus)
+	}
+	stdout, _, exitCode, err := clusterService.ExecInContainer(container, []string{"echo", "hello"})
+	if err != nil || exitCode != 0 || stdout != "echo hello\n" {
+		t.Errorf("unexpected exec:%q,%v,%v", stdout, exitCode, err)
+	}
+
+	if err := clusterService.KillContainer(container, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if container.ContainerStatus.ContainerState != ContainerExited {
+		t.Errorf("want:%v,have:%v", ContainerExited, container.ContainerStatus.ContainerState)
+	}
+	logs, err := clusterService.Logs(container, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(logs)
+	if expected := "exited with code 0"; !strings.Contains(string(b), expected) {
+		t.Errorf("want:%v,have:%v", expected, string(b))
+	}
+	if err := clusterService.RemoveContainer(container.Id); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestInMemoryContainerClient_InjectError(t *testing.T) {
+	client := NewInMemoryContainerClient(0)
+	container := &Container{Image: testImage}
+	failure := errors.New("failure")
+	client.InjectError("Run", failure)
+	if _, err := client.Run(context.Background(), container); !errors.Is(err, failure) {
+		t.Errorf("want:%v,have:%v", failure, err)
+	}
+	client.InjectError("Run", nil)
+	hash, err := client.Run(context.Background(), container)
+	if err != nil {
+		t.Fatal(err)
+	}
+	container.Hash = hash
+
+	tests := []struct {
+		name      string
+		operation func() error
+		expected  error
+	}{
+		{"pauseRunning", func() error { return client.Pause(context.Background(), container) }, nil},
+		{"pausePaused", func() error { return client.Pause(context.Background(), container) }, ErrIllegalTransition},
+		{"removePaused", func() error { return client.Remove(context.Background(), container) }, ErrStillRunning},
+		{"stop", func() error { return client.Stop(context.Background(), container, 0) }, nil},
+		{"remove", func() error { return client.Remove(context.Background(), container) }, nil},
+		{"removeRemoved", func() error { return client.Remove(context.Background(), container) }, ErrContainerNotFound},
+		{"imageNotPulled", func() error { _, err := client.ImageId(context.Background(), testImage); return err }, ErrImageNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.operation(); !errors.Is(err, tt.expected) {
+				t.Errorf("want:%v,have:%v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestInMemoryContainerClient_RunDelay(t *testing.T) {
+	client := NewInMemoryContainerClient(time.Hour)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := client.Run(ctx, &Container{Image: testImage}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want:%v,have:%v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestInMemoryContainerClient_ExitContainer(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	client := NewInMemoryContainerClient(0)
+	runTestNode(t, clusterService, client)
+	container, err := clusterService.CreateContainerWithSpec(ContainerSpec{RestartPolicy: RestartPolicy{Name: RestartOnFailure, MaxRetries: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		// hash of container changes by restart
+		container, _ = clusterService.GetContainer(container.Id)
+		client.ExitContainer(container, 1, time.Millisecond)
+		time.Sleep(10 * time.Millisecond)
+		if err := clusterService.FlushContainers(); err != nil {
+			t.Fatal(err)
+		}
+		if status, _ := clusterService.ContainerStatus(container.Id, "", ""); status.ContainerState != ContainerExited || status.ExitCode != 1 {
+			t.Fatalf("want:%v,have:%v", ContainerExited, status)
+		}
+		restarted, err := clusterService.RestartContainers()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// restarted only once by max retries
+		if expected := 1 - i; len(restarted) != expected {
+			t.Errorf("want:%v,have:%v", expected, restarted)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package clustermetrics
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/ynishi/cluster"
+)
+
+// Metrics holds prometheus metrics of cluster, they are updated by Update.
+type Metrics struct {
+	registry          *prometheus.Registry
+	nodesTotal        prometheus.Gauge
+	nodesRunning      prometheus.Gauge
+	containersTotal   prometheus.Gauge
+	containersRunning prometheus.Gauge
+	restartsTotal     prometheus.Counter
+
+	mu sync.Mutex
+	// restart count already added to restartsTotal by container id,
+	// kept even if container is gone so that the counter never goes back.
+	restarts map[cluster.UID]int
+}
+
+// NewMetrics create metrics registered in its own registry.
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		nodesTotal: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "cluster_nodes_total",
+			Help: "Number of nodes in cluster.",
+		}),
+		nodesRunning: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "cluster_nodes_running",
+			Help: "Number of running nodes in cluster.",
+		}),
+		containersTotal: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "cluster_containers_total",
+			Help: "Number of containers in cluster.",
+		}),
+		containersRunning: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "cluster_containers_running",
+			Help: "Number of running containers in cluster.",
+		}),
+		restartsTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "container_restarts_total",
+			Help: "Number of container restarts by restart policy.",
+		}),
+		restarts: make(map[cluster.UID]int),
+	}
+	m.registry.MustRegister(m.nodesTotal, m.nodesRunning, m.containersTotal, m.containersRunning, m.restartsTotal)
+	return m
+}
+
+// Registry returns registry of metrics, to add other collectors or gather.
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Handler returns promhttp handler, metrics are updated from service on each scrape.
+func (m *Metrics) Handler(service cluster.ClusterService) http.Handler {
+	handler := promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := m.Update(service); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// Update set metrics from current nodes and containers of service.
+func (m *Metrics) Update(service cluster.ClusterService) error {
+	nodes, err := service.Nodes(true)
+	if err != nil {
+		return err
+	}
+	containers, err := service.Containers(true)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	nodesRunning := 0
+	for _, node := range nodes {
+		if node.NodeState == cluster.NodeRunning {
+			nodesRunning++
+		}
+	}
+	containersRunning := 0
+	for _, container := range containers {
+		if container.ContainerStatus != nil && container.ContainerStatus.ContainerState == cluster.ContainerRunning {
+			containersRunning++
+		}
+		if seen := m.restarts[container.Id]; container.RestartCount > seen {
+			m.restartsTotal.Add(float64(container.RestartCount - seen))
+			m.restarts[container.Id] = container.RestartCount
+		}
+	}
+	m.nodesTotal.Set(float64(len(nodes)))
+	m.nodesRunning.Set(float64(nodesRunning))
+	m.containersTotal.Set(float64(len(containers)))
+	m.containersRunning.Set(float64(containersRunning))
+	return nil
+}
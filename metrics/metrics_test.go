@@ -0,0 +1,94 @@
+package clustermetrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/ynishi/cluster"
+)
+
+func TestClusterService(t *testing.T) {
+	service := cluster.NewDefaultClusterService("0.0.1", &cluster.Image{Name: "testImage"})
+	service.SetOptions(cluster.ContainerOptions{"key": "value"})
+	metrics := NewMetrics()
+	instrumented := Instrument(service, metrics)
+
+	node, err := instrumented.CreateNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := cluster.NewFakeContainerClient("hash1")
+	if _, err := service.PrepareNode(node.Id, func(node *cluster.Node) error {
+		node.Client = client
+		node.ResourceProvider = cluster.NewFakeResourceProvider(cluster.ResourceInfo{})
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := instrumented.RunNode(node); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := instrumented.CreateNode(); err != nil {
+		t.Fatal(err)
+	}
+	container, err := instrumented.CreateContainer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := instrumented.CreateContainer(); err != nil {
+		t.Fatal(err)
+	}
+	if err := instrumented.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.UpdateContainerIf(container.Id, container.ResourceVersion, func(container *cluster.Container) error {
+		container.Spec.RestartPolicy = cluster.RestartPolicy{Name: cluster.RestartAlways}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// restart twice
+	for i := 0; i < 2; i++ {
+		client.SetExited(container.Id, 1)
+		if err := service.FlushContainers(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := service.RestartContainers(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := instrumented.FlushContainers(); err != nil {
+		t.Fatal(err)
+	}
+	if err := instrumented.FlushContainers(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		have float64
+		want float64
+	}{
+		{"nodesTotal", testutil.ToFloat64(metrics.nodesTotal), 2},
+		{"nodesRunning", testutil.ToFloat64(metrics.nodesRunning), 1},
+		{"containersTotal", testutil.ToFloat64(metrics.containersTotal), 2},
+		{"containersRunning", testutil.ToFloat64(metrics.containersRunning), 1},
+		{"restartsTotal", testutil.ToFloat64(metrics.restartsTotal), 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.have != tt.want {
+				t.Errorf("want:%v,have:%v", tt.want, tt.have)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	metrics.Handler(service).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "container_restarts_total 2") {
+		t.Errorf("want:%v,have:%v,%v", "container_restarts_total 2", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,71 @@
+package clustermetrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/ynishi/cluster"
+)
+
+// ClusterService is cluster.ClusterService updating metrics after each call changing state.
+// failure of update is ignored, metrics are updated again on next call or scrape.
+type ClusterService struct {
+	cluster.ClusterService
+	metrics *Metrics
+}
+
+var _ cluster.ClusterService = (*ClusterService)(nil)
+
+// Instrument wraps service to update metrics.
+func Instrument(service cluster.ClusterService, metrics *Metrics) *ClusterService {
+	return &ClusterService{ClusterService: service, metrics: metrics}
+}
+
+func (cs *ClusterService) update() {
+	cs.metrics.Update(cs.ClusterService)
+}
+
+func (cs *ClusterService) CreateContainer() (*cluster.Container, error) {
+	defer cs.update()
+	return cs.ClusterService.CreateContainer()
+}
+
+func (cs *ClusterService) RunContainer(container *cluster.Container) error {
+	defer cs.update()
+	return cs.ClusterService.RunContainer(container)
+}
+
+func (cs *ClusterService) KillContainer(runningContainer *cluster.Container, gracePeriod time.Duration) error {
+	defer cs.update()
+	return cs.ClusterService.KillContainer(runningContainer, gracePeriod)
+}
+
+func (cs *ClusterService) CreateNode() (*cluster.Node, error) {
+	defer cs.update()
+	return cs.ClusterService.CreateNode()
+}
+
+func (cs *ClusterService) RunNode(node *cluster.Node) error {
+	defer cs.update()
+	return cs.ClusterService.RunNode(node)
+}
+
+func (cs *ClusterService) KillNode(runningNode cluster.Node, gracePeriod int) error {
+	defer cs.update()
+	return cs.ClusterService.KillNode(runningNode, gracePeriod)
+}
+
+func (cs *ClusterService) Shutdown(ctx context.Context) error {
+	defer cs.update()
+	return cs.ClusterService.Shutdown(ctx)
+}
+
+func (cs *ClusterService) FlushNodes() error {
+	defer cs.update()
+	return cs.ClusterService.FlushNodes()
+}
+
+func (cs *ClusterService) FlushContainers() error {
+	defer cs.update()
+	return cs.ClusterService.FlushContainers()
+}
@@ -0,0 +1,85 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DefaultNamespace is namespace of containers and nodes created without namespace.
+const DefaultNamespace = "default"
+
+// nameKey is key of name unique in namespace.
+type nameKey struct {
+	namespace string
+	name      string
+}
+
+// validateNamespace check namespace is a DNS label same as node name.
+func validateNamespace(namespace string) error {
+	if namespace == "" {
+		return errors.New("namespace required")
+	}
+	if len(namespace) > maxNodeNameLength || !nodeNamePattern.MatchString(namespace) {
+		return fmt.Errorf("invalid namespace:%v", namespace)
+	}
+	return nil
+}
+
+func namespaceOrDefault(namespace string) string {
+	if namespace == "" {
+		return DefaultNamespace
+	}
+	return namespace
+}
+
+// CreateNodeInNamespace create node with generated name unique in namespace.
+func (dcs *DefaultClusterService) CreateNodeInNamespace(namespace string) (*Node, error) {
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	return clonedNode(dcs.createNode(namespace))
+}
+
+// CreateNamedNodeInNamespace create node with name, which must be a DNS label and unique in namespace.
+func (dcs *DefaultClusterService) CreateNamedNodeInNamespace(namespace string, name string) (*Node, error) {
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	return clonedNode(dcs.createNamedNode(namespace, name))
+}
+
+// CreateContainerWithSpecInNamespace create container run with spec in namespace.
+// it may be placed on node of any namespace.
+func (dcs *DefaultClusterService) CreateContainerWithSpecInNamespace(namespace string, spec ContainerSpec) (*Container, error) {
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	return clonedContainer(dcs.createContainerWithSpec(namespace, spec, nil, false))
+}
+
+// ContainersInNamespace is Containers of namespace.
+func (dcs *DefaultClusterService) ContainersInNamespace(namespace string, all bool) (Containers, error) {
+	containers, err := dcs.Containers(all)
+	if err != nil {
+		return nil, err
+	}
+	res := Containers{}
+	for _, c := range containers {
+		if c.Namespace == namespace {
+			res = append(res, c)
+		}
+	}
+	return res, nil
+}
+
+// NodesInNamespace is Nodes of namespace.
+func (dcs *DefaultClusterService) NodesInNamespace(namespace string, all bool) ([]*Node, error) {
+	nodes, err := dcs.Nodes(all)
+	if err != nil {
+		return nil, err
+	}
+	res := []*Node{}
+	for _, node := range nodes {
+		if node.Namespace == namespace {
+			res = append(res, node)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,109 @@
+package cluster
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDefaultClusterService_CreateNamedNodeInNamespace(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	defaultNode, err := clusterService.CreateNamedNode("node1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if defaultNode.Namespace != DefaultNamespace {
+		t.Errorf("want:%v,have:%v", DefaultNamespace, defaultNode.Namespace)
+	}
+	// same name is allowed in other namespace
+	teamNode, err := clusterService.CreateNamedNodeInNamespace("team-a", "node1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := clusterService.CreateNamedNodeInNamespace("team-a", "node1"); !errors.Is(err, ErrNodeAlreadyExists) {
+		t.Errorf("want:%v,have:%v", ErrNodeAlreadyExists, err)
+	}
+
+	tests := []struct {
+		namespace string
+		expected  *Node
+	}{
+		{DefaultNamespace, defaultNode},
+		{"team-a", teamNode},
+	}
+	for _, tt := range tests {
+		node, err := clusterService.GetNodeByNameInNamespace(tt.namespace, "node1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if node.Id != tt.expected.Id {
+			t.Errorf("want:%v,have:%v", tt.expected.Id, node.Id)
+		}
+	}
+	if _, err := clusterService.GetNodeByNameInNamespace("team-b", "node1"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("want:%v,have:%v", ErrNodeNotFound, err)
+	}
+
+	for _, namespace := range []string{"", "Team_A", "-team"} {
+		if _, err := clusterService.CreateNodeInNamespace(namespace); err == nil {
+			t.Errorf("want:error,have:nil for namespace:%v", namespace)
+		}
+	}
+}
+
+func TestDefaultClusterService_InNamespace(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	clusterService.SetOptions(ContainerOptions{})
+	node, err := clusterService.CreateNodeInNamespace("team-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, err = clusterService.PrepareNode(node.Id, func(node *Node) error {
+		node.ResourceProvider = NewFakeResourceProvider(ResourceInfo{})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.RunNode(node); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := clusterService.CreateNode(); err != nil {
+		t.Fatal(err)
+	}
+
+	teamContainer, err := clusterService.CreateContainerWithSpecInNamespace("team-a", ContainerSpec{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if teamContainer.ContainerStatus.Namespace != "team-a" {
+		t.Errorf("want:%v,have:%v", "team-a", teamContainer.ContainerStatus.Namespace)
+	}
+	defaultContainer, err := clusterService.CreateContainer()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		namespace  string
+		containers int
+		nodes      int
+	}{
+		{"team-a", 1, 1},
+		{DefaultNamespace, 1, 1},
+		{"team-b", 0, 0},
+	}
+	for _, tt := range tests {
+		containers, _ := clusterService.ContainersInNamespace(tt.namespace, true)
+		if len(containers) != tt.containers {
+			t.Errorf("want:%v,have:%v", tt.containers, len(containers))
+		}
+		nodes, _ := clusterService.NodesInNamespace(tt.namespace, true)
+		if len(nodes) != tt.nodes {
+			t.Errorf("want:%v,have:%v", tt.nodes, len(nodes))
+		}
+	}
+	containers, _ := clusterService.ContainersInNamespace(DefaultNamespace, true)
+	if len(containers) == 1 && containers[0].Id != defaultContainer.Id {
+		t.Errorf("want:%v,have:%v", defaultContainer.Id, containers[0].Id)
+	}
+}
@@ -0,0 +1,222 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// NodeGroupLabel is label of node holding name of its group.
+const NodeGroupLabel = "cluster/node-group"
+
+// NodeTemplate is configuration shared by nodes of a group, copied to each new node.
+type NodeTemplate struct {
+	Labels   map[string]string
+	Taints   []Taint
+	Capacity Capacity
+}
+
+// NodeGroup is pool of nodes sharing resource provider and template, like gpu-pool or spot-pool.
+type NodeGroup struct {
+	// unique name, same rule as node name
+	Name string
+	// provider of nodes in group, not serialized
+	ResourceProvider ResourceProvider `json:"-"`
+	// number of nodes not exited which ScaleNodeGroup keeps
+	DesiredSize int
+	// copied to new nodes
+	Template NodeTemplate
+	// set client or others of new node before it runs, optional
+	Prepare func(node *Node) error `json:"-"`
+}
+
+// Clone returns deep copy of group.
+func (ng *NodeGroup) Clone() *NodeGroup {
+	if ng == nil {
+		return nil
+	}
+	clone := *ng
+	clone.Template.Labels = cloneStringMap(ng.Template.Labels)
+	if ng.Template.Taints != nil {
+		clone.Template.Taints = append([]Taint{}, ng.Template.Taints...)
+	}
+	return &clone
+}
+
+// AddNodeGroup register group, its nodes are created by CreateNodeInGroup or ScaleNodeGroup.
+func (dcs *DefaultClusterService) AddNodeGroup(group NodeGroup) error {
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	if err := validateNodeName(group.Name); err != nil {
+		return err
+	}
+	if group.ResourceProvider == nil {
+		return ErrNoResourceProvider
+	}
+	if group.DesiredSize < 0 {
+		return fmt.Errorf("invalid desired size:%v", group.DesiredSize)
+	}
+	if _, ok := dcs.nodeGroups[group.Name]; ok {
+		return fmt.Errorf("%w:%v", ErrNodeGroupAlreadyExists, group.Name)
+	}
+	if dcs.nodeGroups == nil {
+		dcs.nodeGroups = make(map[string]*NodeGroup)
+	}
+	dcs.nodeGroups[group.Name] = group.Clone()
+	return nil
+}
+
+// GetNodeGroup returns group of name.
+func (dcs *DefaultClusterService) GetNodeGroup(name string) (*NodeGroup, error) {
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
+	group, ok := dcs.nodeGroups[name]
+	if !ok {
+		return nil, fmt.Errorf("%w:%v", ErrNodeGroupNotFound, name)
+	}
+	return group.Clone(), nil
+}
+
+// SetNodeGroupProvider attach resource provider and prepare of group, replacing ones set before,
+// like after LoadState which does not save them.
+func (dcs *DefaultClusterService) SetNodeGroupProvider(name string, provider ResourceProvider, prepare func(node *Node) error) error {
+	if provider == nil {
+		return ErrNoResourceProvider
+	}
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	group, ok := dcs.nodeGroups[name]
+	if !ok {
+		return fmt.Errorf("%w:%v", ErrNodeGroupNotFound, name)
+	}
+	group.ResourceProvider = provider
+	group.Prepare = prepare
+	return nil
+}
+
+// NodeGroups returns groups sorted by name.
+func (dcs *DefaultClusterService) NodeGroups() []*NodeGroup {
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
+	groups := make([]*NodeGroup, 0, len(dcs.nodeGroups))
+	for _, group := range dcs.nodeGroups {
+		groups = append(groups, group.Clone())
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	return groups
+}
+
+// NodesInGroup returns nodes of group not exited, in order of creation.
+func (dcs *DefaultClusterService) NodesInGroup(name string) (Nodes, error) {
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
+	if _, ok := dcs.nodeGroups[name]; !ok {
+		return nil, fmt.Errorf("%w:%v", ErrNodeGroupNotFound, name)
+	}
+	return Nodes(dcs.nodesInGroup(name)).Clone(), nil
+}
+
+func (dcs *DefaultClusterService) nodesInGroup(name string) Nodes {
+	nodes := Nodes{}
+	for _, node := range dcs.nodes {
+		if node.Labels[NodeGroupLabel] == name && node.NodeState != NodeExited {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// CreateNodeInGroup create node inheriting template and resource provider of group, prepared by the group.
+// node is not run, and removed if preparing fails.
+func (dcs *DefaultClusterService) CreateNodeInGroup(name string) (*Node, error) {
+	dcs.mu.Lock()
+	group, ok := dcs.nodeGroups[name]
+	if !ok {
+		dcs.mu.Unlock()
+		return nil, fmt.Errorf("%w:%v", ErrNodeGroupNotFound, name)
+	}
+	node, err := dcs.createNode(DefaultNamespace)
+	if err != nil {
+		dcs.mu.Unlock()
+		return nil, err
+	}
+	node.Labels = cloneStringMap(group.Template.Labels)
+	if node.Labels == nil {
+		node.Labels = map[string]string{}
+	}
+	node.Labels[NodeGroupLabel] = group.Name
+	if group.Template.Taints != nil {
+		node.Taints = append([]Taint{}, group.Template.Taints...)
+	}
+	node.Capacity = group.Template.Capacity
+	node.ResourceProvider = group.ResourceProvider
+	prepare := group.Prepare
+	created := node.Clone()
+	dcs.mu.Unlock()
+	if prepare == nil {
+		return created, nil
+	}
+	prepared, err := dcs.PrepareNode(created.Id, prepare)
+	if err != nil {
+		dcs.RemoveNode(created.Id)
+		return nil, err
+	}
+	return prepared, nil
+}
+
+// ScaleNodeGroup set desired size of group, then scale it up by running new nodes
+// or down by draining, killing and removing the newest nodes, until it has the size.
+// it stops on the first error, which is retried by calling it again.
+func (dcs *DefaultClusterService) ScaleNodeGroup(ctx context.Context, name string, size int) error {
+	if size < 0 {
+		return fmt.Errorf("invalid desired size:%v", size)
+	}
+	dcs.mu.Lock()
+	group, ok := dcs.nodeGroups[name]
+	if !ok {
+		dcs.mu.Unlock()
+		return fmt.Errorf("%w:%v", ErrNodeGroupNotFound, name)
+	}
+	group.DesiredSize = size
+	nodes := Nodes(dcs.nodesInGroup(name)).Clone()
+	dcs.mu.Unlock()
+
+	for i := len(nodes); i < size; i++ {
+		node, err := dcs.CreateNodeInGroup(name)
+		if err != nil {
+			return err
+		}
+		if err := dcs.RunNodeContext(ctx, node); err != nil {
+			dcs.RemoveNode(node.Id)
+			return err
+		}
+	}
+	for i := len(nodes) - 1; i >= size; i-- {
+		if err := dcs.removeGroupNode(ctx, nodes[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeGroupNode move containers of node to other nodes, then kill and remove node.
+// node is kept as exited if its exited containers are not removed.
+func (dcs *DefaultClusterService) removeGroupNode(ctx context.Context, node *Node) error {
+	if node.NodeState == NodeCreated {
+		return dcs.RemoveNode(node.Id)
+	}
+	if err := dcs.DrainNode(node.Id); err != nil {
+		dcs.Uncordon(node.Id)
+		return err
+	}
+	if err := dcs.KillNodeContext(ctx, *node, int(DefaultStopGracePeriod/time.Millisecond)); err != nil {
+		dcs.Uncordon(node.Id)
+		return err
+	}
+	if err := dcs.RemoveNode(node.Id); err != nil && !errors.Is(err, ErrNodeHasContainers) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDefaultClusterService_AddNodeGroup(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	provider := NewFakeResourceProvider(ResourceInfo{})
+	if err := clusterService.AddNodeGroup(NodeGroup{Name: "gpu-pool", ResourceProvider: provider}); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		group    NodeGroup
+		expected error
+	}{
+		{"duplicated", NodeGroup{Name: "gpu-pool", ResourceProvider: provider}, ErrNodeGroupAlreadyExists},
+		{"noProvider", NodeGroup{Name: "spot-pool"}, ErrNoResourceProvider},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := clusterService.AddNodeGroup(tt.group); !errors.Is(err, tt.expected) {
+				t.Errorf("want:%v,have:%v", tt.expected, err)
+			}
+		})
+	}
+	if _, err := clusterService.GetNodeGroup("spot-pool"); !errors.Is(err, ErrNodeGroupNotFound) {
+		t.Errorf("want:%v,have:%v", ErrNodeGroupNotFound, err)
+	}
+}
+
+func TestDefaultClusterService_ScaleNodeGroup(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	provider := NewFakeResourceProvider(ResourceInfo{})
+	err := clusterService.AddNodeGroup(NodeGroup{
+		Name:             "gpu-pool",
+		ResourceProvider: provider,
+		Template: NodeTemplate{
+			Labels:   map[string]string{"gpu": "true"},
+			Taints:   []Taint{{Key: "gpu", Effect: TaintNoSchedule}},
+			Capacity: Capacity{CPUShares: 2048},
+		},
+		Prepare: func(node *Node) error {
+			node.Client = NewFakeContainerClient("hash1")
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// node out of group is not counted
+	clusterService.CreateNode()
+
+	for _, size := range []int{3, 1} {
+		if err := clusterService.ScaleNodeGroup(context.Background(), "gpu-pool", size); err != nil {
+			t.Fatal(err)
+		}
+		nodes, err := clusterService.NodesInGroup("gpu-pool")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(nodes) != size {
+			t.Fatalf("want:%v,have:%v", size, len(nodes))
+		}
+		for _, node := range nodes {
+			if node.NodeState != NodeRunning || node.Labels["gpu"] != "true" || len(node.Taints) != 1 || node.Capacity.CPUShares != 2048 {
+				t.Errorf("unexpected node:%v", node)
+			}
+		}
+	}
+	group, _ := clusterService.GetNodeGroup("gpu-pool")
+	if group.DesiredSize != 1 {
+		t.Errorf("want:%v,have:%v", 1, group.DesiredSize)
+	}
+	if run, stop, _ := provider.Calls(); run != 3 || stop != 2 {
+		t.Errorf("want:%v,have:%v", []int{3, 2}, []int{run, stop})
+	}
+
+	// group returned is a copy
+	group.Template.Labels["gpu"] = "false"
+	if have, _ := clusterService.GetNodeGroup("gpu-pool"); have.Template.Labels["gpu"] != "true" {
+		t.Errorf("want:%v,have:%v", "true", have.Template.Labels["gpu"])
+	}
+}
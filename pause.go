@@ -0,0 +1,84 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+)
+
+// Pause suspend processes of running container, it is still alive while paused.
+func (dcs *DefaultClusterService) Pause(container *Container) error {
+	return dcs.PauseContext(context.Background(), container)
+}
+
+// PauseContext is Pause which gives up when ctx is done.
+// runtime is called without lock as RemoveContainerContext does.
+func (dcs *DefaultClusterService) PauseContext(ctx context.Context, container *Container) error {
+	return dcs.pauseContainer(ctx, container, ContainerPaused)
+}
+
+// Unpause resume processes of paused container.
+func (dcs *DefaultClusterService) Unpause(container *Container) error {
+	return dcs.UnpauseContext(context.Background(), container)
+}
+
+// UnpauseContext is Unpause which gives up when ctx is done.
+func (dcs *DefaultClusterService) UnpauseContext(ctx context.Context, container *Container) error {
+	return dcs.pauseContainer(ctx, container, ContainerRunning)
+}
+
+// pauseContainer pause container if to is paused, unpause it if to is running.
+func (dcs *DefaultClusterService) pauseContainer(ctx context.Context, container *Container, to ContainerState) error {
+	dcs.mu.Lock()
+	owned := dcs.ownedContainer(container)
+	node, err := dcs.checkPause(owned, to)
+	if err == nil {
+		err = dcs.acquireInFlight(owned)
+	}
+	snapshot := owned.Clone()
+	if err != nil {
+		copyContainer(container, owned)
+		dcs.mu.Unlock()
+		return err
+	}
+	dcs.mu.Unlock()
+
+	event := EventContainerPaused
+	if to == ContainerPaused {
+		err = node.Client.Pause(ctx, snapshot)
+	} else {
+		err = node.Client.Unpause(ctx, snapshot)
+		event = EventContainerResumed
+	}
+
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	defer copyContainer(container, owned)
+	dcs.releaseInFlight(owned)
+	if err != nil {
+		return err
+	}
+	// container may be rescheduled while pausing without lock
+	if owned.Hash != snapshot.Hash {
+		return fmt.Errorf("%w for uid:%v, changed while pausing", ErrConflict, owned.Id)
+	}
+	if err := TransitionContainer(owned.ContainerStatus, to); err != nil {
+		return err
+	}
+	dcs.emit(event, owned.Id)
+	return nil
+}
+
+// checkPause returns snapshot of node of container if it can be paused or unpaused to, caller must hold lock.
+func (dcs *DefaultClusterService) checkPause(container *Container, to ContainerState) (*Node, error) {
+	if err := checkContainerTransition(containerStateOf(container), to); err != nil {
+		return nil, err
+	}
+	node := dcs.findNodeById(container.NodeId)
+	if node == nil {
+		return nil, fmt.Errorf("%w for uid:%v", ErrNodeNotFound, container.NodeId)
+	}
+	if node.Client == nil {
+		return nil, fmt.Errorf("%w:%v", ErrNodeHasNoClient, node.Name)
+	}
+	return node.Clone(), nil
+}
@@ -0,0 +1,92 @@
+package cluster
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDefaultClusterService_Pause(t *testing.T) {
+	clusterService, client := newTestRestartService(t)
+	container, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+
+	// only running container can be paused
+	if err := clusterService.Pause(container); !errors.Is(err, ErrIllegalTransition) {
+		t.Errorf("want:%v,have:%v", ErrIllegalTransition, err)
+	}
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	startedAt := container.ContainerStatus.StartedAt
+	if err := clusterService.Pause(container); err != nil {
+		t.Fatal(err)
+	}
+	if container.ContainerStatus.ContainerState != ContainerPaused || len(client.pauses) != 1 {
+		t.Errorf("%v,%v", container.ContainerStatus.ContainerState, client.pauses)
+	}
+	// paused container is alive
+	if alive, _ := clusterService.Containers(false); len(alive) != 1 {
+		t.Errorf("want:%v,have:%v", 1, alive)
+	}
+	if err := clusterService.Pause(container); !errors.Is(err, ErrIllegalTransition) {
+		t.Errorf("want:%v,have:%v", ErrIllegalTransition, err)
+	}
+
+	if err := clusterService.Unpause(container); err != nil {
+		t.Fatal(err)
+	}
+	if container.ContainerStatus.ContainerState != ContainerRunning || len(client.unpauses) != 1 {
+		t.Errorf("%v,%v", container.ContainerStatus.ContainerState, client.unpauses)
+	}
+	if !container.ContainerStatus.StartedAt.Equal(startedAt) {
+		t.Errorf("want:%v,have:%v", startedAt, container.ContainerStatus.StartedAt)
+	}
+	if err := clusterService.Unpause(container); !errors.Is(err, ErrIllegalTransition) {
+		t.Errorf("want:%v,have:%v", ErrIllegalTransition, err)
+	}
+}
+
+func TestDefaultClusterService_Pause_Error(t *testing.T) {
+	clusterService, client := newTestRestartService(t)
+	container, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	client.err = errors.New("pause failed")
+	if err := clusterService.Pause(container); err != client.err {
+		t.Errorf("want:%v,have:%v", client.err, err)
+	}
+	if container.ContainerStatus.ContainerState != ContainerRunning {
+		t.Errorf("want:%v,have:%v", ContainerRunning, container.ContainerStatus.ContainerState)
+	}
+}
+
+func TestDefaultClusterService_Pause_Unlocked(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	client := newBlockingContainerClient()
+	node := runTestNode(t, clusterService, client.FakeContainerClient)
+	container, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	// runtime blocks after container runs
+	if err := clusterService.SetNodeClient(node.Id, client); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- clusterService.Pause(container.Clone()) }()
+	if called := <-client.called; called != "Pause" {
+		t.Fatalf("want:%v,have:%v", "Pause", called)
+	}
+	assertUnlocked(t, clusterService)
+	if err := clusterService.Pause(container.Clone()); !errors.Is(err, ErrConflict) {
+		t.Errorf("want:%v,have:%v", ErrConflict, err)
+	}
+	close(client.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if state, _ := clusterService.containerState(container.Id); state != ContainerPaused {
+		t.Errorf("want:%v,have:%v", ContainerPaused, state)
+	}
+}
@@ -0,0 +1,140 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// isUnschedulable returns true if err of scheduling may be resolved by nodes joining or freeing capacity.
+func isUnschedulable(err error) bool {
+	return errors.Is(err, ErrNoValidNode) || errors.Is(err, ErrInsufficientCapacity) || errors.Is(err, ErrUnsatisfiedConstraints)
+}
+
+// enqueuePending register new container as pending without node, named unique among pending ones.
+func (dcs *DefaultClusterService) enqueuePending(container *Container) error {
+	container.NodeId = ""
+	container.NodeName = ""
+	container.Name = dcs.genContainerName("", container.Image)
+	container.ContainerStatus.NodeName = ""
+	container.ContainerStatus.Name = container.Name
+	if err := transitionContainer(container.ContainerStatus, ContainerPending, "no node can take container"); err != nil {
+		return err
+	}
+	dcs.containers = append(dcs.containers, container)
+	dcs.indexContainer(container)
+	dcs.containerStatuses = append(dcs.containerStatuses, container.ContainerStatus)
+	dcs.indexContainerStatus(container.ContainerStatus)
+	dcs.emit(EventContainerCreated, container.Id)
+	return nil
+}
+
+// PendingContainers returns containers waiting for node, in order they are placed.
+func (dcs *DefaultClusterService) PendingContainers() Containers {
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
+	return byPriority(dcs.pendingContainers()).Clone()
+}
+
+func (dcs *DefaultClusterService) pendingContainers() Containers {
+	pending := Containers{}
+	for _, c := range dcs.containers {
+		if containerStateOf(c) == ContainerPending {
+			pending = append(pending, c)
+		}
+	}
+	return pending
+}
+
+// SchedulePending place pending containers on nodes selected by scheduler in order of priority and creation,
+// making them created to be run. containers no node can take yet are kept pending.
+// returns placed containers, and errors other than no node can take them.
+func (dcs *DefaultClusterService) SchedulePending() (Containers, error) {
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	placed := Containers{}
+	errs := []error{}
+	for _, c := range byPriority(dcs.pendingContainers()) {
+		if err := dcs.schedulePending(c); err != nil {
+			if !isUnschedulable(err) {
+				errs = append(errs, fmt.Errorf("failed to schedule container:%v, %w", c.Name, err))
+			}
+			continue
+		}
+		placed = append(placed, c.Clone())
+	}
+	return placed, errors.Join(errs...)
+}
+
+// schedulePending move pending container to node selected by scheduler.
+func (dcs *DefaultClusterService) schedulePending(container *Container) error {
+	node, err := dcs.minWorkingNode(container)
+	if err != nil {
+		return err
+	}
+	status := container.ContainerStatus
+	dcs.unindexContainerStatus(status)
+	dcs.unindexContainer(container)
+	container.NodeId = node.Id
+	container.NodeName = node.Name
+	container.Name = dcs.genContainerName(node.Id, container.Image)
+	status.Name = container.Name
+	status.NodeName = node.Name
+	dcs.indexContainerStatus(status)
+	dcs.indexContainer(container)
+	if err := transitionContainer(status, ContainerCreated, "scheduled by SchedulePending"); err != nil {
+		return err
+	}
+	dcs.emit(EventContainerScheduled, container.Id)
+	return nil
+}
+
+// StartSchedulingLoop place pending containers by SchedulePending every interval until ctx is done or Shutdown.
+// returned channel is closed when the loop exited.
+func (dcs *DefaultClusterService) StartSchedulingLoop(ctx context.Context, interval time.Duration) <-chan struct{} {
+	return dcs.goLoop(ctx, func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				// errors are retried in the next round
+				dcs.SchedulePending()
+			}
+		}
+	})
+}
+
+// SetPendingTimeout set duration a container may be pending before Status reports cluster degraded, 0 disables it.
+func (dcs *DefaultClusterService) SetPendingTimeout(timeout time.Duration) {
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	dcs.pendingTimeout = timeout
+}
+
+// stuckPending returns number of containers pending longer than pending timeout at now.
+func (dcs *DefaultClusterService) stuckPending(now time.Time) int {
+	if dcs.pendingTimeout <= 0 {
+		return 0
+	}
+	stuck := 0
+	for _, c := range dcs.pendingContainers() {
+		if since := c.ContainerStatus.pendingSince(); !since.IsZero() && now.Sub(since) > dcs.pendingTimeout {
+			stuck++
+		}
+	}
+	return stuck
+}
+
+// pendingSince returns time status became pending, zero if it is not recorded in history.
+func (cs *ContainerStatus) pendingSince() time.Time {
+	for i := len(cs.History) - 1; i >= 0; i-- {
+		if cs.History[i].To == ContainerPending {
+			return cs.History[i].Time
+		}
+	}
+	return time.Time{}
+}
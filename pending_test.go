@@ -0,0 +1,96 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDefaultClusterService_SchedulePending(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	if _, err := clusterService.CreateContainerWithSpec(ContainerSpec{}); !errors.Is(err, ErrNoValidNode) {
+		t.Errorf("want:%v,have:%v", ErrNoValidNode, err)
+	}
+	pending := Containers{}
+	for i := 0; i < 2; i++ {
+		container, err := clusterService.CreateContainerWithSpecAllowPending(ContainerSpec{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if containerStateOf(container) != ContainerPending || container.NodeId != "" {
+			t.Errorf("want:%v,have:%v on %v", ContainerPending, containerStateOf(container), container.NodeId)
+		}
+		pending = append(pending, container)
+	}
+	if pending[0].Name == pending[1].Name {
+		t.Errorf("duplicated name:%v", pending[0].Name)
+	}
+	// pending containers are alive, waiting for node
+	if alive, _ := clusterService.Containers(false); len(alive) != 2 {
+		t.Errorf("want:%v,have:%v", 2, alive)
+	}
+	if err := clusterService.RunContainer(pending[0]); !errors.Is(err, ErrContainerPending) {
+		t.Errorf("want:%v,have:%v", ErrContainerPending, err)
+	}
+	if placed, err := clusterService.SchedulePending(); err != nil || len(placed) != 0 {
+		t.Errorf("want none placed,have:%v,%v", placed, err)
+	}
+
+	// capacity appears
+	node := runTestNode(t, clusterService, NewInMemoryContainerClient(0))
+	placed, err := clusterService.SchedulePending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(placed) != 2 || placed[0].Id != pending[0].Id {
+		t.Fatalf("want:%v,have:%v", pending, placed)
+	}
+	for _, c := range placed {
+		if containerStateOf(c) != ContainerCreated || c.NodeId != node.Id {
+			t.Errorf("want:%v on %v,have:%v on %v", ContainerCreated, node.Id, containerStateOf(c), c.NodeId)
+		}
+	}
+	if have := len(clusterService.PendingContainers()); have != 0 {
+		t.Errorf("want:%v,have:%v", 0, have)
+	}
+	if err := clusterService.RunContainer(pending[0]); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDefaultClusterService_StartSchedulingLoop(t *testing.T) {
+	clusterService := newTestProbeService(t)
+	clusterService.SetPendingTimeout(time.Millisecond)
+	container, err := clusterService.CreateContainerWithSpecAllowPending(ContainerSpec{NodeAffinity: map[string]string{"gpu": "true"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if status, _ := clusterService.Status(); status.ClusterState != ClusterDegraded || !strings.Contains(status.Reason, "pending") {
+		t.Errorf("want:%v,have:%v", ClusterDegraded, status)
+	}
+
+	events, unsubscribe := clusterService.Watch()
+	defer unsubscribe()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	prepareTestNode(t, clusterService, func(node *Node) {
+		node.Labels = map[string]string{"gpu": "true"}
+		node.Client = NewInMemoryContainerClient(0)
+	})
+	clusterService.StartSchedulingLoop(ctx, 5*time.Millisecond)
+	timeout := time.After(time.Second)
+	for scheduled := false; !scheduled; {
+		select {
+		case event := <-events:
+			scheduled = event.Type == EventContainerScheduled && event.Id == container.Id
+		case <-timeout:
+			t.Fatal("not scheduled")
+		}
+	}
+	if status, _ := clusterService.Status(); status.ClusterState != ClusterRunning {
+		t.Errorf("want:%v,have:%v", ClusterRunning, status)
+	}
+}
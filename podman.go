@@ -0,0 +1,24 @@
+package cluster
+
+func init() {
+	RegisterContainerRuntime(RuntimePodman, func(endpoint string) (ContainerClient, error) {
+		return NewPodmanContainerClient(endpoint)
+	})
+}
+
+// podman serves docker compatible api of this version.
+const podmanAPIVersion = "1.40"
+
+// PodmanContainerClient is a ContainerClient backed by podman service through its docker compatible api.
+type PodmanContainerClient struct {
+	*DockerContainerClient
+}
+
+// NewPodmanContainerClient create client for podman service on host(ex: unix:///run/podman/podman.sock).
+func NewPodmanContainerClient(host string) (*PodmanContainerClient, error) {
+	dcc, err := NewDockerContainerClient(host, podmanAPIVersion)
+	if err != nil {
+		return nil, err
+	}
+	return &PodmanContainerClient{DockerContainerClient: dcc}, nil
+}
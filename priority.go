@@ -0,0 +1,33 @@
+package cluster
+
+import "sort"
+
+// priorityOrder returns indexes of containers in descending order of their priority,
+// ties keep their order, which is order of creation for containers of the service.
+func priorityOrder(containers Containers) []int {
+	order := make([]int, len(containers))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return priorityOf(containers[order[a]]) > priorityOf(containers[order[b]])
+	})
+	return order
+}
+
+// priorityOf returns priority of container, 0 for nil which batch leaves for rejected ones.
+func priorityOf(container *Container) int {
+	if container == nil {
+		return 0
+	}
+	return container.Spec.Priority
+}
+
+// byPriority returns containers in descending order of their priority, ties keep their order.
+func byPriority(containers Containers) Containers {
+	sorted := make(Containers, 0, len(containers))
+	for _, i := range priorityOrder(containers) {
+		sorted = append(sorted, containers[i])
+	}
+	return sorted
+}
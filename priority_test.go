@@ -0,0 +1,55 @@
+package cluster
+
+import "testing"
+
+func TestDefaultClusterService_Priority(t *testing.T) {
+	priorities := []int{0, 2, 1, 2}
+	// higher first, ties keep order of creation
+	want := []int{1, 3, 2, 0}
+	tests := []struct {
+		name string
+		run  func(clusterService *DefaultClusterService, client *mockContainerClient, containers Containers) error
+	}{
+		{"batch", func(clusterService *DefaultClusterService, client *mockContainerClient, containers Containers) error {
+			_, err := clusterService.RunContainers(containers)
+			return err
+		}},
+		{"restart", func(clusterService *DefaultClusterService, client *mockContainerClient, containers Containers) error {
+			for _, c := range containers {
+				if err := clusterService.RunContainer(c); err != nil {
+					return err
+				}
+			}
+			// runtime reports containers exited
+			client.state = ContainerExited
+			if err := clusterService.FlushContainers(); err != nil {
+				return err
+			}
+			_, err := clusterService.RestartContainers()
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusterService, client := newTestRestartService(t)
+			clusterService.SetMaxInFlight(1)
+			containers := Containers{}
+			for _, priority := range priorities {
+				container, err := clusterService.CreateContainerWithSpec(ContainerSpec{Priority: priority, RestartPolicy: RestartPolicy{Name: RestartAlways}})
+				if err != nil {
+					t.Fatal(err)
+				}
+				containers = append(containers, container)
+			}
+			if err := tt.run(clusterService, client, containers); err != nil {
+				t.Fatal(err)
+			}
+			runs := client.runs[len(client.runs)-len(want):]
+			for i, w := range want {
+				if runs[i].Id != containers[w].Id {
+					t.Errorf("want:%v,have:%v", containers[w].Name, runs[i].Name)
+				}
+			}
+		})
+	}
+}
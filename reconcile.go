@@ -0,0 +1,247 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// StartReconcileLoop flush containers and nodes, place pending containers, restart exited containers by their restart policy,
+// then ensure replicas of replica sets, every interval until ctx is done or Shutdown. returned channel is closed when the loop exited.
+func (dcs *DefaultClusterService) StartReconcileLoop(ctx context.Context, interval time.Duration) <-chan struct{} {
+	return dcs.goLoop(ctx, func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				dcs.reconcileOnce(ctx)
+			}
+		}
+	})
+}
+
+// reconcileOnce run a round of reconcile loop, errors are ignored and retried in the next round.
+func (dcs *DefaultClusterService) reconcileOnce(ctx context.Context) {
+	dcs.FlushContainersContext(ctx)
+	dcs.FlushNodes()
+	dcs.SchedulePending()
+	dcs.RestartContainersContext(ctx)
+	dcs.ensureReplicaSets(ctx)
+}
+
+// ReconcileLabel is label of container holding name of its spec given to Reconcile.
+const ReconcileLabel = "cluster/desired"
+
+// ReconcileReport is operations applied by Reconcile, containers are copies at the time.
+type ReconcileReport struct {
+	// created and run
+	Created Containers
+	// created before but not running, then run
+	Started Containers
+	// alive but not desired, then killed and removed
+	Killed Containers
+	// exited, then removed to be replaced
+	Removed Containers
+}
+
+// Reconcile converge containers labeled by ReconcileLabel to desired specs, identified by their Name.
+// for each spec, alive containers matching it are kept up to its replicas, and missing ones are created and run.
+// containers not matching spec, over replicas, or of name not desired are killed and removed,
+// and exited ones are removed, not to be restarted by restart policy.
+// It continues on error, and returns joined errors with operations applied.
+func (dcs *DefaultClusterService) Reconcile(desired []ContainerSpec) (ReconcileReport, error) {
+	return dcs.ReconcileContext(context.Background(), desired)
+}
+
+// ReconcileContext is Reconcile which gives up when ctx is done.
+func (dcs *DefaultClusterService) ReconcileContext(ctx context.Context, desired []ContainerSpec) (ReconcileReport, error) {
+	names := make(map[string]bool, len(desired))
+	for _, spec := range desired {
+		if err := validateNodeName(spec.Name); err != nil {
+			return ReconcileReport{}, fmt.Errorf("invalid spec name:%w", err)
+		}
+		if spec.Replicas < 0 {
+			return ReconcileReport{}, fmt.Errorf("invalid replicas:%v of spec:%v", spec.Replicas, spec.Name)
+		}
+		if names[spec.Name] {
+			return ReconcileReport{}, fmt.Errorf("duplicated spec name:%v", spec.Name)
+		}
+		names[spec.Name] = true
+	}
+	// specs of higher priority take capacity first
+	ordered := append([]ContainerSpec{}, desired...)
+	sort.SliceStable(ordered, func(i, j int) bool { return ordered[i].Priority > ordered[j].Priority })
+	replicas := make([]int, len(ordered))
+	for i, spec := range ordered {
+		replicas[i] = spec.Replicas
+		if replicas[i] == 0 {
+			replicas[i] = 1
+		}
+	}
+	report := &ReconcileReport{}
+	errs := dcs.converge(ctx, ReconcileLabel, ordered, replicas, true, report)
+	return *report, errors.Join(errs...)
+}
+
+// converge converge containers labeled by label with name of each spec to its replicas, in order of specs.
+// containers of names not in specs are dropped too if dropOthers.
+// plans are made under lock, and runtime is called without lock, so slow runtime does not block others.
+// containers are dropped first to free capacity, then missing ones are created and run.
+// containers of ReplicaSetLabel are named after the set.
+func (dcs *DefaultClusterService) converge(ctx context.Context, label string, specs []ContainerSpec, replicas []int,
+	dropOthers bool, report *ReconcileReport) []error {
+	dcs.mu.RLock()
+	drops := dcs.planDrops(label, specs, replicas, dropOthers)
+	dcs.mu.RUnlock()
+	errs := dcs.dropContainers(ctx, drops, report)
+
+	dcs.mu.Lock()
+	runs, created, createErrs := dcs.planRuns(label, specs, replicas)
+	dcs.mu.Unlock()
+	errs = append(errs, createErrs...)
+	runErrs, _ := dcs.RunContainersContext(ctx, runs)
+	for i, c := range runs {
+		if runErrs[i] != nil {
+			errs = append(errs, runErrs[i])
+			continue
+		}
+		if created[c.Id] {
+			report.Created = append(report.Created, c)
+		} else {
+			report.Started = append(report.Started, c)
+		}
+	}
+	return errs
+}
+
+// planDrops returns copies of containers labeled by label to be dropped, which are exited, not matching spec,
+// over replicas, or of name not in specs if dropOthers. caller must hold lock.
+func (dcs *DefaultClusterService) planDrops(label string, specs []ContainerSpec, replicas []int, dropOthers bool) Containers {
+	drops := Containers{}
+	if dropOthers {
+		names := make(map[string]bool, len(specs))
+		for _, spec := range specs {
+			names[spec.Name] = true
+		}
+		for _, c := range dcs.managedContainers(label, "") {
+			if !names[c.Labels[label]] {
+				drops = append(drops, c.Clone())
+			}
+		}
+	}
+	for i, spec := range specs {
+		kept := 0
+		for _, c := range dcs.managedContainers(label, spec.Name) {
+			if containerStateOf(c) == ContainerExited || !dcs.matchesSpec(c, spec) || kept >= replicas[i] {
+				drops = append(drops, c.Clone())
+				continue
+			}
+			kept++
+		}
+	}
+	return drops
+}
+
+// planRuns create containers missing from replicas of specs, and returns copies of them and kept containers
+// not run yet, with ids of created ones. caller must hold lock.
+func (dcs *DefaultClusterService) planRuns(label string, specs []ContainerSpec, replicas []int) (Containers, map[UID]bool, []error) {
+	runs := Containers{}
+	created := map[UID]bool{}
+	errs := []error{}
+	for i, spec := range specs {
+		kept := 0
+		for _, c := range dcs.managedContainers(label, spec.Name) {
+			if containerStateOf(c) == ContainerExited || !dcs.matchesSpec(c, spec) || kept >= replicas[i] {
+				continue
+			}
+			kept++
+			if containerStateOf(c) == ContainerCreated {
+				runs = append(runs, c.Clone())
+			}
+		}
+		for ; kept < replicas[i]; kept++ {
+			c, err := dcs.createContainerWithSpec(DefaultNamespace, spec.Clone(), nil, false)
+			if err != nil {
+				errs = append(errs, err)
+				break
+			}
+			c.Labels = map[string]string{label: spec.Name}
+			if label == ReplicaSetLabel {
+				dcs.renameContainer(c, dcs.replicaName(spec.Name))
+			}
+			runs = append(runs, c.Clone())
+			created[c.Id] = true
+		}
+	}
+	return runs, created, errs
+}
+
+// managedContainers returns containers labeled by label with name, or any name if empty, in order of creation.
+func (dcs *DefaultClusterService) managedContainers(label string, name string) Containers {
+	res := Containers{}
+	for _, c := range dcs.containers {
+		if managed, ok := c.Labels[label]; ok && (name == "" || managed == name) {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// dropContainers kill containers if they are alive, then remove them, recording them in report.
+// containers are copies, which are updated by the operations.
+func (dcs *DefaultClusterService) dropContainers(ctx context.Context, containers Containers, report *ReconcileReport) []error {
+	errs := []error{}
+	alive := Containers{}
+	killed := map[UID]bool{}
+	for _, c := range containers {
+		state := containerStateOf(c)
+		if state == ContainerRunning || state == ContainerPaused {
+			alive = append(alive, c)
+		}
+		killed[c.Id] = state == ContainerRunning || state == ContainerPaused || state == ContainerCreated
+	}
+	killErrs, _ := dcs.killContainers(ctx, alive, DefaultStopGracePeriod, "killed by Reconcile")
+	failed := map[UID]bool{}
+	for i, err := range killErrs {
+		if err != nil {
+			errs = append(errs, err)
+			failed[alive[i].Id] = true
+		}
+	}
+	for _, c := range containers {
+		if failed[c.Id] {
+			continue
+		}
+		if err := dcs.RemoveContainerContext(ctx, c.Id); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if killed[c.Id] {
+			report.Killed = append(report.Killed, c)
+		} else {
+			report.Removed = append(report.Removed, c)
+		}
+	}
+	return errs
+}
+
+// matchesSpec returns true if container is created by spec regardless of its replicas, with image of spec.
+func (dcs *DefaultClusterService) matchesSpec(c *Container, spec ContainerSpec) bool {
+	image := spec.Image
+	if image == "" && dcs.image != nil {
+		image = dcs.image.FullName
+	}
+	if c.Image == nil || c.Image.FullName != image {
+		return false
+	}
+	have := c.Spec
+	have.Replicas = 0
+	spec.Replicas = 0
+	return reflect.DeepEqual(have, spec)
+}
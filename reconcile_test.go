@@ -0,0 +1,137 @@
+package cluster
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDefaultClusterService_StartReconcileLoop(t *testing.T) {
+	clusterService, client := newTestRestartService(t)
+	container, err := clusterService.CreateContainerWithSpec(ContainerSpec{RestartPolicy: RestartPolicy{Name: RestartAlways}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	// runtime reports container exited
+	client.state = ContainerExited
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := clusterService.StartReconcileLoop(ctx, 5*time.Millisecond)
+	deadline := time.Now().Add(time.Second)
+	restartCount := 0
+	for restartCount == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+		if c, err := clusterService.GetContainer(container.Id); err == nil {
+			restartCount = c.RestartCount
+		}
+	}
+	if restartCount == 0 {
+		t.Errorf("not restarted:%v", container.Name)
+	}
+
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Error("reconcile loop not stopped")
+	}
+}
+
+func TestDefaultClusterService_Reconcile(t *testing.T) {
+	clusterService := newTestProbeService(t)
+	web := ContainerSpec{Name: "web", Replicas: 2, Env: []string{"PORT=80"}}
+	worker := ContainerSpec{Name: "worker"}
+
+	tests := []struct {
+		name    string
+		desired []ContainerSpec
+		// number of containers created, started, killed and removed
+		expected [4]int
+		running  map[string]int
+	}{
+		{"create", []ContainerSpec{web, worker}, [4]int{3, 0, 0, 0}, map[string]int{"web": 2, "worker": 1}},
+		{"converged", []ContainerSpec{web, worker}, [4]int{0, 0, 0, 0}, map[string]int{"web": 2, "worker": 1}},
+		{"scaleUp", []ContainerSpec{{Name: "web", Replicas: 3, Env: []string{"PORT=80"}}, worker}, [4]int{1, 0, 0, 0}, map[string]int{"web": 3, "worker": 1}},
+		{"scaleDown", []ContainerSpec{web, worker}, [4]int{0, 0, 1, 0}, map[string]int{"web": 2, "worker": 1}},
+		{"changed", []ContainerSpec{web, {Name: "worker", Command: []string{"work"}}}, [4]int{1, 0, 1, 0}, map[string]int{"web": 2, "worker": 1}},
+		{"dropped", []ContainerSpec{web}, [4]int{0, 0, 1, 0}, map[string]int{"web": 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := clusterService.Reconcile(tt.desired)
+			if err != nil {
+				t.Fatal(err)
+			}
+			have := [4]int{len(report.Created), len(report.Started), len(report.Killed), len(report.Removed)}
+			if have != tt.expected {
+				t.Errorf("want:%v,have:%v", tt.expected, have)
+			}
+			running := map[string]int{}
+			for _, c := range clusterService.RunningContainers() {
+				running[c.Labels[ReconcileLabel]]++
+			}
+			if !reflect.DeepEqual(running, tt.running) {
+				t.Errorf("want:%v,have:%v", tt.running, running)
+			}
+		})
+	}
+
+	// exited container is replaced
+	containers := clusterService.RunningContainers()
+	if err := clusterService.KillContainer(containers[0], time.Second); err != nil {
+		t.Fatal(err)
+	}
+	report, err := clusterService.Reconcile([]ContainerSpec{web})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Created) != 1 || len(report.Removed) != 1 || report.Removed[0].Id != containers[0].Id {
+		t.Errorf("unexpected report:%v", report)
+	}
+
+	if _, err := clusterService.Reconcile([]ContainerSpec{web, web}); err == nil {
+		t.Error("want error of duplicated name")
+	}
+}
+
+func TestDefaultClusterService_Reconcile_Unlocked(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	client := newBlockingContainerClient()
+	runTestNode(t, clusterService, client)
+
+	tests := []struct {
+		name    string
+		desired []ContainerSpec
+		call    string
+	}{
+		{"run", []ContainerSpec{{Name: "web"}}, "Run"},
+		{"kill", nil, "Stop"},
+	}
+	for _, tt := range tests {
+		client.release = make(chan struct{})
+		done := make(chan error, 1)
+		go func() {
+			_, err := clusterService.Reconcile(tt.desired)
+			done <- err
+		}()
+		if called := <-client.called; called != tt.call {
+			t.Fatalf("%v: want:%v,have:%v", tt.name, tt.call, called)
+		}
+		assertUnlocked(t, clusterService)
+		close(client.release)
+		if err := <-done; err != nil {
+			t.Fatalf("%v: %v", tt.name, err)
+		}
+		// drain calls after the blocked one
+		for len(client.called) > 0 {
+			<-client.called
+		}
+	}
+	if len(clusterService.containers) != 0 {
+		t.Errorf("%v", clusterService.containers)
+	}
+}
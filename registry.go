@@ -0,0 +1,60 @@
+package cluster
+
+import (
+	"sync"
+)
+
+// DefaultRegistry is registry of image whose Registry is empty.
+const DefaultRegistry = "docker.io"
+
+// RegistryAuth is credential to pull image from private registry.
+// fields are never serialized, and String does not show them.
+type RegistryAuth struct {
+	Username string `json:"-"`
+	Password string `json:"-"`
+	// token issued by registry, used instead of Password if set
+	IdentityToken string `json:"-"`
+}
+
+// String hides credential not to be logged.
+func (auth RegistryAuth) String() string {
+	return "RegistryAuth{" + auth.Username + ":***}"
+}
+
+// GoString hides credential printed by %#v.
+func (auth RegistryAuth) GoString() string {
+	return auth.String()
+}
+
+// registryAuths holds credentials of clients by registry host.
+type registryAuths struct {
+	mu    sync.RWMutex
+	auths map[string]RegistryAuth
+}
+
+// set register auth for registry, empty registry means DefaultRegistry.
+func (ra *registryAuths) set(registry string, auth RegistryAuth) {
+	ra.mu.Lock()
+	defer ra.mu.Unlock()
+	if ra.auths == nil {
+		ra.auths = make(map[string]RegistryAuth)
+	}
+	ra.auths[normalizeRegistry(registry)] = auth
+}
+
+// forImage returns auth registered for registry of image.
+func (ra *registryAuths) forImage(image *Image) (RegistryAuth, bool) {
+	ra.mu.RLock()
+	defer ra.mu.RUnlock()
+	auth, ok := ra.auths[normalizeRegistry(image.Registry)]
+	return auth, ok
+}
+
+func normalizeRegistry(registry string) string {
+	switch registry {
+	case "", "index.docker.io", "registry-1.docker.io":
+		return DefaultRegistry
+	default:
+		return registry
+	}
+}
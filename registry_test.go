@@ -0,0 +1,52 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFakeContainerClient_RegistryAuth(t *testing.T) {
+	client := NewFakeContainerClient("hash1")
+	private := RegistryAuth{Username: "user", Password: "secret"}
+	hub := RegistryAuth{Username: "hubuser", IdentityToken: "token"}
+	client.SetRegistryAuth("registry.example.com:5000", private)
+	client.SetRegistryAuth("", hub)
+
+	images := []string{
+		"registry.example.com:5000/app:1.0",
+		"nginx:latest",
+		"docker.io/library/redis",
+		"quay.io/coreos/etcd",
+	}
+	for _, reference := range images {
+		image, err := NewImage(reference)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := client.PullImage(context.Background(), image); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []RegistryAuth{private, hub, hub, RegistryAuth{}}
+	for i, auth := range client.PullAuths() {
+		if auth != want[i] {
+			t.Errorf("%v want:%v,have:%v", images[i], want[i], auth)
+		}
+	}
+}
+
+func TestRegistryAuth_Hidden(t *testing.T) {
+	auth := RegistryAuth{Username: "user", Password: "secret", IdentityToken: "token"}
+	data, err := json.Marshal(auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, printed := range []string{string(data), fmt.Sprintf("%v", auth), fmt.Sprintf("%+v", auth), fmt.Sprintf("%#v", auth)} {
+		if strings.Contains(printed, "secret") || strings.Contains(printed, "token") {
+			t.Errorf("want hidden,have:%v", printed)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ReplicaSetLabel is label of container holding name of its replica set.
+const ReplicaSetLabel = "cluster/replica-set"
+
+// ReplicaSet is named spec with number of running containers of it kept by the service.
+type ReplicaSet struct {
+	// unique name, same rule as node name, prefix of names of its replicas
+	Name string
+	Spec ContainerSpec
+	// number of running containers
+	Replicas int
+}
+
+// Clone returns deep copy of replica set.
+func (rs *ReplicaSet) Clone() *ReplicaSet {
+	if rs == nil {
+		return nil
+	}
+	clone := *rs
+	clone.Spec = rs.Spec.Clone()
+	return &clone
+}
+
+// EnsureReplicas register replica set of name, then keep exactly count running containers matching spec,
+// by creating missing ones and killing extra or outdated ones. replicas are named name-N unique in cluster.
+// replica sets are ensured again by reconcile loop, so replicas lost with their node are replaced.
+func (dcs *DefaultClusterService) EnsureReplicas(name string, spec ContainerSpec, count int) error {
+	return dcs.EnsureReplicasContext(context.Background(), name, spec, count)
+}
+
+// EnsureReplicasContext is EnsureReplicas which gives up when ctx is done.
+func (dcs *DefaultClusterService) EnsureReplicasContext(ctx context.Context, name string, spec ContainerSpec, count int) error {
+	if err := validateNodeName(name); err != nil {
+		return fmt.Errorf("invalid replica set name:%w", err)
+	}
+	if count < 0 {
+		return fmt.Errorf("invalid replicas:%v of replica set:%v", count, name)
+	}
+	spec = spec.Clone()
+	spec.Name = name
+	spec.Replicas = count
+	dcs.mu.Lock()
+	if dcs.replicaSets == nil {
+		dcs.replicaSets = make(map[string]*ReplicaSet)
+	}
+	dcs.replicaSets[name] = &ReplicaSet{Name: name, Spec: spec, Replicas: count}
+	dcs.mu.Unlock()
+	errs := dcs.converge(ctx, ReplicaSetLabel, []ContainerSpec{spec}, []int{count}, false, &ReconcileReport{})
+	return errors.Join(errs...)
+}
+
+// ensureReplicaSets converge replicas of all replica sets, errors are retried in the next round.
+func (dcs *DefaultClusterService) ensureReplicaSets(ctx context.Context) {
+	dcs.mu.RLock()
+	sets := make([]*ReplicaSet, 0, len(dcs.replicaSets))
+	for _, rs := range dcs.replicaSets {
+		sets = append(sets, rs.Clone())
+	}
+	dcs.mu.RUnlock()
+	// sets of higher priority take capacity first
+	sort.Slice(sets, func(i, j int) bool {
+		if sets[i].Spec.Priority != sets[j].Spec.Priority {
+			return sets[i].Spec.Priority > sets[j].Spec.Priority
+		}
+		return sets[i].Name < sets[j].Name
+	})
+	specs := make([]ContainerSpec, len(sets))
+	replicas := make([]int, len(sets))
+	for i, rs := range sets {
+		specs[i] = rs.Spec
+		replicas[i] = rs.Replicas
+	}
+	dcs.converge(ctx, ReplicaSetLabel, specs, replicas, false, &ReconcileReport{})
+}
+
+// GetReplicaSet returns replica set of name.
+func (dcs *DefaultClusterService) GetReplicaSet(name string) (*ReplicaSet, error) {
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
+	rs, ok := dcs.replicaSets[name]
+	if !ok {
+		return nil, fmt.Errorf("%w:%v", ErrReplicaSetNotFound, name)
+	}
+	return rs.Clone(), nil
+}
+
+// ReplicaSets returns replica sets sorted by name.
+func (dcs *DefaultClusterService) ReplicaSets() []*ReplicaSet {
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
+	sets := make([]*ReplicaSet, 0, len(dcs.replicaSets))
+	for _, rs := range dcs.replicaSets {
+		sets = append(sets, rs.Clone())
+	}
+	sort.Slice(sets, func(i, j int) bool { return sets[i].Name < sets[j].Name })
+	return sets
+}
+
+// Replicas returns containers of replica set of name, in order of creation.
+func (dcs *DefaultClusterService) Replicas(name string) (Containers, error) {
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
+	if _, ok := dcs.replicaSets[name]; !ok {
+		return nil, fmt.Errorf("%w:%v", ErrReplicaSetNotFound, name)
+	}
+	return dcs.managedContainers(ReplicaSetLabel, name).Clone(), nil
+}
+
+// replicaName returns name of new replica of set, not used by any container in cluster.
+func (dcs *DefaultClusterService) replicaName(set string) string {
+	names := make(map[string]bool, len(dcs.containers))
+	for _, c := range dcs.containers {
+		names[c.Name] = true
+	}
+	for i := 1; ; i++ {
+		name := fmt.Sprintf("%s-%d", set, i)
+		if !names[name] {
+			return name
+		}
+	}
+}
+
+// nameUsedOn returns true if name is used by container on node.
+func (dcs *DefaultClusterService) nameUsedOn(nodeId UID, name string) bool {
+	for _, c := range dcs.containersByNode[nodeId] {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// renameContainer set name of container and its status, keeping index by name.
+func (dcs *DefaultClusterService) renameContainer(c *Container, name string) {
+	dcs.unindexContainerStatus(c.ContainerStatus)
+	c.Name = name
+	c.ContainerStatus.Name = name
+	dcs.indexContainerStatus(c.ContainerStatus)
+}
@@ -0,0 +1,79 @@
+package cluster
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDefaultClusterService_EnsureReplicas(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	nodes := Nodes{}
+	for i := 0; i < 2; i++ {
+		node := runTestNode(t, clusterService, NewInMemoryContainerClient(0))
+		nodes = append(nodes, node)
+	}
+	spec := ContainerSpec{Env: []string{"PORT=80"}}
+
+	tests := []struct {
+		name     string
+		spec     ContainerSpec
+		count    int
+		expected []string
+	}{
+		{"create", spec, 3, []string{"web-1", "web-2", "web-3"}},
+		{"converged", spec, 3, []string{"web-1", "web-2", "web-3"}},
+		{"scaleDown", spec, 1, []string{"web-1"}},
+		{"scaleUp", spec, 2, []string{"web-1", "web-2"}},
+		{"changed", ContainerSpec{Env: []string{"PORT=8080"}}, 2, []string{"web-1", "web-2"}},
+		{"zero", spec, 0, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := clusterService.EnsureReplicas("web", tt.spec, tt.count); err != nil {
+				t.Fatal(err)
+			}
+			names := []string{}
+			for _, c := range clusterService.RunningContainers() {
+				names = append(names, c.Name)
+			}
+			if !reflect.DeepEqual(names, tt.expected) {
+				t.Errorf("want:%v,have:%v", tt.expected, names)
+			}
+		})
+	}
+
+	// replicas on dead node are rescheduled
+	if err := clusterService.EnsureReplicas("web", spec, 2); err != nil {
+		t.Fatal(err)
+	}
+	clusterService.SetHeartbeatTimeout(time.Millisecond)
+	nodes[0].ResourceProvider.(*FakeResourceProvider).StopNode(nodes[0])
+	time.Sleep(5 * time.Millisecond)
+	clusterService.FlushNodes()
+	if _, err := clusterService.CheckHeartbeats(); err != nil {
+		t.Fatal(err)
+	}
+	replicas, err := clusterService.Replicas("web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range replicas {
+		if c.NodeId != nodes[1].Id || containerStateOf(c) != ContainerRunning {
+			t.Errorf("want running on:%v,have:%v on %v", nodes[1].Name, containerStateOf(c), c.NodeName)
+		}
+	}
+
+	// replicas are not touched by Reconcile
+	if _, err := clusterService.Reconcile(nil); err != nil {
+		t.Fatal(err)
+	}
+	if have := len(clusterService.RunningContainers()); have != 2 {
+		t.Errorf("want:%v,have:%v", 2, have)
+	}
+
+	if _, err := clusterService.Replicas("db"); !errors.Is(err, ErrReplicaSetNotFound) {
+		t.Errorf("want:%v,have:%v", ErrReplicaSetNotFound, err)
+	}
+}
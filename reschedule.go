@@ -0,0 +1,105 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// RescheduleContainersFrom move containers on the node to other nodes selected by scheduler in order of priority,
+// and re-run them unless killed by KillContainer. returns containers failed to be placed or run.
+func (dcs *DefaultClusterService) RescheduleContainersFrom(nodeId UID) (Containers, error) {
+	return dcs.RescheduleContainersFromContext(context.Background(), nodeId)
+}
+
+// RescheduleContainersFromContext is RescheduleContainersFrom which gives up re-running containers when ctx is done.
+func (dcs *DefaultClusterService) RescheduleContainersFromContext(ctx context.Context, nodeId UID) (Containers, error) {
+	moved := &rescheduled{}
+	dcs.mu.Lock()
+	dcs.rescheduleContainersFrom(nodeId, moved)
+	dcs.mu.Unlock()
+	return dcs.runRescheduled(ctx, moved)
+}
+
+// rescheduled is result of moving containers, which are run by runRescheduled after lock is released.
+type rescheduled struct {
+	// owned containers to be run, with their snapshots taken under lock
+	runs      Containers
+	snapshots Containers
+	failed    Containers
+	reasons   []string
+}
+
+// rescheduleContainersFrom move containers on the node under lock, adding them to moved.
+func (dcs *DefaultClusterService) rescheduleContainersFrom(nodeId UID, moved *rescheduled) {
+	// rescheduled containers leave the index while iterating
+	for _, c := range byPriority(dcs.containersByNode[nodeId]) {
+		if err := dcs.rescheduleContainer(c); err != nil {
+			moved.fail(c, err)
+			continue
+		}
+		moved.add(c)
+	}
+}
+
+// add container to be run unless killed by KillContainer, with its snapshot. caller must hold lock.
+func (r *rescheduled) add(container *Container) {
+	if container.Killed {
+		return
+	}
+	r.runs = append(r.runs, container)
+	r.snapshots = append(r.snapshots, container.Clone())
+}
+
+func (r *rescheduled) fail(container *Container, err error) {
+	r.failed = append(r.failed, container)
+	r.reasons = append(r.reasons, fmt.Sprintf("%v:%v", container.Id, err))
+}
+
+// runRescheduled run moved containers in batch, and returns containers failed to be placed or run.
+// runtime is called without lock, so rescheduling containers of lost node does not block others.
+func (dcs *DefaultClusterService) runRescheduled(ctx context.Context, moved *rescheduled) (Containers, error) {
+	if len(moved.snapshots) > 0 {
+		errs, _ := dcs.RunContainersContext(ctx, moved.snapshots)
+		for i, err := range errs {
+			if err != nil {
+				moved.fail(moved.runs[i], err)
+			}
+		}
+	}
+	if len(moved.failed) > 0 {
+		dcs.mu.RLock()
+		defer dcs.mu.RUnlock()
+		return moved.failed.Clone(), fmt.Errorf("failed to reschedule containers:%v", strings.Join(moved.reasons, ", "))
+	}
+	return Containers{}, nil
+}
+
+func (dcs *DefaultClusterService) rescheduleContainer(container *Container) error {
+	node, err := dcs.minWorkingNode(container)
+	if err != nil {
+		return err
+	}
+	status := container.ContainerStatus
+	dcs.unindexContainerStatus(status)
+	dcs.unindexContainer(container)
+	// name is unique only in node, so it may be used on new node, except name of replica unique in cluster
+	if _, ok := container.Labels[ReplicaSetLabel]; !ok || dcs.nameUsedOn(node.Id, container.Name) {
+		container.Name = dcs.genContainerName(node.Id, container.Image)
+	}
+	container.NodeId = node.Id
+	container.NodeName = node.Name
+	container.Hash = ""
+	status.Name = container.Name
+	status.NodeName = node.Name
+	dcs.indexContainerStatus(status)
+	dcs.indexContainer(container)
+	dcs.bumpContainer(container)
+	if status.ContainerState == ContainerRunning || status.ContainerState == ContainerPaused {
+		// it was running on the dead node
+		if err := transitionContainer(status, ContainerExited, "node of container is lost"); err != nil {
+			return err
+		}
+	}
+	return transitionContainer(status, ContainerCreated, "rescheduled by RescheduleContainersFrom")
+}
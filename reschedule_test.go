@@ -0,0 +1,145 @@
+package cluster
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDefaultClusterService_RescheduleContainersFrom(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	nodes := Nodes{}
+	for i := 0; i < 2; i++ {
+		node := prepareTestNode(t, clusterService, func(node *Node) {
+			node.Client = &mockContainerClient{hash: "hash1"}
+			node.Capacity = Capacity{MemoryMB: 1024}
+		})
+		nodes = append(nodes, node)
+	}
+	containers := Containers{}
+	for _, request := range []int64{256, 768, 512} {
+		container, err := clusterService.CreateContainerWithSpec(ContainerSpec{ResourceRequests: Capacity{MemoryMB: request}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := clusterService.RunContainer(container); err != nil {
+			t.Fatal(err)
+		}
+		containers = append(containers, container)
+	}
+	// node-1 has 256 and 512, node-2 has 768
+	if containers[0].NodeId != nodes[0].Id || containers[1].NodeId != nodes[1].Id || containers[2].NodeId != nodes[0].Id {
+		t.Fatalf("%v,%v,%v", containers[0].NodeName, containers[1].NodeName, containers[2].NodeName)
+	}
+	if err := clusterService.KillNode(*nodes[0], 0); err != nil {
+		t.Fatal(err)
+	}
+
+	failed, err := clusterService.RescheduleContainersFrom(nodes[0].Id)
+	if err == nil {
+		t.Fatal("want error for container not placed")
+	}
+	if len(failed) != 1 || failed[0].Id != containers[2].Id {
+		t.Errorf("%v", failed)
+	}
+	moved, _ := clusterService.GetContainer(containers[0].Id)
+	if moved.NodeId != nodes[1].Id || moved.NodeName != nodes[1].Name || moved.ContainerStatus.NodeName != nodes[1].Name {
+		t.Errorf("%v", moved)
+	}
+	if moved.ContainerStatus.ContainerState != ContainerRunning {
+		t.Errorf("%v", moved.ContainerStatus)
+	}
+	client := nodes[1].Client.(*mockContainerClient)
+	if len(client.runs) != 2 || client.runs[1].Id != moved.Id {
+		t.Errorf("%v", client.runs)
+	}
+}
+
+func TestDefaultClusterService_ContainersOnNode(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	nodes := Nodes{}
+	for i := 0; i < 2; i++ {
+		node := runTestNode(t, clusterService, &mockContainerClient{hash: "hash1"})
+		nodes = append(nodes, node)
+	}
+	clusterService.SetOptions(ContainerOptions{})
+	first, err := clusterService.CreateContainerOn(nodes[0].Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := clusterService.CreateContainerOn(nodes[0].Id)
+	ids := func(nodeId UID) []UID {
+		containers, err := clusterService.ContainersOnNode(nodeId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res := []UID{}
+		for _, c := range containers {
+			res = append(res, c.Id)
+		}
+		return res
+	}
+	if have := ids(nodes[0].Id); !reflect.DeepEqual([]UID{first.Id, second.Id}, have) {
+		t.Errorf("want:%v,have:%v", []UID{first.Id, second.Id}, have)
+	}
+
+	// moved containers follow their node
+	if err := clusterService.KillNode(*nodes[0], 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := clusterService.RescheduleContainersFrom(nodes[0].Id); err != nil {
+		t.Fatal(err)
+	}
+	if have := ids(nodes[0].Id); len(have) != 0 {
+		t.Errorf("want:%v,have:%v", 0, have)
+	}
+	if have := ids(nodes[1].Id); !reflect.DeepEqual([]UID{first.Id, second.Id}, have) {
+		t.Errorf("want:%v,have:%v", []UID{first.Id, second.Id}, have)
+	}
+
+	if err := clusterService.ForceRemoveContainer(first.Id); err != nil {
+		t.Fatal(err)
+	}
+	if have := ids(nodes[1].Id); !reflect.DeepEqual([]UID{second.Id}, have) {
+		t.Errorf("want:%v,have:%v", []UID{second.Id}, have)
+	}
+	if _, err := clusterService.ContainersOnNode("unknown"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("want:%v,have:%v", ErrNodeNotFound, err)
+	}
+}
+
+func TestDefaultClusterService_RescheduleContainersFrom_Unlocked(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	lost := runTestNode(t, clusterService, NewFakeContainerClient("hash1"))
+	client := newBlockingContainerClient()
+	other := runTestNode(t, clusterService, client)
+	clusterService.SetOptions(ContainerOptions{})
+	container, err := clusterService.CreateContainerOn(lost.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.KillNode(*lost, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := clusterService.RescheduleContainersFrom(lost.Id)
+		done <- err
+	}()
+	if called := <-client.called; called != "Run" {
+		t.Fatalf("want:%v,have:%v", "Run", called)
+	}
+	assertUnlocked(t, clusterService)
+	close(client.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	container, _ = clusterService.GetContainer(container.Id)
+	if container.NodeId != other.Id || container.ContainerStatus.ContainerState != ContainerRunning {
+		t.Errorf("%v,%v", container.NodeName, container.ContainerStatus.ContainerState)
+	}
+}
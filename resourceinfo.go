@@ -0,0 +1,69 @@
+package cluster
+
+// well-known keys of ResourceInfo shared by resource providers.
+// providers may set other keys for their own data.
+const (
+	// id of vm instance
+	ResourceInstanceId = "instance-id"
+	// ip address of node reachable in cluster network
+	ResourcePrivateIP = "private-ip"
+	// ip address of node reachable from outside of cluster network
+	ResourcePublicIP = "public-ip"
+	// name of pod running node
+	ResourcePod = "pod"
+	// namespace of pod running node
+	ResourceNamespace = "namespace"
+)
+
+// Get returns value of key, empty if not set. it is safe on nil ResourceInfo.
+func (ri ResourceInfo) Get(key string) string {
+	return ri[key]
+}
+
+// Set set value of key, allocating map if ResourceInfo is nil.
+func (ri *ResourceInfo) Set(key string, value string) {
+	if *ri == nil {
+		*ri = ResourceInfo{}
+	}
+	(*ri)[key] = value
+}
+
+func (ri ResourceInfo) InstanceId() string {
+	return ri.Get(ResourceInstanceId)
+}
+
+func (ri *ResourceInfo) SetInstanceId(instanceId string) {
+	ri.Set(ResourceInstanceId, instanceId)
+}
+
+func (ri ResourceInfo) PrivateIP() string {
+	return ri.Get(ResourcePrivateIP)
+}
+
+func (ri *ResourceInfo) SetPrivateIP(ip string) {
+	ri.Set(ResourcePrivateIP, ip)
+}
+
+func (ri ResourceInfo) PublicIP() string {
+	return ri.Get(ResourcePublicIP)
+}
+
+func (ri *ResourceInfo) SetPublicIP(ip string) {
+	ri.Set(ResourcePublicIP, ip)
+}
+
+func (ri ResourceInfo) Pod() string {
+	return ri.Get(ResourcePod)
+}
+
+func (ri *ResourceInfo) SetPod(pod string) {
+	ri.Set(ResourcePod, pod)
+}
+
+func (ri ResourceInfo) Namespace() string {
+	return ri.Get(ResourceNamespace)
+}
+
+func (ri *ResourceInfo) SetNamespace(namespace string) {
+	ri.Set(ResourceNamespace, namespace)
+}
@@ -0,0 +1,35 @@
+package cluster
+
+import (
+	"testing"
+)
+
+func TestResourceInfo(t *testing.T) {
+	var resourceInfo ResourceInfo
+	if resourceInfo.InstanceId() != "" {
+		t.Errorf("want:empty,have:%v", resourceInfo.InstanceId())
+	}
+	resourceInfo.SetInstanceId("i-1")
+	resourceInfo.SetPrivateIP("10.0.0.1")
+	resourceInfo.SetPublicIP("203.0.113.1")
+	resourceInfo.SetPod("pod1")
+	resourceInfo.SetNamespace("cluster")
+	resourceInfo.Set("zone", "a")
+	tests := []struct {
+		key      string
+		expected string
+		have     string
+	}{
+		{ResourceInstanceId, "i-1", resourceInfo.InstanceId()},
+		{ResourcePrivateIP, "10.0.0.1", resourceInfo.PrivateIP()},
+		{ResourcePublicIP, "203.0.113.1", resourceInfo.PublicIP()},
+		{ResourcePod, "pod1", resourceInfo.Pod()},
+		{ResourceNamespace, "cluster", resourceInfo.Namespace()},
+		{"zone", "a", resourceInfo.Get("zone")},
+	}
+	for _, tt := range tests {
+		if tt.expected != tt.have || tt.expected != resourceInfo[tt.key] {
+			t.Errorf("want:%v,have:%v", tt.expected, tt.have)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+)
+
+// bumpContainer set next resource version to container after it is mutated.
+func (dcs *DefaultClusterService) bumpContainer(container *Container) {
+	dcs.resourceVersion++
+	container.ResourceVersion = dcs.resourceVersion
+}
+
+// bumpNode set next resource version to node after it is mutated.
+func (dcs *DefaultClusterService) bumpNode(node *Node) {
+	dcs.resourceVersion++
+	node.ResourceVersion = dcs.resourceVersion
+}
+
+// bump bump resource version of container or node of id.
+func (dcs *DefaultClusterService) bump(id UID) {
+	if container := dcs.findContainerById(id); container != nil {
+		dcs.bumpContainer(container)
+	} else if node := dcs.findNodeById(id); node != nil {
+		dcs.bumpNode(node)
+	}
+}
+
+// UpdateContainerIf apply update to container if its resource version is still resourceVersion,
+// otherwise returns ErrConflict. changes of Labels, Spec and ContainerOptions are kept, others are ignored
+// as they are changed by operations. spec is checked as UpdateContainer does, so changing immutable fields
+// fails with ErrImmutableField and resource limits of alive container are changed by its runtime.
+// It returns updated container.
+func (dcs *DefaultClusterService) UpdateContainerIf(uid UID, resourceVersion uint64, update func(container *Container) error) (*Container, error) {
+	return dcs.updateContainerIf(context.Background(), uid, resourceVersion, update)
+}
+
+// UpdateNodeIf apply update to node if its resource version is still resourceVersion,
+// otherwise returns ErrConflict. changes of Labels, Taints and Capacity are kept, others are ignored
+// as they are changed by operations. It returns updated node.
+func (dcs *DefaultClusterService) UpdateNodeIf(uid UID, resourceVersion uint64, update func(node *Node) error) (*Node, error) {
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	node := dcs.findNodeById(uid)
+	if node == nil {
+		return nil, fmt.Errorf("%w for uid:%v", ErrNodeNotFound, uid)
+	}
+	if node.ResourceVersion != resourceVersion {
+		return nil, fmt.Errorf("%w for uid:%v, want:%v, have:%v", ErrConflict, uid, resourceVersion, node.ResourceVersion)
+	}
+	updated := node.Clone()
+	if err := update(updated); err != nil {
+		return nil, err
+	}
+	node.Labels = updated.Labels
+	node.Taints = updated.Taints
+	node.Capacity = updated.Capacity
+	dcs.bumpNode(node)
+	return node.Clone(), nil
+}
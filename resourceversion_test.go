@@ -0,0 +1,131 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDefaultClusterService_ResourceVersion(t *testing.T) {
+	clusterService, _ := newTestRestartService(t)
+	container, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	created := container.ResourceVersion
+	if created == 0 {
+		t.Fatalf("want:bumped,have:%v", created)
+	}
+	clusterService.RunContainer(container)
+	if container.ResourceVersion <= created {
+		t.Errorf("want:>%v,have:%v", created, container.ResourceVersion)
+	}
+
+	node, _ := clusterService.CreateNode()
+	before := node.ResourceVersion
+	clusterService.Cordon(node.Id)
+	node, _ = clusterService.GetNode(node.Id)
+	if node.ResourceVersion <= before {
+		t.Errorf("want:>%v,have:%v", before, node.ResourceVersion)
+	}
+
+	var buf bytes.Buffer
+	if err := clusterService.SaveState(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded := NewDefaultClusterService("0.0.0", testImage)
+	if err := loaded.LoadState(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.resourceVersion != node.ResourceVersion {
+		t.Errorf("want:%v,have:%v", node.ResourceVersion, loaded.resourceVersion)
+	}
+}
+
+func TestDefaultClusterService_UpdateContainerIf(t *testing.T) {
+	clusterService, _ := newTestRestartService(t)
+	container, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	version := container.ResourceVersion
+	setLabel := func(c *Container) error {
+		c.Labels = map[string]string{"app": "web"}
+		// ignored as it is changed by operations
+		c.Killed = true
+		return nil
+	}
+	updated, err := clusterService.UpdateContainerIf(container.Id, version, setLabel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Labels["app"] != "web" || updated.Killed || updated.ResourceVersion <= version {
+		t.Errorf("unexpected update:%v", updated)
+	}
+
+	failure := errors.New("failure")
+	tests := []struct {
+		name     string
+		uid      UID
+		version  uint64
+		update   func(c *Container) error
+		expected error
+	}{
+		{"stale", container.Id, version, setLabel, ErrConflict},
+		{"updateFailed", container.Id, updated.ResourceVersion, func(c *Container) error { return failure }, failure},
+		{"notFound", "unknown", version, setLabel, ErrContainerNotFound},
+		{"immutable", container.Id, updated.ResourceVersion, func(c *Container) error {
+			c.Spec.Image = "nginx:latest"
+			c.Spec.ResourceRequests = Capacity{MemoryMB: 1 << 20}
+			return nil
+		}, ErrImmutableField},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := clusterService.UpdateContainerIf(tt.uid, tt.version, tt.update); !errors.Is(err, tt.expected) {
+				t.Errorf("want:%v,have:%v", tt.expected, err)
+			}
+		})
+	}
+	if have, _ := clusterService.GetContainer(container.Id); have.ResourceVersion != updated.ResourceVersion {
+		t.Errorf("want:%v,have:%v", updated.ResourceVersion, have.ResourceVersion)
+	}
+}
+
+func TestDefaultClusterService_UpdateNodeIf(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	node, _ := clusterService.CreateNode()
+	version := node.ResourceVersion
+	updated, err := clusterService.UpdateNodeIf(node.Id, version, func(n *Node) error {
+		n.Taints = []Taint{{Key: "dedicated", Effect: TaintNoSchedule}}
+		n.NodeState = NodeRunning
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updated.Taints) != 1 || updated.NodeState != NodeCreated {
+		t.Errorf("unexpected update:%v", updated)
+	}
+	if _, err := clusterService.UpdateNodeIf(node.Id, version, func(n *Node) error { return nil }); !errors.Is(err, ErrConflict) {
+		t.Errorf("want:%v,have:%v", ErrConflict, err)
+	}
+}
+
+func TestDefaultClusterService_UpdateContainerIf_Limits(t *testing.T) {
+	clusterService := newTestProbeService(t)
+	container, _ := clusterService.CreateContainerWithSpec(ContainerSpec{ResourceLimits: Capacity{MemoryMB: 256}})
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	updated, err := clusterService.UpdateContainerIf(container.Id, container.ResourceVersion, func(c *Container) error {
+		c.Spec.ResourceLimits = Capacity{MemoryMB: 512}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// limits of running container are changed by runtime
+	stats, err := clusterService.Stats(context.Background(), updated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.MemoryLimitBytes != 512<<20 {
+		t.Errorf("want:%v,have:%v", 512<<20, stats.MemoryLimitBytes)
+	}
+}
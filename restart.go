@@ -0,0 +1,136 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// RestartContainers re-run exited containers according to their restart policy in order of priority,
+// returns restarted containers. It continues on error and returns the first error.
+func (dcs *DefaultClusterService) RestartContainers() (Containers, error) {
+	return dcs.RestartContainersContext(context.Background())
+}
+
+// RestartContainersContext is RestartContainers which gives up when ctx is done.
+// runtime is called without lock, so slow runtime does not block others.
+func (dcs *DefaultClusterService) RestartContainersContext(ctx context.Context) (Containers, error) {
+	dcs.mu.Lock()
+	secrets := dcs.secrets
+	containers, snapshots, nodes := Containers{}, Containers{}, Nodes{}
+	for _, c := range byPriority(dcs.containers) {
+		// container in flight of other operation is restarted next time if it is still exited
+		if !c.shouldRestart() || dcs.acquireInFlight(c) != nil {
+			continue
+		}
+		containers = append(containers, c)
+		snapshots = append(snapshots, c.Clone())
+		nodes = append(nodes, dcs.findNodeById(c.NodeId).Clone())
+	}
+	dcs.mu.Unlock()
+
+	restarted := Containers{}
+	var firstErr error
+	for i, c := range containers {
+		apply, discard, err := dcs.restartOnClient(ctx, nodes[i], snapshots[i], secrets)
+		dcs.mu.Lock()
+		if err == nil {
+			// container may be changed or removed while restarting without lock
+			if dcs.findContainerById(c.Id) != c || !c.shouldRestart() {
+				err = fmt.Errorf("%w for uid:%v, changed while restarting", ErrConflict, c.Id)
+			} else {
+				err = apply(c)
+			}
+		}
+		dcs.releaseInFlight(c)
+		if err == nil {
+			restarted = append(restarted, c.Clone())
+		}
+		dcs.mu.Unlock()
+		// container run on runtime but not applied is orphaned, so it is discarded
+		if err != nil && discard != nil {
+			err = errors.Join(err, discard())
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return restarted, firstErr
+}
+
+// restartOnClient remove exited container on runtime and run it again with env populated from secrets,
+// and returns func to apply the result to container under lock, and func to discard the run if it is not applied,
+// which is nil if it did not run. container is snapshot, so it is changed.
+func (dcs *DefaultClusterService) restartOnClient(ctx context.Context, node *Node, container *Container, secrets SecretStore) (func(*Container) error, func() error, error) {
+	if node == nil {
+		return nil, nil, fmt.Errorf("%w for uid:%v", ErrNodeNotFound, container.NodeId)
+	}
+	if node.Client == nil {
+		return nil, nil, fmt.Errorf("%w:%v", ErrNodeHasNoClient, node.Name)
+	}
+	// remove exited container on runtime to reuse its name
+	if container.Hash != "" {
+		if err := node.Client.Remove(ctx, container); err != nil {
+			return nil, nil, err
+		}
+	}
+	ran, runErr := func() (*runResult, error) {
+		env, err := secretEnv(ctx, secrets, container.Spec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run container:%v, %w", container.Name, err)
+		}
+		container.Spec.Env = env
+		release, err := dcs.launches.acquire(ctx, node.Id, node.MaxConcurrentLaunches)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		return node.runOnClient(ctx, container)
+	}()
+	var discard func() error
+	if runErr == nil {
+		discard = func() error {
+			container.Hash = ran.hash
+			return discardOnClient(ctx, node, container)
+		}
+	}
+	return func(container *Container) error {
+		if err := transitionContainer(container.ContainerStatus, ContainerCreated, "restarted by RestartContainers"); err != nil {
+			return err
+		}
+		container.RestartCount++
+		if runErr != nil {
+			// keep exited to be restarted again, container is already removed on runtime
+			container.Hash = ""
+			TransitionContainer(container.ContainerStatus, ContainerExited)
+			return runErr
+		}
+		if err := ran.apply(container); err != nil {
+			return err
+		}
+		dcs.emit(EventContainerStarted, container.Id)
+		return nil
+	}, discard, nil
+}
+
+// shouldRestart returns true if container is exited and its restart policy allows to re-run.
+func (c *Container) shouldRestart() bool {
+	status := c.ContainerStatus
+	if status == nil || status.ContainerState != ContainerExited {
+		return false
+	}
+	policy := c.Spec.RestartPolicy
+	if policy.MaxRetries > 0 && c.RestartCount >= policy.MaxRetries {
+		return false
+	}
+	switch policy.Name {
+	case RestartAlways:
+		return true
+	case RestartUnlessStopped:
+		return !c.Killed
+	case RestartOnFailure:
+		return status.Failed()
+	default:
+		return false
+	}
+}
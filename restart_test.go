@@ -0,0 +1,257 @@
+package cluster
+
+import (
+	"errors"
+	"testing"
+)
+
+func newTestRestartService(t *testing.T) (*DefaultClusterService, *mockContainerClient) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	client := &mockContainerClient{hash: "hash1"}
+	runTestNode(t, clusterService, client)
+	return clusterService, client
+}
+
+// exitTestContainers make containers exited with exitCode on client, and flush it to cluster.
+func exitTestContainers(t *testing.T, clusterService *DefaultClusterService, client *FakeContainerClient, exitCode int, containers ...*Container) {
+	t.Helper()
+	for _, c := range containers {
+		client.SetExited(c.Id, exitCode)
+	}
+	if err := clusterService.FlushContainers(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDefaultClusterService_RestartContainers(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	client := NewFakeContainerClient("hash1")
+	runTestNode(t, clusterService, client)
+	policies := []RestartPolicy{
+		RestartPolicy{Name: RestartNo},
+		RestartPolicy{Name: RestartOnFailure, MaxRetries: 2},
+		RestartPolicy{Name: RestartAlways},
+		RestartPolicy{Name: RestartUnlessStopped},
+	}
+	containers := Containers{}
+	for _, policy := range policies {
+		container, err := clusterService.CreateContainerWithSpec(ContainerSpec{RestartPolicy: policy})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := clusterService.RunContainer(container); err != nil {
+			t.Fatal(err)
+		}
+		containers = append(containers, container)
+	}
+	// all containers exited with error
+	exitTestContainers(t, clusterService, client, 1, containers...)
+
+	restarted, err := clusterService.RestartContainers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(restarted) != 3 || restarted[0].Id != containers[1].Id || restarted[1].Id != containers[2].Id || restarted[2].Id != containers[3].Id {
+		t.Fatalf("%v", restarted)
+	}
+	if len(client.Removes()) != 3 {
+		t.Errorf("%v", client.Removes())
+	}
+	for _, c := range restarted {
+		if c.ContainerStatus.ContainerState != ContainerRunning || c.RestartCount != 1 {
+			t.Errorf("%v,%v", c.ContainerStatus, c.RestartCount)
+		}
+	}
+	if status, _ := clusterService.ContainerStatus(containers[0].Id, "", ""); status.ContainerState != ContainerExited {
+		t.Errorf("%v", status)
+	}
+}
+
+func TestDefaultClusterService_RestartContainers_MaxRetries(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	client := NewFakeContainerClient("hash1")
+	runTestNode(t, clusterService, client)
+	container, err := clusterService.CreateContainerWithSpec(ContainerSpec{RestartPolicy: RestartPolicy{Name: RestartOnFailure, MaxRetries: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		exitTestContainers(t, clusterService, client, 1, container)
+		if _, err := clusterService.RestartContainers(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	container, _ = clusterService.GetContainer(container.Id)
+	if container.RestartCount != 2 || container.ContainerStatus.ContainerState != ContainerExited {
+		t.Errorf("%v,%v", container.RestartCount, container.ContainerStatus)
+	}
+
+	// clean exit is not restarted by on-failure
+	clean, _ := clusterService.CreateContainerWithSpec(ContainerSpec{RestartPolicy: RestartPolicy{Name: RestartOnFailure}})
+	if err := clusterService.RunContainer(clean); err != nil {
+		t.Fatal(err)
+	}
+	exitTestContainers(t, clusterService, client, 0, clean)
+	if restarted, _ := clusterService.RestartContainers(); len(restarted) != 0 {
+		t.Errorf("%v", restarted)
+	}
+}
+
+func TestDefaultClusterService_RestartContainers_Killed(t *testing.T) {
+	clusterService, _ := newTestRestartService(t)
+	unlessStopped, _ := clusterService.CreateContainerWithSpec(ContainerSpec{RestartPolicy: RestartPolicy{Name: RestartUnlessStopped}})
+	always, _ := clusterService.CreateContainerWithSpec(ContainerSpec{RestartPolicy: RestartPolicy{Name: RestartAlways}})
+	for _, c := range []*Container{unlessStopped, always} {
+		if err := clusterService.RunContainer(c); err != nil {
+			t.Fatal(err)
+		}
+		if err := clusterService.KillContainer(c, DefaultStopGracePeriod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	restarted, err := clusterService.RestartContainers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(restarted) != 1 || restarted[0].Id != always.Id {
+		t.Errorf("%v", restarted)
+	}
+}
+
+func TestContainerStatus_Failed(t *testing.T) {
+	tests := []struct {
+		name   string
+		status ContainerStatus
+		want   bool
+	}{
+		{"running", ContainerStatus{ContainerState: ContainerRunning, ExitCode: NoExitCode}, false},
+		{"clean exit", ContainerStatus{ContainerState: ContainerExited, ExitCode: 0}, false},
+		{"crash", ContainerStatus{ContainerState: ContainerExited, ExitCode: 137}, true},
+		{"unknown code with error", ContainerStatus{ContainerState: ContainerExited, ExitCode: NoExitCode, Error: errors.New("oom")}, true},
+		{"unknown code", ContainerStatus{ContainerState: ContainerExited, ExitCode: NoExitCode}, false},
+	}
+	for _, tt := range tests {
+		if have := tt.status.Failed(); have != tt.want {
+			t.Errorf("%v want:%v,have:%v", tt.name, tt.want, have)
+		}
+	}
+}
+
+func TestDefaultClusterService_RestartContainers_ExitCode(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	client := NewFakeContainerClient("hash1")
+	runTestNode(t, clusterService, client)
+	crashed, _ := clusterService.CreateContainerWithSpec(ContainerSpec{RestartPolicy: RestartPolicy{Name: RestartOnFailure}})
+	clean, _ := clusterService.CreateContainerWithSpec(ContainerSpec{RestartPolicy: RestartPolicy{Name: RestartOnFailure}})
+	for _, c := range []*Container{crashed, clean} {
+		if err := clusterService.RunContainer(c); err != nil {
+			t.Fatal(err)
+		}
+		if c.ContainerStatus.ExitCode != NoExitCode {
+			t.Errorf("want:%v,have:%v", NoExitCode, c.ContainerStatus.ExitCode)
+		}
+	}
+	client.SetExited(crashed.Id, 2)
+	client.SetExited(clean.Id, 0)
+	if err := clusterService.FlushContainers(); err != nil {
+		t.Fatal(err)
+	}
+	crashed, _ = clusterService.GetContainer(crashed.Id)
+	clean, _ = clusterService.GetContainer(clean.Id)
+	if crashed.ContainerStatus.ExitCode != 2 || clean.ContainerStatus.ExitCode != 0 {
+		t.Errorf("want:%v,%v,have:%v,%v", 2, 0, crashed.ContainerStatus.ExitCode, clean.ContainerStatus.ExitCode)
+	}
+
+	restarted, err := clusterService.RestartContainers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(restarted) != 1 || restarted[0].Id != crashed.Id {
+		t.Errorf("want:%v,have:%v", crashed, restarted)
+	}
+	crashed, _ = clusterService.GetContainer(crashed.Id)
+	if crashed.ContainerStatus.ExitCode != NoExitCode {
+		t.Errorf("want:%v,have:%v", NoExitCode, crashed.ContainerStatus.ExitCode)
+	}
+}
+
+func TestDefaultClusterService_RestartContainers_RunFailed(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	client := NewInMemoryContainerClient(0)
+	runTestNode(t, clusterService, client)
+	container, _ := clusterService.CreateContainerWithSpec(ContainerSpec{RestartPolicy: RestartPolicy{Name: RestartAlways}})
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	client.ExitContainer(container, 1, 0)
+	if err := clusterService.FlushContainers(); err != nil {
+		t.Fatal(err)
+	}
+
+	// container removed on runtime but failed to run again stays exited without hash
+	client.InjectError("Run", errors.New("daemon down"))
+	if _, err := clusterService.RestartContainers(); err == nil {
+		t.Fatal("want error for failed run")
+	}
+	container, _ = clusterService.GetContainer(container.Id)
+	if container.ContainerStatus.ContainerState != ContainerExited || container.Hash != "" || container.RestartCount != 1 {
+		t.Fatalf("%v,%v,%v", container.ContainerStatus, container.Hash, container.RestartCount)
+	}
+
+	// so it is restarted once runtime recovers
+	client.InjectError("Run", nil)
+	restarted, err := clusterService.RestartContainers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	container, _ = clusterService.GetContainer(container.Id)
+	if len(restarted) != 1 || container.ContainerStatus.ContainerState != ContainerRunning || container.RestartCount != 2 {
+		t.Errorf("%v,%v,%v", restarted, container.ContainerStatus, container.RestartCount)
+	}
+}
+
+func TestDefaultClusterService_RestartContainers_Conflict(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	client := newBlockingContainerClient()
+	node := runTestNode(t, clusterService, client.FakeContainerClient)
+	container, _ := clusterService.CreateContainerWithSpec(ContainerSpec{RestartPolicy: RestartPolicy{Name: RestartAlways}})
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	exitTestContainers(t, clusterService, client.FakeContainerClient, 1, container)
+	// runtime blocks after container exits
+	if err := clusterService.SetNodeClient(node.Id, client); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := clusterService.RestartContainers()
+		done <- err
+	}()
+	if called := <-client.called; called != "Remove" {
+		t.Fatalf("want:%v,have:%v", "Remove", called)
+	}
+	// restart policy is changed while restarting, so the new run is not applied
+	current, _ := clusterService.GetContainer(container.Id)
+	if _, err := clusterService.UpdateContainerIf(container.Id, current.ResourceVersion, func(c *Container) error {
+		c.Spec.RestartPolicy = RestartPolicy{Name: RestartNo}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	close(client.release)
+	if err := <-done; !errors.Is(err, ErrConflict) {
+		t.Fatalf("want:%v,have:%v", ErrConflict, err)
+	}
+	if len(client.Runs()) != 2 || len(client.Stops()) != 1 || len(client.Removes()) != 2 {
+		t.Errorf("%v,%v,%v", client.Runs(), client.Stops(), client.Removes())
+	}
+	container, _ = clusterService.GetContainer(container.Id)
+	if container.Hash != "hash1" || container.ContainerStatus.ContainerState != ContainerExited {
+		t.Errorf("%v,%v", container.Hash, container.ContainerStatus)
+	}
+}
@@ -0,0 +1,113 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// RetryPolicy is how RetryClusterService retries failed calls.
+type RetryPolicy struct {
+	// max number of calls including the first one, 1 disables retry
+	MaxAttempts int
+	// wait before the first retry, doubled on each retry
+	InitialBackoff time.Duration
+	// upper bound of wait, 0 for no bound
+	MaxBackoff time.Duration
+	// returns true if call failed with err may succeed by retry, DefaultRetryable if nil
+	Retryable func(err error) bool
+}
+
+// DefaultRetryPolicy retries transient errors twice, waiting 100ms then 200ms.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+}
+
+// permanentErrors are errors of cluster which retry never resolves.
+var permanentErrors = []error{
+	ErrNodeNotFound,
+	ErrContainerNotFound,
+	ErrAlreadyRunning,
+	ErrNotRunning,
+	ErrAlreadyExited,
+	ErrIllegalTransition,
+	ErrNoResourceProvider,
+	ErrUnknownRuntime,
+	ErrConflict,
+	context.Canceled,
+	context.DeadlineExceeded,
+}
+
+// DefaultRetryable returns true for network errors and errors with 5xx status code,
+// which report StatusCode() int like responses of cloud APIs. other errors are permanent.
+func DefaultRetryable(err error) bool {
+	for _, permanent := range permanentErrors {
+		if errors.Is(err, permanent) {
+			return false
+		}
+	}
+	var statusErr interface{ StatusCode() int }
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode() >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// RetryClusterService is ClusterService retrying RunContainer, RunNode and KillNode with exponential backoff.
+// other calls are not retried.
+type RetryClusterService struct {
+	ClusterService
+	policy RetryPolicy
+}
+
+var _ ClusterService = (*RetryClusterService)(nil)
+
+// NewRetryClusterService wraps service to retry calls by policy.
+func NewRetryClusterService(service ClusterService, policy RetryPolicy) (*RetryClusterService, error) {
+	if policy.MaxAttempts < 1 {
+		return nil, fmt.Errorf("invalid max attempts:%v", policy.MaxAttempts)
+	}
+	if policy.InitialBackoff < 0 || policy.MaxBackoff < 0 {
+		return nil, fmt.Errorf("invalid backoff, initial:%v, max:%v", policy.InitialBackoff, policy.MaxBackoff)
+	}
+	if policy.Retryable == nil {
+		policy.Retryable = DefaultRetryable
+	}
+	return &RetryClusterService{ClusterService: service, policy: policy}, nil
+}
+
+// retry call until it succeeds, fails with error not retryable, or reaches max attempts.
+func (rcs *RetryClusterService) retry(call func() error) error {
+	backoff := rcs.policy.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := call()
+		if err == nil || !rcs.policy.Retryable(err) {
+			return err
+		}
+		if attempt >= rcs.policy.MaxAttempts {
+			return fmt.Errorf("%w, gave up after %d attempts", err, attempt)
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+		if rcs.policy.MaxBackoff > 0 && backoff > rcs.policy.MaxBackoff {
+			backoff = rcs.policy.MaxBackoff
+		}
+	}
+}
+
+func (rcs *RetryClusterService) RunContainer(container *Container) error {
+	return rcs.retry(func() error { return rcs.ClusterService.RunContainer(container) })
+}
+
+func (rcs *RetryClusterService) RunNode(node *Node) error {
+	return rcs.retry(func() error { return rcs.ClusterService.RunNode(node) })
+}
+
+func (rcs *RetryClusterService) KillNode(runningNode Node, gracePeriod int) error {
+	return rcs.retry(func() error { return rcs.ClusterService.KillNode(runningNode, gracePeriod) })
+}
@@ -0,0 +1,104 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+type statusCodeError int
+
+func (e statusCodeError) Error() string   { return fmt.Sprintf("status:%d", int(e)) }
+func (e statusCodeError) StatusCode() int { return int(e) }
+
+func TestDefaultRetryable(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"network", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{"serverError", fmt.Errorf("wrapped:%w", statusCodeError(503)), true},
+		{"clientError", statusCodeError(404), false},
+		{"notFound", fmt.Errorf("%w for uid:%v", ErrNodeNotFound, "uid"), false},
+		{"conflict", ErrConflict, false},
+		{"unknown", errors.New("unknown"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if have := DefaultRetryable(tt.err); have != tt.expected {
+				t.Errorf("want:%v,have:%v", tt.expected, have)
+			}
+		})
+	}
+}
+
+func TestRetryClusterService_RunNode(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+	tests := []struct {
+		name      string
+		err       error
+		retryable func(err error) bool
+		calls     int
+	}{
+		{"transient", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, nil, 3},
+		{"permanent", statusCodeError(400), nil, 1},
+		{"custom", errors.New("flaky"), func(err error) bool { return true }, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusterService := NewDefaultClusterService("0.0.0", testImage)
+			provider := NewFakeResourceProvider(ResourceInfo{})
+			provider.Err = tt.err
+			node, _ := clusterService.CreateNode()
+			node, err := clusterService.PrepareNode(node.Id, func(node *Node) error {
+				node.ResourceProvider = provider
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			policy.Retryable = tt.retryable
+			service, err := NewRetryClusterService(clusterService, policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := service.RunNode(node); !errors.Is(err, tt.err) {
+				t.Errorf("want:%v,have:%v", tt.err, err)
+			}
+			if run, _, _ := provider.Calls(); run != tt.calls {
+				t.Errorf("want:%v,have:%v", tt.calls, run)
+			}
+		})
+	}
+}
+
+func TestRetryClusterService_RunNode_Recovered(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	provider := NewFakeResourceProvider(ResourceInfo{})
+	provider.Err = statusCodeError(502)
+	node, _ := clusterService.CreateNode()
+	node, err := clusterService.PrepareNode(node.Id, func(node *Node) error {
+		node.ResourceProvider = provider
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	service, _ := NewRetryClusterService(clusterService, RetryPolicy{
+		MaxAttempts: 3,
+		Retryable: func(err error) bool {
+			// recovers before the next attempt
+			provider.Err = nil
+			return DefaultRetryable(err)
+		},
+	})
+	if err := service.RunNode(node); err != nil {
+		t.Fatal(err)
+	}
+	if node.NodeState != NodeRunning {
+		t.Errorf("want:%v,have:%v", NodeRunning, node.NodeState)
+	}
+}
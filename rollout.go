@@ -0,0 +1,102 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// RollingReplace replace running containers matching selector with new ones of newImage,
+// at most maxUnavailable at a time. Each new container is created with spec, labels and namespace of old one,
+// and old one is killed after new one is running, and healthy if it has health check.
+// If a new container exits, gets unhealthy or is not ready within ready timeout, it is removed,
+// no more replacement starts, and the error is returned after replacements in flight finished.
+func (dcs *DefaultClusterService) RollingReplace(selector map[string]string, newImage *Image, maxUnavailable int) error {
+	return dcs.RollingReplaceContext(context.Background(), selector, newImage, maxUnavailable)
+}
+
+// RollingReplaceContext is RollingReplace which gives up when ctx is done.
+func (dcs *DefaultClusterService) RollingReplaceContext(ctx context.Context, selector map[string]string, newImage *Image, maxUnavailable int) error {
+	if newImage == nil {
+		return errors.New("image required")
+	}
+	if maxUnavailable < 1 {
+		maxUnavailable = 1
+	}
+	targets := Containers{}
+	for _, c := range dcs.SelectContainers(selector) {
+		if containerStateOf(c) == ContainerRunning {
+			targets = append(targets, c)
+		}
+	}
+
+	var mu sync.Mutex
+	var errs []error
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(errs) > 0
+	}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxUnavailable)
+	for _, old := range targets {
+		sem <- struct{}{}
+		if failed() || ctx.Err() != nil {
+			<-sem
+			break
+		}
+		wg.Add(1)
+		go func(old *Container) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := dcs.replaceContainer(ctx, old, newImage); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}(old)
+	}
+	wg.Wait()
+	if len(errs) == 0 && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return errors.Join(errs...)
+}
+
+// replaceContainer run new container of image in place of old one, then kill old one.
+func (dcs *DefaultClusterService) replaceContainer(ctx context.Context, old *Container, image *Image) error {
+	spec := old.Spec.Clone()
+	// spec follows new image, not to run old one when replacement is run again
+	spec.Image = image.FullName
+	dcs.mu.Lock()
+	replacement, err := dcs.newContainerWithSpec(spec)
+	if err == nil {
+		replacement.Image = image
+		replacement.Namespace = old.Namespace
+		replacement.ContainerStatus.Namespace = old.Namespace
+		replacement.Labels = cloneStringMap(old.Labels)
+		replacement.ContainerOptions = cloneStringMap(old.ContainerOptions)
+		err = dcs.placeContainer(replacement, nil)
+	}
+	if err == nil {
+		replacement = replacement.Clone()
+	}
+	dcs.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to create replacement of %v: %w", old.Name, err)
+	}
+	// runtime is called without lock, so replacements in flight run concurrently
+	err = dcs.RunContainerContext(ctx, replacement)
+	if err == nil {
+		err = dcs.waitForReady(ctx, replacement.Id)
+	}
+	if err != nil {
+		// do not leave replacement which is not ready, ctx may be done already
+		if removeErr := dcs.ForceRemoveContainer(replacement.Id); removeErr != nil {
+			err = errors.Join(err, removeErr)
+		}
+		return fmt.Errorf("replacement of %v is not ready: %w", old.Name, err)
+	}
+	return dcs.KillContainerContext(ctx, old, DefaultStopGracePeriod)
+}
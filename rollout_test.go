@@ -0,0 +1,145 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDefaultClusterService_RollingReplace(t *testing.T) {
+	clusterService, _ := newTestRestartService(t)
+	olds := Containers{}
+	for i := 0; i < 3; i++ {
+		c, _ := clusterService.CreateContainerWithSpec(ContainerSpec{Env: []string{"A=1"}})
+		labelTestContainer(t, clusterService, c, map[string]string{"app": "web"})
+		clusterService.RunContainer(c)
+		olds = append(olds, c)
+	}
+	db, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	labelTestContainer(t, clusterService, db, map[string]string{"app": "db"})
+	clusterService.RunContainer(db)
+
+	newImage, _ := NewImage("image:new")
+	if err := clusterService.RollingReplace(map[string]string{"app": "web"}, newImage, 2); err != nil {
+		t.Fatal(err)
+	}
+	for _, old := range olds {
+		if state, _ := clusterService.containerState(old.Id); state != ContainerExited {
+			t.Errorf("want:%v,have:%v", ContainerExited, state)
+		}
+	}
+	if state, _ := clusterService.containerState(db.Id); state != ContainerRunning {
+		t.Errorf("want:%v,have:%v", ContainerRunning, state)
+	}
+	replaced := clusterService.FilterContainers(func(c *Container) bool {
+		return c.Labels["app"] == "web" && containerStateOf(c) == ContainerRunning
+	})
+	if len(replaced) != len(olds) {
+		t.Fatalf("want:%v,have:%v", len(olds), len(replaced))
+	}
+	for _, c := range replaced {
+		if c.Image.FullName != newImage.FullName || c.Spec.Image != newImage.FullName || len(c.Spec.Env) != 1 || c.Namespace != DefaultNamespace {
+			t.Errorf("unexpected replacement:%v", c)
+		}
+	}
+}
+
+func TestDefaultClusterService_RollingReplace_NotReady(t *testing.T) {
+	clusterService, _ := newTestRestartService(t)
+	// replacements never get healthy as health check is not run
+	spec := ContainerSpec{HealthCheck: &HealthCheck{Command: []string{"true"}}}
+	olds := Containers{}
+	for i := 0; i < 2; i++ {
+		c, _ := clusterService.CreateContainerWithSpec(spec)
+		labelTestContainer(t, clusterService, c, map[string]string{"app": "web"})
+		clusterService.RunContainer(c)
+		olds = append(olds, c)
+	}
+	clusterService.SetReadyTimeout(20 * time.Millisecond)
+
+	newImage, _ := NewImage("image:new")
+	if err := clusterService.RollingReplace(map[string]string{"app": "web"}, newImage, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want:%v,have:%v", context.DeadlineExceeded, err)
+	}
+	for _, old := range olds {
+		if state, _ := clusterService.containerState(old.Id); state != ContainerRunning {
+			t.Errorf("want:%v,have:%v", ContainerRunning, state)
+		}
+	}
+	// only first replacement was tried, and it is removed
+	all, _ := clusterService.Containers(true)
+	if len(all) != len(olds) {
+		t.Errorf("unexpected containers:%v", all)
+	}
+}
+
+func TestDefaultClusterService_RollingReplace_Unhealthy(t *testing.T) {
+	clusterService := newTestProbeService(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clusterService.StartHealthCheck(ctx)
+	// replacement gets unhealthy soon, old ones keep running as health check does not kill them
+	spec := ContainerSpec{HealthCheck: &HealthCheck{Command: []string{"false"}, Interval: 10 * time.Millisecond, Retries: 1}}
+	olds := Containers{}
+	for i := 0; i < 2; i++ {
+		c, _ := clusterService.CreateContainerWithSpec(spec)
+		labelTestContainer(t, clusterService, c, map[string]string{"app": "web"})
+		if err := clusterService.RunContainer(c); err != nil {
+			t.Fatal(err)
+		}
+		olds = append(olds, c)
+	}
+
+	newImage, _ := NewImage("image:new")
+	done := make(chan error, 1)
+	go func() { done <- clusterService.RollingReplace(map[string]string{"app": "web"}, newImage, 1) }()
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrUnexpectedState) {
+			t.Errorf("want:%v,have:%v", ErrUnexpectedState, err)
+		}
+	// readiness is polled every second as health does not emit event
+	case <-time.After(5 * time.Second):
+		t.Fatal("unhealthy replacement is waited for")
+	}
+	for _, old := range olds {
+		if state, _ := clusterService.containerState(old.Id); state != ContainerRunning {
+			t.Errorf("want:%v,have:%v", ContainerRunning, state)
+		}
+	}
+	all, _ := clusterService.Containers(true)
+	if len(all) != len(olds) {
+		t.Errorf("unexpected containers:%v", all)
+	}
+}
+
+func TestDefaultClusterService_RollingReplace_Unlocked(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	client := newBlockingContainerClient()
+	node := runTestNode(t, clusterService, client.FakeContainerClient)
+	old, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	labelTestContainer(t, clusterService, old, map[string]string{"app": "web"})
+	if err := clusterService.RunContainer(old); err != nil {
+		t.Fatal(err)
+	}
+	// runtime blocks after container runs
+	if err := clusterService.SetNodeClient(node.Id, client); err != nil {
+		t.Fatal(err)
+	}
+
+	newImage, _ := NewImage("image:new")
+	done := make(chan error, 1)
+	go func() { done <- clusterService.RollingReplace(map[string]string{"app": "web"}, newImage, 1) }()
+	if called := <-client.called; called != "Run" {
+		t.Fatalf("want:%v,have:%v", "Run", called)
+	}
+	assertUnlocked(t, clusterService)
+	close(client.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if state, _ := clusterService.containerState(old.Id); state != ContainerExited {
+		t.Errorf("want:%v,have:%v", ContainerExited, state)
+	}
+}
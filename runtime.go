@@ -0,0 +1,75 @@
+package cluster
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// container runtimes known by NewContainerClient.
+const (
+	RuntimeDocker     = "docker"
+	RuntimePodman     = "podman"
+	RuntimeContainerd = "containerd"
+	// simulated in memory, endpoint is ignored
+	RuntimeInMemory = "memory"
+)
+
+// ContainerClientFactory create client connecting to endpoint of runtime.
+type ContainerClientFactory func(endpoint string) (ContainerClient, error)
+
+var containerRuntimes = struct {
+	mu        sync.RWMutex
+	factories map[string]ContainerClientFactory
+}{factories: make(map[string]ContainerClientFactory)}
+
+// RegisterContainerRuntime make runtime available to NewContainerClient, replacing registered one.
+func RegisterContainerRuntime(runtime string, factory ContainerClientFactory) {
+	containerRuntimes.mu.Lock()
+	defer containerRuntimes.mu.Unlock()
+	containerRuntimes.factories[runtime] = factory
+}
+
+// ContainerRuntimes returns names of registered runtimes in order.
+func ContainerRuntimes() []string {
+	containerRuntimes.mu.RLock()
+	defer containerRuntimes.mu.RUnlock()
+	runtimes := []string{}
+	for runtime := range containerRuntimes.factories {
+		runtimes = append(runtimes, runtime)
+	}
+	sort.Strings(runtimes)
+	return runtimes
+}
+
+// NewContainerClient create client of runtime(ex: docker) connecting to endpoint(ex: unix:///var/run/docker.sock).
+// each node can have client of different runtime.
+func NewContainerClient(runtime string, endpoint string) (ContainerClient, error) {
+	containerRuntimes.mu.RLock()
+	factory, ok := containerRuntimes.factories[runtime]
+	containerRuntimes.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w:%v", ErrUnknownRuntime, runtime)
+	}
+	return factory(endpoint)
+}
+
+// parseRuntimeTime parse time reported by runtime, zero time if invalid.
+func parseRuntimeTime(value string) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// sortPorts sort ports reported by runtime by container port then host port.
+func sortPorts(ports []PortMapping) {
+	sort.Slice(ports, func(i, j int) bool {
+		if ports[i].ContainerPort != ports[j].ContainerPort {
+			return ports[i].ContainerPort < ports[j].ContainerPort
+		}
+		return ports[i].HostPort < ports[j].HostPort
+	})
+}
@@ -0,0 +1,48 @@
+package cluster
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewContainerClient(t *testing.T) {
+	fake := NewFakeContainerClient("hash1")
+	RegisterContainerRuntime("fake", func(endpoint string) (ContainerClient, error) {
+		if endpoint == "" {
+			return nil, errors.New("no endpoint")
+		}
+		return fake, nil
+	})
+	tests := []struct {
+		name     string
+		runtime  string
+		endpoint string
+		want     ContainerClient
+		wantErr  bool
+	}{
+		{"registered", "fake", "fake.sock", fake, false},
+		{"factoryError", "fake", "", nil, true},
+		{"unknown", "unknown", "unknown.sock", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewContainerClient(tt.runtime, tt.endpoint)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want:%v,have:%v", tt.wantErr, err)
+			}
+			if client != tt.want {
+				t.Errorf("want:%v,have:%v", tt.want, client)
+			}
+		})
+	}
+	if _, err := NewContainerClient("unknown", ""); !errors.Is(err, ErrUnknownRuntime) {
+		t.Errorf("want:%v,have:%v", ErrUnknownRuntime, err)
+	}
+	found := false
+	for _, runtime := range ContainerRuntimes() {
+		found = found || runtime == RuntimeContainerd
+	}
+	if !found {
+		t.Errorf("want:%v,have:%v", RuntimeContainerd, ContainerRuntimes())
+	}
+}
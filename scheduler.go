@@ -0,0 +1,148 @@
+package cluster
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// Scheduler selects node to place container.
+type Scheduler interface {
+	// select node from running nodes satisfying affinity of container, returns error if no node fits container.
+	Select(nodes []*Node, container *Container) (*Node, error)
+}
+
+// LeastLoadedScheduler selects node with the largest ratio of free capacity.
+type LeastLoadedScheduler struct{}
+
+func (LeastLoadedScheduler) Select(nodes []*Node, container *Container) (*Node, error) {
+	return selectNode(nodes, container, func(node, selected *Node) bool {
+		return node.FreeRatio() > selected.FreeRatio()
+	})
+}
+
+// SpreadScheduler selects node with the fewest containers.
+type SpreadScheduler struct{}
+
+func (SpreadScheduler) Select(nodes []*Node, container *Container) (*Node, error) {
+	return selectNode(nodes, container, func(node, selected *Node) bool {
+		return node.ContainerCount < selected.ContainerCount
+	})
+}
+
+// BinpackScheduler selects node with the smallest ratio of free capacity, to fill nodes one by one.
+type BinpackScheduler struct{}
+
+func (BinpackScheduler) Select(nodes []*Node, container *Container) (*Node, error) {
+	return selectNode(nodes, container, func(node, selected *Node) bool {
+		return node.FreeRatio() < selected.FreeRatio()
+	})
+}
+
+// WeightedScheduler selects node randomly with probability proportional to its weight,
+// free memory by default, so that bigger nodes get proportionally more containers.
+type WeightedScheduler struct {
+	// returns weight of node, node of weight 0 or less is selected only if all nodes are so.
+	// FreeMemoryWeight if nil
+	Weight func(node *Node, nodes []*Node) float64
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// NewWeightedScheduler create scheduler whose selections are determined by seed.
+func NewWeightedScheduler(seed int64) *WeightedScheduler {
+	return &WeightedScheduler{rand: rand.New(rand.NewSource(seed))}
+}
+
+// FreeMemoryWeight returns free memory of node in MB. node of unlimited memory weighs as the largest
+// free memory of the others, or 1 if all of them are unlimited.
+func FreeMemoryWeight(node *Node, nodes []*Node) float64 {
+	if node.Capacity.MemoryMB > 0 {
+		return float64(node.FreeCapacity().MemoryMB)
+	}
+	var largest int64
+	for _, n := range nodes {
+		if free := n.FreeCapacity().MemoryMB; n.Capacity.MemoryMB > 0 && free > largest {
+			largest = free
+		}
+	}
+	if largest == 0 {
+		return 1
+	}
+	return float64(largest)
+}
+
+func (ws *WeightedScheduler) Select(nodes []*Node, container *Container) (*Node, error) {
+	candidates := []*Node{}
+	schedulable := 0
+	for _, node := range nodes {
+		if !node.Schedulable() {
+			continue
+		}
+		schedulable++
+		if node.Fits(container.Spec.ResourceRequests) {
+			candidates = append(candidates, node)
+		}
+	}
+	if schedulable == 0 {
+		return nil, ErrNoValidNode
+	}
+	if len(candidates) == 0 {
+		return nil, ErrInsufficientCapacity
+	}
+	weight := ws.Weight
+	if weight == nil {
+		weight = FreeMemoryWeight
+	}
+	weights := make([]float64, len(candidates))
+	total := 0.0
+	for i, node := range candidates {
+		if w := weight(node, candidates); w > 0 {
+			weights[i] = w
+			total += w
+		}
+	}
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.rand == nil {
+		ws.rand = rand.New(rand.NewSource(1))
+	}
+	if total == 0 {
+		return candidates[ws.rand.Intn(len(candidates))], nil
+	}
+	r := ws.rand.Float64() * total
+	for i, w := range weights {
+		if r < w {
+			return candidates[i], nil
+		}
+		r -= w
+	}
+	// rounding error
+	return candidates[len(candidates)-1], nil
+}
+
+// selectNode returns the best schedulable node fits container, better reports node is better than selected.
+// the first node wins on tie.
+func selectNode(nodes []*Node, container *Container, better func(node, selected *Node) bool) (*Node, error) {
+	var selected *Node
+	schedulable := 0
+	for _, node := range nodes {
+		if !node.Schedulable() {
+			continue
+		}
+		schedulable++
+		if !node.Fits(container.Spec.ResourceRequests) {
+			continue
+		}
+		if selected == nil || better(node, selected) {
+			selected = node
+		}
+	}
+	if schedulable == 0 {
+		return nil, ErrNoValidNode
+	}
+	if selected == nil {
+		return nil, ErrInsufficientCapacity
+	}
+	return selected, nil
+}
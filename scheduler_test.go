@@ -0,0 +1,106 @@
+package cluster
+
+import (
+	"math"
+	"testing"
+)
+
+func newTestSchedulerNodes() []*Node {
+	return []*Node{
+		&Node{Id: "node1", Name: "node-1", Capacity: Capacity{MemoryMB: 1024}, Allocated: Capacity{MemoryMB: 256}, ContainerCount: 3},
+		&Node{Id: "node2", Name: "node-2", Capacity: Capacity{MemoryMB: 1024}, Allocated: Capacity{MemoryMB: 768}, ContainerCount: 1},
+		&Node{Id: "node3", Name: "node-3", Capacity: Capacity{MemoryMB: 1024}, Allocated: Capacity{MemoryMB: 512}, ContainerCount: 2},
+	}
+}
+
+type testSchedulerData struct {
+	scheduler Scheduler
+	request   Capacity
+	output    UID
+	error     error
+}
+
+func TestScheduler_Select(t *testing.T) {
+	dataList := []testSchedulerData{
+		testSchedulerData{LeastLoadedScheduler{}, Capacity{MemoryMB: 256}, "node1", nil},
+		testSchedulerData{SpreadScheduler{}, Capacity{MemoryMB: 256}, "node2", nil},
+		testSchedulerData{BinpackScheduler{}, Capacity{MemoryMB: 256}, "node2", nil},
+		testSchedulerData{BinpackScheduler{}, Capacity{MemoryMB: 512}, "node3", nil},
+		testSchedulerData{SpreadScheduler{}, Capacity{MemoryMB: 512}, "node3", nil},
+		testSchedulerData{LeastLoadedScheduler{}, Capacity{MemoryMB: 1024}, "", ErrInsufficientCapacity},
+		testSchedulerData{SpreadScheduler{}, Capacity{MemoryMB: 1024}, "", ErrInsufficientCapacity},
+		testSchedulerData{BinpackScheduler{}, Capacity{MemoryMB: 1024}, "", ErrInsufficientCapacity},
+	}
+	for _, data := range dataList {
+		container := NewContainer("id1", "name1", "", "", "", testImage, "", nil)
+		container.Spec.ResourceRequests = data.request
+		node, err := data.scheduler.Select(newTestSchedulerNodes(), container)
+		if err != data.error {
+			t.Fatalf("%T,%v,%v", data.scheduler, data.request, err)
+		}
+		if err == nil && node.Id != data.output {
+			t.Errorf("%T,%v want:%v,have:%v", data.scheduler, data.request, data.output, node.Id)
+		}
+	}
+}
+
+func TestDefaultClusterService_SetScheduler(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	clusterService.SetScheduler(SpreadScheduler{})
+	for i := 0; i < 3; i++ {
+		runTestNode(t, clusterService, nil)
+	}
+	for i := 0; i < 6; i++ {
+		if _, err := clusterService.CreateContainerWithSpec(ContainerSpec{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	clusterService.refreshAllocated()
+	for _, node := range clusterService.nodes {
+		if node.ContainerCount != 2 {
+			t.Errorf("%v,%v", node.Name, node.ContainerCount)
+		}
+	}
+}
+
+func TestWeightedScheduler_Select(t *testing.T) {
+	nodes := newTestSchedulerNodes()
+	container := NewContainer("id1", "name1", "", "", "", testImage, "", nil)
+	scheduler := NewWeightedScheduler(42)
+	counts := map[UID]int{}
+	placements := 6000
+	for i := 0; i < placements; i++ {
+		node, err := scheduler.Select(nodes, container)
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[node.Id]++
+	}
+	// free memory is 768, 256 and 512
+	expected := map[UID]float64{"node1": 0.5, "node2": 1.0 / 6, "node3": 1.0 / 3}
+	for id, ratio := range expected {
+		if have := float64(counts[id]) / float64(placements); math.Abs(have-ratio) > 0.02 {
+			t.Errorf("%v want:%v,have:%v", id, ratio, have)
+		}
+	}
+
+	// same seed selects same nodes
+	a, b := NewWeightedScheduler(7), NewWeightedScheduler(7)
+	for i := 0; i < 10; i++ {
+		na, _ := a.Select(nodes, container)
+		nb, _ := b.Select(nodes, container)
+		if na.Id != nb.Id {
+			t.Fatalf("want:%v,have:%v", na.Id, nb.Id)
+		}
+	}
+
+	container.Spec.ResourceRequests = Capacity{MemoryMB: 1024}
+	if _, err := scheduler.Select(nodes, container); err != ErrInsufficientCapacity {
+		t.Errorf("want:%v,have:%v", ErrInsufficientCapacity, err)
+	}
+	// unlimited node weighs as the largest limited one
+	unlimited := append(newTestSchedulerNodes(), &Node{Id: "node4", Name: "node-4"})
+	if have := FreeMemoryWeight(unlimited[3], unlimited); have != 768 {
+		t.Errorf("want:%v,have:%v", 768, have)
+	}
+}
@@ -0,0 +1,112 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Secret is named key value pairs given to env of containers referencing it by EnvFrom.
+// it is not saved by SaveState.
+type Secret struct {
+	Name string
+	// values by env key
+	Data map[string]string `json:"-"`
+}
+
+// Clone returns deep copy of secret.
+func (s *Secret) Clone() *Secret {
+	if s == nil {
+		return nil
+	}
+	clone := *s
+	clone.Data = cloneStringMap(s.Data)
+	return &clone
+}
+
+// SecretStore provides secrets to the service, backed by external vault or others.
+type SecretStore interface {
+	// GetSecret returns secret of name, or error wrapping ErrSecretNotFound if it does not exist.
+	GetSecret(ctx context.Context, name string) (*Secret, error)
+}
+
+// InMemorySecretStore is SecretStore holding secrets in memory.
+type InMemorySecretStore struct {
+	mu      sync.RWMutex
+	secrets map[string]*Secret
+}
+
+var _ SecretStore = (*InMemorySecretStore)(nil)
+
+func NewInMemorySecretStore() *InMemorySecretStore {
+	return &InMemorySecretStore{secrets: make(map[string]*Secret)}
+}
+
+// PutSecret add secret, or replace it if name exists. keys must be valid env keys.
+func (ims *InMemorySecretStore) PutSecret(secret Secret) error {
+	if secret.Name == "" {
+		return fmt.Errorf("secret name required")
+	}
+	for key := range secret.Data {
+		if key == "" || strings.Contains(key, "=") {
+			return fmt.Errorf("invalid key:%v of secret:%v", key, secret.Name)
+		}
+	}
+	ims.mu.Lock()
+	defer ims.mu.Unlock()
+	ims.secrets[secret.Name] = secret.Clone()
+	return nil
+}
+
+// DeleteSecret remove secret of name, containers referencing it fail to run after.
+func (ims *InMemorySecretStore) DeleteSecret(name string) {
+	ims.mu.Lock()
+	defer ims.mu.Unlock()
+	delete(ims.secrets, name)
+}
+
+func (ims *InMemorySecretStore) GetSecret(ctx context.Context, name string) (*Secret, error) {
+	ims.mu.RLock()
+	defer ims.mu.RUnlock()
+	secret, ok := ims.secrets[name]
+	if !ok {
+		return nil, fmt.Errorf("%w:%v", ErrSecretNotFound, name)
+	}
+	return secret.Clone(), nil
+}
+
+// SetSecretStore set store of secrets referenced by EnvFrom of containers.
+func (dcs *DefaultClusterService) SetSecretStore(store SecretStore) {
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	dcs.secrets = store
+}
+
+// secretEnv returns env of spec populated from secrets of EnvFrom, keys sorted in each secret.
+// Env of spec follows, so it overrides the same keys of secrets.
+func secretEnv(ctx context.Context, store SecretStore, spec ContainerSpec) ([]string, error) {
+	if len(spec.EnvFrom) == 0 {
+		return spec.Env, nil
+	}
+	if store == nil {
+		return nil, fmt.Errorf("%w:%v, no secret store", ErrSecretNotFound, spec.EnvFrom[0])
+	}
+	env := []string{}
+	for _, name := range spec.EnvFrom {
+		secret, err := store.GetSecret(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		keys := make([]string, 0, len(secret.Data))
+		for key := range secret.Data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			env = append(env, key+"="+secret.Data[key])
+		}
+	}
+	return append(env, spec.Env...), nil
+}
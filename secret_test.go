@@ -0,0 +1,76 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSecretEnv(t *testing.T) {
+	store := NewInMemorySecretStore()
+	if err := store.PutSecret(Secret{Name: "db", Data: map[string]string{"PASSWORD": "p", "USER": "u"}}); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		store    SecretStore
+		spec     ContainerSpec
+		expected []string
+		err      error
+	}{
+		{"noEnvFrom", nil, ContainerSpec{Env: []string{"A=1"}}, []string{"A=1"}, nil},
+		{"populated", store, ContainerSpec{Env: []string{"USER=v"}, EnvFrom: []string{"db"}}, []string{"PASSWORD=p", "USER=u", "USER=v"}, nil},
+		{"missing", store, ContainerSpec{EnvFrom: []string{"cache"}}, nil, ErrSecretNotFound},
+		{"noStore", nil, ContainerSpec{EnvFrom: []string{"db"}}, nil, ErrSecretNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := secretEnv(context.Background(), tt.store, tt.spec)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("want:%v,have:%v", tt.err, err)
+			}
+			if !reflect.DeepEqual(env, tt.expected) {
+				t.Errorf("want:%v,have:%v", tt.expected, env)
+			}
+		})
+	}
+}
+
+func TestDefaultClusterService_RunContainer_EnvFrom(t *testing.T) {
+	clusterService, client := newTestRestartService(t)
+	store := NewInMemorySecretStore()
+	store.PutSecret(Secret{Name: "db", Data: map[string]string{"PASSWORD": "s3cr3t"}})
+	clusterService.SetSecretStore(store)
+
+	container, err := clusterService.CreateContainerWithSpec(ContainerSpec{Env: []string{"A=1"}, EnvFrom: []string{"db"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"PASSWORD=s3cr3t", "A=1"}; !reflect.DeepEqual(client.envs[0], expected) {
+		t.Errorf("want:%v,have:%v", expected, client.envs[0])
+	}
+	if expected := []string{"A=1"}; !reflect.DeepEqual(container.Spec.Env, expected) {
+		t.Errorf("want:%v,have:%v", expected, container.Spec.Env)
+	}
+	var buf bytes.Buffer
+	if err := clusterService.SaveState(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "s3cr3t") {
+		t.Errorf("secret saved:%v", buf.String())
+	}
+
+	missing, err := clusterService.CreateContainerWithSpec(ContainerSpec{EnvFrom: []string{"cache"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.RunContainer(missing); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("want:%v,have:%v", ErrSecretNotFound, err)
+	}
+}
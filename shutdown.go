@@ -0,0 +1,123 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// backgroundLoops tracks loops started by the service to stop them by Shutdown.
+type backgroundLoops struct {
+	mu      sync.Mutex
+	nextId  int
+	cancels map[int]context.CancelFunc
+	// loops started after Shutdown stop immediately
+	shutdown bool
+	wg       sync.WaitGroup
+}
+
+// goLoop run loop in background with ctx which is also cancelled by Shutdown.
+// returned channel is closed when loop returned.
+func (dcs *DefaultClusterService) goLoop(ctx context.Context, loop func(ctx context.Context)) <-chan struct{} {
+	bl := &dcs.loops
+	ctx, cancel := context.WithCancel(ctx)
+	bl.mu.Lock()
+	if bl.cancels == nil {
+		bl.cancels = make(map[int]context.CancelFunc)
+	}
+	id := bl.nextId
+	bl.nextId++
+	bl.cancels[id] = cancel
+	if bl.shutdown {
+		cancel()
+	}
+	bl.wg.Add(1)
+	bl.mu.Unlock()
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		defer bl.wg.Done()
+		defer func() {
+			bl.mu.Lock()
+			defer bl.mu.Unlock()
+			delete(bl.cancels, id)
+			cancel()
+		}()
+		loop(ctx)
+	}()
+	return stopped
+}
+
+// stop cancel all loops and wait for them to return, or ctx is done.
+func (bl *backgroundLoops) stop(ctx context.Context) error {
+	bl.mu.Lock()
+	bl.shutdown = true
+	for _, cancel := range bl.cancels {
+		cancel()
+	}
+	bl.mu.Unlock()
+
+	stopped := make(chan struct{})
+	go func() {
+		bl.wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-stopped:
+		return nil
+	}
+}
+
+// Shutdown tear down cluster. It stops background loops, kills running and paused containers,
+// stops running, draining and unreachable nodes by their resource providers in DefaultStopGracePeriod
+// or until deadline of ctx, and closes clients and resource providers implementing io.Closer of all nodes.
+// It keeps going on failure and returns joined errors.
+func (dcs *DefaultClusterService) Shutdown(ctx context.Context) error {
+	errs := []error{dcs.loops.stop(ctx)}
+
+	alive := dcs.FilterContainers(func(c *Container) bool {
+		state := containerStateOf(c)
+		return state == ContainerRunning || state == ContainerPaused
+	})
+	if len(alive) > 0 {
+		_, err := dcs.KillContainersContext(ctx, alive)
+		errs = append(errs, err)
+	}
+
+	gracePeriod := DefaultStopGracePeriod
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < gracePeriod {
+		gracePeriod = time.Until(deadline)
+	}
+	if gracePeriod < 0 {
+		// nodes are removed without stopping once deadline passed
+		gracePeriod = 0
+	}
+	nodes, _ := dcs.Nodes(true)
+	for _, node := range nodes {
+		if node.ResourceProvider == nil || checkKillNode(node, node.Id) != nil {
+			continue
+		}
+		errs = append(errs, dcs.KillNodeContext(ctx, *node, int(gracePeriod/time.Millisecond)))
+	}
+
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
+	// client may be shared by nodes
+	closed := map[io.Closer]bool{}
+	for _, node := range dcs.nodes {
+		for _, v := range []interface{}{node.Client, node.ResourceProvider} {
+			closer, ok := v.(io.Closer)
+			if !ok || closed[closer] {
+				continue
+			}
+			closed[closer] = true
+			errs = append(errs, closer.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,132 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type closingContainerClient struct {
+	*FakeContainerClient
+	closed int32
+}
+
+func (ccc *closingContainerClient) Close() error {
+	atomic.AddInt32(&ccc.closed, 1)
+	return nil
+}
+
+func TestDefaultClusterService_Shutdown(t *testing.T) {
+	clusterService, provider, nodes := newTestHeartbeatService(t)
+	client := &closingContainerClient{FakeContainerClient: NewFakeContainerClient("hash1")}
+	for _, node := range nodes {
+		if err := clusterService.SetNodeClient(node.Id, client); err != nil {
+			t.Fatal(err)
+		}
+	}
+	clusterService.SetOptions(ContainerOptions{})
+	containers := Containers{}
+	for _, node := range nodes {
+		container, err := clusterService.CreateContainerOn(node.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := clusterService.RunContainer(container); err != nil {
+			t.Fatal(err)
+		}
+		containers = append(containers, container)
+	}
+	if err := clusterService.Pause(containers[1]); err != nil {
+		t.Fatal(err)
+	}
+	created, _ := clusterService.CreateContainerOn(nodes[0].Id)
+	reconciling := clusterService.StartReconcileLoop(context.Background(), time.Hour)
+	checking := clusterService.StartHeartbeatCheck(context.Background(), time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := clusterService.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, stopped := range []<-chan struct{}{reconciling, checking} {
+		select {
+		case <-stopped:
+		default:
+			t.Error("want loop stopped")
+		}
+	}
+	all, _ := clusterService.Containers(true)
+	for _, c := range all {
+		want := ContainerExited
+		if c.Id == created.Id {
+			want = ContainerCreated
+		}
+		if c.ContainerStatus.ContainerState != want {
+			t.Errorf("want:%v,have:%v", want, c.ContainerStatus)
+		}
+	}
+	for _, node := range nodes {
+		if have, _ := clusterService.GetNode(node.Id); have.NodeState != NodeExited {
+			t.Errorf("want:%v,have:%v", NodeExited, have.NodeState)
+		}
+	}
+	if provider.stopCalls != 2 {
+		t.Errorf("want:%v,have:%v", 2, provider.stopCalls)
+	}
+	// shared client is closed once
+	if client.closed != 1 {
+		t.Errorf("want:%v,have:%v", 1, client.closed)
+	}
+
+	// loop started after shutdown stops immediately
+	select {
+	case <-clusterService.StartReconcileLoop(context.Background(), time.Hour):
+	case <-time.After(time.Second):
+		t.Error("want loop stopped")
+	}
+}
+
+func TestDefaultClusterService_Shutdown_Error(t *testing.T) {
+	clusterService, provider, _ := newTestHeartbeatService(t)
+	provider.Err = errors.New("stop failed")
+	err := clusterService.Shutdown(context.Background())
+	if !errors.Is(err, provider.Err) {
+		t.Errorf("want:%v,have:%v", provider.Err, err)
+	}
+	// errors of nodes are joined
+	if joined, ok := err.(interface{ Unwrap() []error }); !ok || len(joined.Unwrap()) != 2 {
+		t.Errorf("want:%v,have:%v", 2, err)
+	}
+}
+
+func TestDefaultClusterService_Shutdown_Unreachable(t *testing.T) {
+	clusterService, provider, nodes := newTestHeartbeatService(t)
+	client := &closingContainerClient{FakeContainerClient: NewFakeContainerClient("hash1")}
+	if err := clusterService.SetNodeClient(nodes[0].Id, client); err != nil {
+		t.Fatal(err)
+	}
+	partition(t, clusterService, nodes[0])
+
+	if err := clusterService.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if have, _ := clusterService.GetNode(nodes[0].Id); have.NodeState != NodeExited {
+		t.Errorf("want:%v,have:%v", NodeExited, have.NodeState)
+	}
+	if provider.stopCalls != 2 || client.closed != 1 {
+		t.Errorf("%v,%v", provider.stopCalls, client.closed)
+	}
+
+	// failure of killing unreachable node is reported
+	clusterService, provider, nodes = newTestHeartbeatService(t)
+	if err := clusterService.KillNode(*nodes[1], 0); err != nil {
+		t.Fatal(err)
+	}
+	partition(t, clusterService, nodes[0])
+	provider.Err = errors.New("stop failed")
+	if err := clusterService.Shutdown(context.Background()); !errors.Is(err, provider.Err) {
+		t.Errorf("want:%v,have:%v", provider.Err, err)
+	}
+}
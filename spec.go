@@ -0,0 +1,248 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ContainerSpec is typed options to run container.
+type ContainerSpec struct {
+	// name of desired containers managed by Reconcile, ignored by others
+	Name string
+	// full name of image, image of cluster if empty
+	Image string
+	// number of containers Reconcile keeps for spec, 0 means 1
+	Replicas int
+	// environment variables, formatted KEY=VALUE
+	Env []string
+	// names of secrets whose key values are given to env at run, not kept in spec
+	EnvFrom []string
+	// ports published to host
+	Ports []PortMapping
+	// volumes mounted into container
+	Volumes []VolumeMount
+	// command to run, overrides image default
+	Command []string
+	// working directory in container
+	WorkingDir string
+	// keep stdin of main process open, so that input is written to it by Attach
+	Stdin bool
+	// restart policy applied when container exited
+	RestartPolicy RestartPolicy
+	// containers of higher priority are started first by batch, restart, reschedule and reconcile, 0 by default
+	Priority int
+	// resources reserved on node for scheduling
+	ResourceRequests Capacity
+	// resources container can use at most, enforced by runtime. DiskGB is not enforced.
+	ResourceLimits Capacity
+	// health check while running, nil means no check
+	HealthCheck *HealthCheck
+	// check while running, container is restarted by restart policy if it fails, nil means no check
+	LivenessProbe *HealthCheck
+	// check while running, container is not ready to serve while it fails, nil means no check
+	ReadinessProbe *HealthCheck
+	// containers which must be running, and healthy and ready if they have checks, before this starts
+	// by RunContainersOrdered
+	DependsOn []UID
+	// labels node must have to place container
+	NodeAffinity map[string]string
+	// container is not placed on node with alive container whose labels include all of it, empty means no constraint
+	AntiAffinity map[string]string
+	// taints of node container may be placed on
+	Tolerations []Toleration
+}
+
+// PortMapping publish container port to host port.
+type PortMapping struct {
+	// port on host, 0 to let runtime pick
+	HostPort int
+	// port in container
+	ContainerPort int
+	// tcp or udp, empty means tcp
+	Protocol string
+}
+
+// VolumeMount mount host path or named volume into container.
+type VolumeMount struct {
+	// absolute host path to bind, or volume name. empty means anonymous volume
+	Source string
+	// path in container
+	Target string
+	// mount read only
+	ReadOnly bool
+}
+
+// Bind returns true if source is host path, otherwise it is named volume.
+func (v VolumeMount) Bind() bool {
+	return path.IsAbs(v.Source)
+}
+
+func (v VolumeMount) String() string {
+	value := v.Source + ":" + v.Target
+	if v.ReadOnly {
+		value += ":ro"
+	}
+	return value
+}
+
+// validateVolumes rejects volume without target, or with relative host path as source.
+// source without separator is volume name.
+func validateVolumes(volumes []VolumeMount) error {
+	for _, v := range volumes {
+		if v.Target == "" {
+			return fmt.Errorf("%w, target required:%v", ErrInvalidVolume, v)
+		}
+		if !v.Bind() && (strings.Contains(v.Source, "/") || strings.HasPrefix(v.Source, ".")) {
+			return fmt.Errorf("%w, relative source:%v", ErrInvalidVolume, v)
+		}
+	}
+	return nil
+}
+
+// RestartPolicy decides whether exited container is re-run.
+type RestartPolicy struct {
+	// policy name, empty means RestartNo
+	Name RestartPolicyName
+	// max number of restarts, 0 means unlimited
+	MaxRetries int
+}
+
+type RestartPolicyName string
+
+const (
+	// never restart
+	RestartNo RestartPolicyName = "no"
+	// restart if container exited with non-zero code, or with error if its code is unknown
+	RestartOnFailure RestartPolicyName = "on-failure"
+	// restart whenever container exited, even if it is killed
+	RestartAlways RestartPolicyName = "always"
+	// restart whenever container exited, unless it is killed by KillContainer
+	RestartUnlessStopped RestartPolicyName = "unless-stopped"
+)
+
+// SetEnv set environment variable to spec, overrides if key is already set.
+func (spec *ContainerSpec) SetEnv(key, value string) error {
+	if key == "" {
+		return errors.New("env key required")
+	}
+	if strings.Contains(key, "=") {
+		return fmt.Errorf("invalid env key:%v", key)
+	}
+	kv := key + "=" + value
+	for i, env := range spec.Env {
+		if strings.HasPrefix(env, key+"=") {
+			spec.Env[i] = kv
+			return nil
+		}
+	}
+	spec.Env = append(spec.Env, kv)
+	return nil
+}
+
+// keys of ContainerOptions converted by Spec.
+const (
+	// comma separated KEY=VALUE
+	OptionEnv = "env"
+	// comma separated hostPort:containerPort[/protocol]
+	OptionPorts = "ports"
+	// comma separated source:target[:ro]
+	OptionVolumes = "volumes"
+	// space separated command
+	OptionCommand = "command"
+	// working directory
+	OptionWorkingDir = "workingDir"
+)
+
+// Spec convert options into ContainerSpec. unknown keys are ignored.
+func (options ContainerOptions) Spec() (*ContainerSpec, error) {
+	spec := &ContainerSpec{}
+	if env, ok := options[OptionEnv]; ok {
+		for _, kv := range splitOption(env, ",") {
+			if strings.Index(kv, "=") <= 0 {
+				return nil, fmt.Errorf("invalid env:%v", kv)
+			}
+			spec.Env = append(spec.Env, kv)
+		}
+	}
+	if ports, ok := options[OptionPorts]; ok {
+		for _, port := range splitOption(ports, ",") {
+			portMapping, err := parsePortMapping(port)
+			if err != nil {
+				return nil, err
+			}
+			spec.Ports = append(spec.Ports, *portMapping)
+		}
+	}
+	if volumes, ok := options[OptionVolumes]; ok {
+		for _, volume := range splitOption(volumes, ",") {
+			volumeMount, err := parseVolumeMount(volume)
+			if err != nil {
+				return nil, err
+			}
+			spec.Volumes = append(spec.Volumes, *volumeMount)
+		}
+	}
+	if command, ok := options[OptionCommand]; ok {
+		spec.Command = strings.Fields(command)
+	}
+	spec.WorkingDir = options[OptionWorkingDir]
+	return spec, nil
+}
+
+func splitOption(value string, sep string) []string {
+	res := []string{}
+	for _, v := range strings.Split(value, sep) {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+func parsePortMapping(value string) (*PortMapping, error) {
+	protocol := ""
+	if index := strings.Index(value, "/"); index >= 0 {
+		protocol = value[index+1:]
+		value = value[:index]
+	}
+	ports := strings.Split(value, ":")
+	if len(ports) != 2 {
+		return nil, fmt.Errorf("invalid port:%v", value)
+	}
+	hostPort, err := strconv.Atoi(ports[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid host port:%v", ports[0])
+	}
+	containerPort, err := strconv.Atoi(ports[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid container port:%v", ports[1])
+	}
+	return &PortMapping{
+		HostPort:      hostPort,
+		ContainerPort: containerPort,
+		Protocol:      protocol,
+	}, nil
+}
+
+func parseVolumeMount(value string) (*VolumeMount, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return nil, fmt.Errorf("invalid volume:%v", value)
+	}
+	readOnly := false
+	if len(parts) == 3 {
+		if parts[2] != "ro" {
+			return nil, fmt.Errorf("invalid volume mode:%v", parts[2])
+		}
+		readOnly = true
+	}
+	return &VolumeMount{
+		Source:   parts[0],
+		Target:   parts[1],
+		ReadOnly: readOnly,
+	}, nil
+}
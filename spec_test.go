@@ -0,0 +1,145 @@
+package cluster
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testSpecData struct {
+	input  ContainerOptions
+	output *ContainerSpec
+	error  error
+}
+
+func TestContainerOptions_Spec(t *testing.T) {
+	dataList := []testSpecData{
+		testSpecData{ContainerOptions{}, &ContainerSpec{}, nil},
+		testSpecData{ContainerOptions{"options": "option1"}, &ContainerSpec{}, nil},
+		testSpecData{
+			ContainerOptions{
+				OptionEnv:        "A=1, B=2",
+				OptionPorts:      "80:8080,0:53/udp",
+				OptionVolumes:    "/data:/var/data:ro,cache:/cache",
+				OptionCommand:    "app serve --verbose",
+				OptionWorkingDir: "/app",
+			},
+			&ContainerSpec{
+				Env: []string{"A=1", "B=2"},
+				Ports: []PortMapping{
+					PortMapping{HostPort: 80, ContainerPort: 8080},
+					PortMapping{HostPort: 0, ContainerPort: 53, Protocol: "udp"},
+				},
+				Volumes: []VolumeMount{
+					VolumeMount{Source: "/data", Target: "/var/data", ReadOnly: true},
+					VolumeMount{Source: "cache", Target: "/cache"},
+				},
+				Command:    []string{"app", "serve", "--verbose"},
+				WorkingDir: "/app",
+			},
+			nil,
+		},
+		testSpecData{ContainerOptions{OptionEnv: "=1"}, nil, errors.New("invalid env:=1")},
+		testSpecData{ContainerOptions{OptionPorts: "80"}, nil, errors.New("invalid port:80")},
+		testSpecData{ContainerOptions{OptionPorts: "a:80"}, nil, errors.New("invalid host port:a")},
+		testSpecData{ContainerOptions{OptionVolumes: "/data:/data:rw"}, nil, errors.New("invalid volume mode:rw")},
+	}
+	for _, data := range dataList {
+		spec, err := data.input.Spec()
+		if data.error != nil && (err == nil || err.Error() != data.error.Error()) {
+			t.Fatalf("err:%v, input:%v", err, data.input)
+		}
+		if data.error == nil && err != nil {
+			t.Fatalf("err:%v, input:%v", err, data.input)
+		}
+		if !reflect.DeepEqual(data.output, spec) {
+			t.Errorf("want:%v,have:%v", data.output, spec)
+		}
+	}
+}
+
+func TestContainer_SetEnv(t *testing.T) {
+	container := NewContainer("id1", "name1", "", "node1", "nodename1", testImage, "", nil)
+	if err := container.SetEnv("KEY", "value1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := container.SetEnv("OTHER", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if err := container.SetEnv("KEY", "value2"); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"KEY=value2", "OTHER=value"}
+	if !reflect.DeepEqual(expected, container.Spec.Env) {
+		t.Errorf("want:%v,have:%v", expected, container.Spec.Env)
+	}
+	if err := container.SetEnv("", "value"); err == nil {
+		t.Errorf("want error for empty key")
+	}
+	if err := container.SetEnv("A=B", "value"); err == nil {
+		t.Errorf("want error for invalid key")
+	}
+}
+
+func TestValidateVolumes(t *testing.T) {
+	tests := []struct {
+		volume  VolumeMount
+		wantErr bool
+	}{
+		{VolumeMount{Source: "/data", Target: "/data"}, false},
+		{VolumeMount{Source: "cache", Target: "/cache", ReadOnly: true}, false},
+		{VolumeMount{Target: "/tmp"}, false},
+		{VolumeMount{Source: "/data"}, true},
+		{VolumeMount{Source: "data/sub", Target: "/data"}, true},
+		{VolumeMount{Source: "./data", Target: "/data"}, true},
+	}
+	for _, tt := range tests {
+		err := validateVolumes([]VolumeMount{tt.volume})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v want error:%v,have:%v", tt.volume, tt.wantErr, err)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidVolume) {
+			t.Errorf("want:%v,have:%v", ErrInvalidVolume, err)
+		}
+	}
+}
+
+func TestDefaultClusterService_CreateContainerWithSpec_Volumes(t *testing.T) {
+	clusterService, _ := newTestRestartService(t)
+	if _, err := clusterService.CreateContainerWithSpec(ContainerSpec{Volumes: []VolumeMount{VolumeMount{Source: "data", Target: ""}}}); !errors.Is(err, ErrInvalidVolume) {
+		t.Errorf("want:%v,have:%v", ErrInvalidVolume, err)
+	}
+	if len(clusterService.containers) != 0 {
+		t.Errorf("want:%v,have:%v", 0, clusterService.containers)
+	}
+	container, err := clusterService.CreateContainerWithSpec(ContainerSpec{Volumes: []VolumeMount{VolumeMount{Source: "/data", Target: "/data"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !container.Spec.Volumes[0].Bind() {
+		t.Errorf("want bind:%v", container.Spec.Volumes[0])
+	}
+}
+
+func TestDefaultClusterService_CreateContainerWithSpec_Image(t *testing.T) {
+	clusterService, _ := newTestRestartService(t)
+	tests := []struct {
+		name     string
+		image    string
+		expected string
+	}{
+		{"default", "", testImage.FullName},
+		{"spec", "nginx:1.25", "nginx:1.25"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			container, err := clusterService.CreateContainerWithSpec(ContainerSpec{Image: tt.image})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if container.Image.FullName != tt.expected {
+				t.Errorf("want:%v,have:%v", tt.expected, container.Image.FullName)
+			}
+		})
+	}
+}
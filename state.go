@@ -0,0 +1,122 @@
+package cluster
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+)
+
+// clusterState is serialized form of DefaultClusterService.
+type clusterState struct {
+	Containers        Containers
+	ContainerStatuses ContainerStatuses
+	Nodes             Nodes
+	NodeStatuses      NodeStatuses
+	MaxNameI          int
+	ReplicaSets       []*ReplicaSet
+	NodeGroups        []*NodeGroup
+	PendingTimeout    time.Duration
+}
+
+// SaveState write containers, nodes and their statuses, replica sets, node groups and pending timeout as JSON.
+// Client and ResourceProvider of nodes, ResourceProvider and Prepare of node groups, and secret store are not saved.
+// secrets are referenced by EnvFrom of specs, which are saved, but their values are not.
+func (dcs *DefaultClusterService) SaveState(w io.Writer) error {
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
+	state := &clusterState{
+		Containers:        dcs.containers,
+		ContainerStatuses: dcs.containerStatuses,
+		Nodes:             dcs.nodes,
+		NodeStatuses:      dcs.nodeStatuses,
+		MaxNameI:          dcs.maxNameI,
+		ReplicaSets:       make([]*ReplicaSet, 0, len(dcs.replicaSets)),
+		NodeGroups:        make([]*NodeGroup, 0, len(dcs.nodeGroups)),
+		PendingTimeout:    dcs.pendingTimeout,
+	}
+	for _, rs := range dcs.replicaSets {
+		state.ReplicaSets = append(state.ReplicaSets, rs)
+	}
+	// sorted to keep saved state stable
+	sort.Slice(state.ReplicaSets, func(i, j int) bool { return state.ReplicaSets[i].Name < state.ReplicaSets[j].Name })
+	for _, group := range dcs.nodeGroups {
+		state.NodeGroups = append(state.NodeGroups, group)
+	}
+	sort.Slice(state.NodeGroups, func(i, j int) bool { return state.NodeGroups[i].Name < state.NodeGroups[j].Name })
+	return json.NewEncoder(w).Encode(state)
+}
+
+// LoadState restore state written by SaveState, replacing current containers, nodes, replica sets and node groups.
+// Client and ResourceProvider of nodes must be re-attached by caller, ResourceProvider and Prepare of node groups
+// by SetNodeGroupProvider, and secret store by SetSecretStore if it is not set.
+func (dcs *DefaultClusterService) LoadState(r io.Reader) error {
+	state := &clusterState{}
+	if err := json.NewDecoder(r).Decode(state); err != nil {
+		return err
+	}
+	// versions continue from the latest one saved
+	var resourceVersion uint64
+	containers := Containers{}
+	containerStatuses := ContainerStatuses{}
+	statusesById := make(map[UID]*ContainerStatus)
+	for _, cs := range state.ContainerStatuses {
+		cs.Namespace = namespaceOrDefault(cs.Namespace)
+		containerStatuses = append(containerStatuses, cs)
+		statusesById[cs.Id] = cs
+	}
+	for _, c := range state.Containers {
+		c.Namespace = namespaceOrDefault(c.Namespace)
+		// share status with containerStatuses as CreateContainer does
+		if cs, ok := statusesById[c.Id]; ok {
+			c.ContainerStatus = cs
+		} else if c.ContainerStatus != nil {
+			containerStatuses = append(containerStatuses, c.ContainerStatus)
+		}
+		containers = append(containers, c)
+		if c.ResourceVersion > resourceVersion {
+			resourceVersion = c.ResourceVersion
+		}
+	}
+
+	nodes := Nodes{}
+	nodesById := make(map[UID]*Node)
+	nodesByName := make(map[nameKey]*Node)
+	for _, node := range state.Nodes {
+		// state saved before namespace is in default namespace
+		node.Namespace = namespaceOrDefault(node.Namespace)
+		nodes = append(nodes, node)
+		nodesById[node.Id] = node
+		nodesByName[nameKey{namespace: node.Namespace, name: node.Name}] = node
+		if node.ResourceVersion > resourceVersion {
+			resourceVersion = node.ResourceVersion
+		}
+	}
+	nodeStatuses := NodeStatuses{}
+	nodeStatuses = append(nodeStatuses, state.NodeStatuses...)
+	replicaSets := make(map[string]*ReplicaSet)
+	for _, rs := range state.ReplicaSets {
+		replicaSets[rs.Name] = rs
+	}
+	nodeGroups := make(map[string]*NodeGroup)
+	for _, group := range state.NodeGroups {
+		nodeGroups[group.Name] = group
+	}
+
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	dcs.containers = containers
+	dcs.containerStatuses = containerStatuses
+	dcs.reindexContainerStatuses()
+	dcs.reindexContainers()
+	dcs.nodes = nodes
+	dcs.nodeStatuses = nodeStatuses
+	dcs.nodesById = nodesById
+	dcs.nodesByName = nodesByName
+	dcs.maxNameI = state.MaxNameI
+	dcs.replicaSets = replicaSets
+	dcs.nodeGroups = nodeGroups
+	dcs.pendingTimeout = state.PendingTimeout
+	dcs.resourceVersion = resourceVersion
+	return nil
+}
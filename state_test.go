@@ -0,0 +1,131 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDefaultClusterService_SaveState(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	node := prepareTestNode(t, clusterService, func(node *Node) {
+		node.ResourceProvider = &mockResourceProvider{resourceInfo: &ResourceInfo{"host": "host1"}}
+		node.Client = &mockContainerClient{hash: "hash1"}
+		node.Capacity = Capacity{MemoryMB: 1024}
+		node.Labels = map[string]string{"gpu": "true"}
+	})
+	clusterService.CreateNode()
+	container, err := clusterService.CreateContainerWithSpec(ContainerSpec{
+		Env:           []string{"A=1"},
+		Ports:         []PortMapping{PortMapping{HostPort: 80, ContainerPort: 8080}},
+		RestartPolicy: RestartPolicy{Name: RestartAlways},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := clusterService.SaveState(&buf); err != nil {
+		t.Fatal(err)
+	}
+	saved := buf.String()
+	loaded := NewDefaultClusterService("0.0.0", testImage)
+	if err := loaded.LoadState(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// loaded state is saved as it is
+	var again bytes.Buffer
+	if err := loaded.SaveState(&again); err != nil {
+		t.Fatal(err)
+	}
+	if again.String() != saved {
+		t.Errorf("want:%v,have:%v", saved, again.String())
+	}
+	// clients and providers are not saved
+	have, err := loaded.GetNode(node.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have.Client != nil || have.ResourceProvider != nil || have.Capacity != node.Capacity || !reflect.DeepEqual(node.Labels, have.Labels) {
+		t.Errorf("want:%v,have:%v", node, have)
+	}
+	if have, _ := loaded.GetContainer(container.Id); have.Hash != container.Hash || !reflect.DeepEqual(container.Spec, have.Spec) {
+		t.Errorf("want:%v,have:%v", container, have)
+	}
+	if loaded.containers[0].ContainerStatus != loaded.containerStatuses[0] {
+		t.Errorf("container status is not shared")
+	}
+	if loaded.findNodeById(node.Id) != loaded.nodes[0] || loaded.findNodeByName(DefaultNamespace, node.Name) != loaded.nodes[0] {
+		t.Errorf("nodes are not indexed")
+	}
+	if loaded.maxNameI != clusterService.maxNameI {
+		t.Errorf("want:%v,have:%v", clusterService.maxNameI, loaded.maxNameI)
+	}
+	created, _ := loaded.CreateNode()
+	if created.Name != "node-3" {
+		t.Errorf("%v", created.Name)
+	}
+}
+
+func TestDefaultClusterService_SaveState_ReplicaSets(t *testing.T) {
+	clusterService, _ := newTestRestartService(t)
+	store := NewInMemorySecretStore()
+	store.PutSecret(Secret{Name: "db", Data: map[string]string{"PASSWORD": "secret"}})
+	clusterService.SetSecretStore(store)
+	spec := ContainerSpec{Env: []string{"A=1"}, EnvFrom: []string{"db"}}
+	if err := clusterService.EnsureReplicas("web", spec, 2); err != nil {
+		t.Fatal(err)
+	}
+	group := NodeGroup{
+		Name:             "gpu-pool",
+		ResourceProvider: NewFakeResourceProvider(ResourceInfo{"host": "gpu1"}),
+		DesiredSize:      1,
+		Template:         NodeTemplate{Labels: map[string]string{"gpu": "true"}, Capacity: Capacity{MemoryMB: 1024}},
+	}
+	if err := clusterService.AddNodeGroup(group); err != nil {
+		t.Fatal(err)
+	}
+	clusterService.SetPendingTimeout(time.Minute)
+
+	var buf bytes.Buffer
+	if err := clusterService.SaveState(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded := NewDefaultClusterService("0.0.0", testImage)
+	if err := loaded.LoadState(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(clusterService.ReplicaSets(), loaded.ReplicaSets()) {
+		t.Errorf("want:%v,have:%v", clusterService.ReplicaSets(), loaded.ReplicaSets())
+	}
+	if rs, _ := loaded.GetReplicaSet("web"); rs == nil || !reflect.DeepEqual(spec.EnvFrom, rs.Spec.EnvFrom) {
+		t.Errorf("want:%v,have:%v", spec.EnvFrom, rs)
+	}
+	if loaded.pendingTimeout != time.Minute {
+		t.Errorf("want:%v,have:%v", time.Minute, loaded.pendingTimeout)
+	}
+	// provider of group is not saved, and attached again
+	group.ResourceProvider = nil
+	if groups := loaded.NodeGroups(); len(groups) != 1 || !reflect.DeepEqual(&group, groups[0]) {
+		t.Errorf("want:%v,have:%v", group, groups)
+	}
+	if err := loaded.SetNodeGroupProvider("gpu-pool", NewFakeResourceProvider(ResourceInfo{"host": "gpu1"}), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.ScaleNodeGroup(context.Background(), "gpu-pool", 1); err != nil {
+		t.Fatal(err)
+	}
+	if nodes, _ := loaded.NodesInGroup("gpu-pool"); len(nodes) != 1 || nodes[0].NodeState != NodeRunning {
+		t.Errorf("want:%v,have:%v", NodeRunning, nodes)
+	}
+	if err := loaded.SetNodeGroupProvider("unknown", NewFakeResourceProvider(nil), nil); !errors.Is(err, ErrNodeGroupNotFound) {
+		t.Errorf("want:%v,have:%v", ErrNodeGroupNotFound, err)
+	}
+}
@@ -0,0 +1,72 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ContainerStats is resource usage of container sampled by runtime.
+type ContainerStats struct {
+	// cpu usage in percent of a core, may exceed 100 on multiple cores
+	CPUPercent float64
+	// memory used by container
+	MemoryBytes int64
+	// memory container can use, host memory if unlimited
+	MemoryLimitBytes int64
+}
+
+// Stats returns current resource usage of running or paused container.
+func (dcs *DefaultClusterService) Stats(ctx context.Context, container *Container) (*ContainerStats, error) {
+	dcs.mu.RLock()
+	state := containerStateOf(container)
+	node := dcs.findNodeById(container.NodeId).Clone()
+	dcs.mu.RUnlock()
+	if state != ContainerRunning && state != ContainerPaused {
+		return nil, fmt.Errorf("%w:%v", ErrNotRunning, container.Name)
+	}
+	if node == nil {
+		return nil, fmt.Errorf("%w for uid:%v", ErrNodeNotFound, container.NodeId)
+	}
+	if node.Client == nil {
+		return nil, fmt.Errorf("%w:%v", ErrNodeHasNoClient, node.Name)
+	}
+	return node.Client.Stats(ctx, container)
+}
+
+// StatsStream sends stats of container every interval until ctx is done or Stats fails.
+// error of the first Stats is returned, the channel is closed when streaming stopped.
+func (dcs *DefaultClusterService) StatsStream(ctx context.Context, container *Container, interval time.Duration) (<-chan *ContainerStats, error) {
+	if interval <= 0 {
+		return nil, errors.New("interval must be positive")
+	}
+	first, err := dcs.Stats(ctx, container)
+	if err != nil {
+		return nil, err
+	}
+	stream := make(chan *ContainerStats, 1)
+	stream <- first
+	go func() {
+		defer close(stream)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			stats, err := dcs.Stats(ctx, container)
+			if err != nil {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case stream <- stats:
+			}
+		}
+	}()
+	return stream, nil
+}
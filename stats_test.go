@@ -0,0 +1,45 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDefaultClusterService_Stats(t *testing.T) {
+	clusterService, _, nodes := newTestHeartbeatService(t)
+	clusterService.SetOptions(ContainerOptions{})
+	container, _ := clusterService.CreateContainerOn(nodes[0].Id)
+	client := nodes[0].Client.(*FakeContainerClient)
+	expected := ContainerStats{CPUPercent: 1.5, MemoryBytes: 1 << 20, MemoryLimitBytes: 1 << 30}
+	client.SetStats(container.Id, expected)
+
+	if _, err := clusterService.Stats(context.Background(), container); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("want:%v,have:%v", ErrNotRunning, err)
+	}
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := clusterService.Stats(context.Background(), container)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *stats != expected {
+		t.Errorf("want:%v,have:%v", expected, *stats)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := clusterService.StatsStream(ctx, container, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if stats := <-stream; stats == nil || *stats != expected {
+			t.Errorf("want:%v,have:%v", expected, stats)
+		}
+	}
+	cancel()
+	for range stream {
+	}
+}
@@ -0,0 +1,102 @@
+package cluster
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// String returns state, unknown if not set.
+func (s ContainerState) String() string {
+	if s == "" {
+		return string(ContainerUnknown)
+	}
+	return string(s)
+}
+
+// String returns state, unknown if not set.
+func (s NodeState) String() string {
+	if s == "" {
+		return string(NodeUnknown)
+	}
+	return string(s)
+}
+
+// String returns state, unknown if not set.
+func (s ClusterState) String() string {
+	if s == "" {
+		return "unknown"
+	}
+	return string(s)
+}
+
+// String returns one line of container, its state, reason and error if any.
+func (cs ContainerStatus) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "container %v(%v) on %v: %v", cs.Name, cs.Id, cs.NodeName, cs.ContainerState)
+	writeReason(&b, cs.Reason, cs.Error)
+	return b.String()
+}
+
+// String returns one line of node, its state, reason and error if any.
+func (ns NodeStatus) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "node %v(%v): %v", ns.Name, ns.Id, ns.NodeState)
+	writeReason(&b, ns.Reason, ns.Error)
+	return b.String()
+}
+
+// String returns state with reason if any.
+func (cs ClusterStatus) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "cluster: %v", cs.ClusterState)
+	writeReason(&b, cs.Reason, nil)
+	return b.String()
+}
+
+func writeReason(b *strings.Builder, reason string, err error) {
+	if reason != "" {
+		fmt.Fprintf(b, ", reason:%v", reason)
+	}
+	if err != nil {
+		fmt.Fprintf(b, ", error:%v", err)
+	}
+}
+
+// summaryStates are columns of container counts in Summary.
+var summaryStates = []ContainerState{ContainerCreated, ContainerRunning, ContainerPaused, ContainerExited, ContainerUnknown}
+
+// Summary returns table of nodes with number of their containers in each state, and cluster status.
+func (dcs *DefaultClusterService) Summary() string {
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
+	counts := make(map[UID]map[ContainerState]int, len(dcs.nodes))
+	for _, c := range dcs.containers {
+		if counts[c.NodeId] == nil {
+			counts[c.NodeId] = map[ContainerState]int{}
+		}
+		counts[c.NodeId][containerStateOf(c)]++
+	}
+
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprint(w, "NODE\tID\tSTATE\tCONTAINERS")
+	for _, state := range summaryStates {
+		fmt.Fprintf(w, "\t%v", strings.ToUpper(string(state)))
+	}
+	fmt.Fprintln(w)
+	for _, node := range dcs.nodes {
+		total := 0
+		for _, n := range counts[node.Id] {
+			total += n
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v", node.Name, node.Id, node.NodeState, total)
+		for _, state := range summaryStates {
+			fmt.Fprintf(w, "\t%v", counts[node.Id][state])
+		}
+		fmt.Fprintln(w)
+	}
+	w.Flush()
+	fmt.Fprintln(&b, dcs.status())
+	return b.String()
+}
@@ -0,0 +1,63 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestStatus_String(t *testing.T) {
+	tests := []struct {
+		have fmt.Stringer
+		want string
+	}{
+		{ContainerState(""), "unknown"},
+		{ContainerRunning, "running"},
+		{NodeState(""), "unknown"},
+		{ClusterDegraded, "degraded"},
+		{
+			ContainerStatus{Id: "id1", Name: "name1", NodeName: "node1", ContainerState: ContainerExited, Reason: "killed", Error: errors.New("code:1")},
+			"container name1(id1) on node1: exited, reason:killed, error:code:1",
+		},
+		{NodeStatus{Id: "id1", Name: "node1", NodeState: NodeRunning}, "node node1(id1): running"},
+		{ClusterStatus{ClusterState: ClusterDown, Reason: "no running node"}, "cluster: down, reason:no running node"},
+	}
+	for _, tt := range tests {
+		if have := tt.have.String(); have != tt.want {
+			t.Errorf("want:%v,have:%v", tt.want, have)
+		}
+	}
+}
+
+func TestDefaultClusterService_Summary(t *testing.T) {
+	clusterService, _ := newTestRestartService(t)
+	nodes, _ := clusterService.Nodes(true)
+	node := nodes[0]
+	containers := Containers{}
+	for i := 0; i < 3; i++ {
+		container, err := clusterService.CreateContainerWithSpec(ContainerSpec{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		containers = append(containers, container)
+	}
+	if err := clusterService.RunContainer(containers[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(clusterService.Summary()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("want:%v,have:%v", 3, lines)
+	}
+	if fields := strings.Fields(lines[0]); strings.Join(fields, " ") != "NODE ID STATE CONTAINERS CREATED RUNNING PAUSED EXITED UNKNOWN" {
+		t.Errorf("have:%v", lines[0])
+	}
+	want := strings.Join([]string{node.Name, string(node.Id), "running", "3", "2", "1", "0", "0", "0"}, " ")
+	if have := strings.Join(strings.Fields(lines[1]), " "); have != want {
+		t.Errorf("want:%v,have:%v", want, have)
+	}
+	if lines[2] != "cluster: running, reason:1 nodes running" {
+		t.Errorf("have:%v", lines[2])
+	}
+}
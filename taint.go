@@ -0,0 +1,52 @@
+package cluster
+
+// TaintEffect is how taint repels containers not tolerating it.
+type TaintEffect string
+
+const (
+	// scheduler does not place new containers on node, running ones stay
+	TaintNoSchedule TaintEffect = "NoSchedule"
+)
+
+// Taint on node repels containers not tolerating it.
+type Taint struct {
+	Key    string
+	Value  string
+	Effect TaintEffect
+}
+
+// Toleration allows container to be placed on node with matching taint.
+type Toleration struct {
+	Key string
+	// empty tolerates any value of key
+	Value string
+	// empty tolerates any effect
+	Effect TaintEffect
+}
+
+// Tolerates returns true if toleration matches taint.
+func (toleration Toleration) Tolerates(taint Taint) bool {
+	return toleration.Key == taint.Key &&
+		(toleration.Value == "" || toleration.Value == taint.Value) &&
+		(toleration.Effect == "" || toleration.Effect == taint.Effect)
+}
+
+// tolerates returns true if every taint blocking placement is tolerated by one of tolerations.
+func tolerates(tolerations []Toleration, taints []Taint) bool {
+	for _, taint := range taints {
+		if taint.Effect != TaintNoSchedule {
+			continue
+		}
+		tolerated := false
+		for _, toleration := range tolerations {
+			if toleration.Tolerates(taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,50 @@
+package cluster
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDefaultClusterService_CreateContainerWithSpec_Taints(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	// tainted node is the least loaded
+	tainted := prepareTestNode(t, clusterService, func(node *Node) {
+		node.Taints = []Taint{{Key: "dedicated", Value: "db", Effect: TaintNoSchedule}}
+		node.Capacity = Capacity{CPUShares: 2048}
+	})
+	plain := prepareTestNode(t, clusterService, func(node *Node) {
+		node.Capacity = Capacity{CPUShares: 1024}
+	})
+
+	tests := []struct {
+		name     string
+		spec     ContainerSpec
+		expected *Node
+	}{
+		{"untolerated", ContainerSpec{}, plain},
+		{"otherValue", ContainerSpec{Tolerations: []Toleration{{Key: "dedicated", Value: "web"}}}, plain},
+		{"tolerated", ContainerSpec{Tolerations: []Toleration{{Key: "dedicated", Value: "db", Effect: TaintNoSchedule}}}, tainted},
+		{"anyValue", ContainerSpec{Tolerations: []Toleration{{Key: "dedicated"}}}, tainted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := clusterService.DryRunCreateContainerWithSpec(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if node.Id != tt.expected.Id {
+				t.Errorf("want:%v,have:%v", tt.expected.Name, node.Name)
+			}
+		})
+	}
+
+	if _, err := clusterService.UpdateNodeIf(plain.Id, plain.ResourceVersion, func(node *Node) error {
+		node.Taints = []Taint{{Key: "maintenance", Effect: TaintNoSchedule}}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := clusterService.CreateContainerWithSpec(ContainerSpec{}); !errors.Is(err, ErrUnsatisfiedConstraints) {
+		t.Errorf("want:%v,have:%v", ErrUnsatisfiedConstraints, err)
+	}
+}
@@ -0,0 +1,126 @@
+package cluster
+
+import (
+	"fmt"
+	"time"
+)
+
+// containerTransitions is legal next states of each container state.
+// unknown may become any state since it is not observed yet.
+var containerTransitions = map[ContainerState][]ContainerState{
+	ContainerUnknown: {ContainerPending, ContainerCreated, ContainerRunning, ContainerExited},
+	ContainerPending: {ContainerCreated},
+	ContainerCreated: {ContainerCreated, ContainerRunning, ContainerExited},
+	ContainerRunning: {ContainerPaused, ContainerExited},
+	ContainerPaused:  {ContainerRunning, ContainerExited},
+	ContainerExited:  {ContainerCreated},
+}
+
+// checkContainerTransition returns error if container can not move from state to state.
+func checkContainerTransition(from ContainerState, to ContainerState) error {
+	if from == "" {
+		from = ContainerUnknown
+	}
+	for _, next := range containerTransitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w of container:%v->%v", ErrIllegalTransition, from, to)
+}
+
+// TransitionContainer move status to state if it is legal, and records time of the state and the transition in history.
+func TransitionContainer(status *ContainerStatus, to ContainerState) error {
+	return transitionContainer(status, to, "")
+}
+
+// transitionContainer is TransitionContainer setting reason to status and its history, reason is kept if empty.
+func transitionContainer(status *ContainerStatus, to ContainerState, reason string) error {
+	if err := checkContainerTransition(status.ContainerState, to); err != nil {
+		return err
+	}
+	now := time.Now()
+	switch to {
+	case ContainerCreated:
+		if status.CreatedAt.IsZero() {
+			status.CreatedAt = now
+		}
+		status.ExitCode = NoExitCode
+	case ContainerRunning:
+		// unpaused container keeps its start time
+		if status.ContainerState != ContainerPaused {
+			status.StartedAt = now
+		}
+		status.ExitCode = NoExitCode
+	case ContainerExited:
+		status.FinishedAt = now
+	}
+	status.recordHistory(StatusEvent{Time: now, From: status.ContainerState, To: to, Reason: reason})
+	status.ContainerState = to
+	if reason != "" {
+		status.Reason = reason
+	}
+	return nil
+}
+
+// nodeTransitions is legal next states of each node state.
+// exited node can not be run again, create new node instead.
+// unreachable node recovers to running, and draining node returns to running if drain is cancelled.
+var nodeTransitions = map[NodeState][]NodeState{
+	NodeUnknown:     {NodeCreated, NodeRunning},
+	NodeCreated:     {NodeRunning},
+	NodeRunning:     {NodeUnreachable, NodeDraining, NodeExited},
+	NodeUnreachable: {NodeRunning, NodeExited},
+	NodeDraining:    {NodeRunning, NodeExited},
+	NodeExited:      {},
+}
+
+// checkNodeTransition returns error if node can not move from state to state.
+func checkNodeTransition(from NodeState, to NodeState) error {
+	if from == "" {
+		from = NodeUnknown
+	}
+	for _, next := range nodeTransitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w of node:%v->%v", ErrIllegalTransition, from, to)
+}
+
+// transitionNode move node and its status to state if it is legal, status is synced to node before.
+func (dcs *DefaultClusterService) transitionNode(node *Node, to NodeState, reason string) error {
+	if err := checkNodeTransition(node.NodeState, to); err != nil {
+		return err
+	}
+	if ns := dcs.findNodeStatusById(node.Id); ns != nil {
+		ns.NodeState = node.NodeState
+		if err := TransitionNode(ns, to); err != nil {
+			return err
+		}
+		ns.Reason = reason
+	}
+	node.NodeState = to
+	dcs.bumpNode(node)
+	return nil
+}
+
+// TransitionNode move status to state if it is legal, and records time of the state.
+func TransitionNode(status *NodeStatus, to NodeState) error {
+	if err := checkNodeTransition(status.NodeState, to); err != nil {
+		return err
+	}
+	now := time.Now()
+	switch to {
+	case NodeCreated:
+		if status.CreatedAt.IsZero() {
+			status.CreatedAt = now
+		}
+	case NodeRunning:
+		status.StartedAt = now
+	case NodeExited:
+		status.FinishedAt = now
+	}
+	status.NodeState = to
+	return nil
+}
@@ -0,0 +1,155 @@
+package cluster
+
+import "testing"
+
+func TestTransitionContainer(t *testing.T) {
+	tests := []struct {
+		from    ContainerState
+		to      ContainerState
+		wantErr bool
+	}{
+		{ContainerUnknown, ContainerCreated, false},
+		{ContainerUnknown, ContainerRunning, false},
+		{ContainerCreated, ContainerCreated, false},
+		{ContainerCreated, ContainerRunning, false},
+		{ContainerCreated, ContainerExited, false},
+		{ContainerRunning, ContainerExited, false},
+		{ContainerExited, ContainerCreated, false},
+		{ContainerRunning, ContainerRunning, true},
+		{ContainerRunning, ContainerCreated, true},
+		{ContainerExited, ContainerRunning, true},
+		{ContainerExited, ContainerExited, true},
+		{ContainerCreated, ContainerUnknown, true},
+		{ContainerRunning, ContainerPaused, false},
+		{ContainerPaused, ContainerExited, false},
+		{ContainerCreated, ContainerPaused, true},
+		{ContainerExited, ContainerPaused, true},
+		{ContainerPaused, ContainerCreated, true},
+		{ContainerUnknown, ContainerPending, false},
+		{ContainerPending, ContainerCreated, false},
+		{ContainerPending, ContainerRunning, true},
+		{ContainerCreated, ContainerPending, true},
+	}
+	for _, tt := range tests {
+		status := NewContainerStatus("id1", "name1", "nodename1")
+		status.ContainerState = tt.from
+		err := TransitionContainer(status, tt.to)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v->%v: want error:%v,have:%v", tt.from, tt.to, tt.wantErr, err)
+			continue
+		}
+		if err != nil {
+			if status.ContainerState != tt.from {
+				t.Errorf("want:%v,have:%v", tt.from, status.ContainerState)
+			}
+			continue
+		}
+		if status.ContainerState != tt.to {
+			t.Errorf("want:%v,have:%v", tt.to, status.ContainerState)
+		}
+		if tt.to == ContainerRunning && status.StartedAt.IsZero() {
+			t.Errorf("not set StartedAt:%v", status)
+		}
+		if tt.to == ContainerExited && status.FinishedAt.IsZero() {
+			t.Errorf("not set FinishedAt:%v", status)
+		}
+	}
+}
+
+func TestNode_RunContainer_Exited(t *testing.T) {
+	client := &mockContainerClient{hash: "hash1"}
+	node := &Node{Id: "node1", Name: "nodename1", Client: client}
+	container := NewContainer("id1", "name1", "", "node1", "nodename1", testImage, "", nil)
+	container.ContainerStatus.ContainerState = ContainerExited
+	if err := node.RunContainer(container); err == nil {
+		t.Fatal("want error for exited container")
+	}
+	if len(client.runs) != 0 {
+		t.Errorf("%v", client.runs)
+	}
+}
+
+func TestTransitionNode(t *testing.T) {
+	tests := []struct {
+		from    NodeState
+		to      NodeState
+		wantErr bool
+	}{
+		{NodeUnknown, NodeCreated, false},
+		{NodeUnknown, NodeRunning, false},
+		{NodeCreated, NodeRunning, false},
+		{NodeRunning, NodeExited, false},
+		{NodeRunning, NodeUnreachable, false},
+		{NodeUnreachable, NodeRunning, false},
+		{NodeUnreachable, NodeExited, false},
+		{NodeRunning, NodeDraining, false},
+		{NodeDraining, NodeExited, false},
+		{NodeDraining, NodeRunning, false},
+		{NodeUnknown, NodeExited, true},
+		{NodeCreated, NodeUnreachable, true},
+		{NodeUnreachable, NodeDraining, true},
+		{NodeExited, NodeUnreachable, true},
+		{NodeCreated, NodeExited, true},
+		{NodeCreated, NodeCreated, true},
+		{NodeRunning, NodeRunning, true},
+		{NodeRunning, NodeCreated, true},
+		{NodeExited, NodeRunning, true},
+		{NodeExited, NodeCreated, true},
+		{NodeExited, NodeExited, true},
+	}
+	for _, tt := range tests {
+		status := &NodeStatus{Id: "node1", Name: "nodename1", NodeState: tt.from}
+		err := TransitionNode(status, tt.to)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v->%v: want error:%v,have:%v", tt.from, tt.to, tt.wantErr, err)
+			continue
+		}
+		if err != nil {
+			if status.NodeState != tt.from {
+				t.Errorf("want:%v,have:%v", tt.from, status.NodeState)
+			}
+			continue
+		}
+		if status.NodeState != tt.to {
+			t.Errorf("want:%v,have:%v", tt.to, status.NodeState)
+		}
+		if tt.to == NodeRunning && status.StartedAt.IsZero() {
+			t.Errorf("not set StartedAt:%v", status)
+		}
+		if tt.to == NodeExited && status.FinishedAt.IsZero() {
+			t.Errorf("not set FinishedAt:%v", status)
+		}
+	}
+}
+
+func TestDefaultClusterService_RunNode_Exited(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	node, _ := clusterService.CreateNode()
+	provider := &mockResourceProvider{}
+	node, err := clusterService.PrepareNode(node.Id, func(node *Node) error {
+		node.ResourceProvider = provider
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.KillNode(*node, 0); err == nil {
+		t.Error("want error for never ran node")
+	}
+	if err := clusterService.RunNode(node); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.KillNode(*node, 1000); err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.RunNode(node); err == nil {
+		t.Error("want error for exited node")
+	}
+	if len(provider.runs) != 1 {
+		t.Errorf("%v", provider.runs)
+	}
+	nodeStatus := clusterService.findNodeStatusById(node.Id)
+	if nodeStatus.NodeState != NodeExited || nodeStatus.StartedAt.IsZero() || nodeStatus.FinishedAt.IsZero() {
+		t.Errorf("%v", nodeStatus)
+	}
+}
@@ -0,0 +1,133 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// immutableFields are fields of spec given to runtime on create or used to place container,
+// so that changing them requires to recreate container.
+var immutableFields = []struct {
+	name  string
+	value func(spec ContainerSpec) any
+}{
+	{"Image", func(spec ContainerSpec) any { return spec.Image }},
+	{"Command", func(spec ContainerSpec) any { return spec.Command }},
+	{"Env", func(spec ContainerSpec) any { return spec.Env }},
+	{"EnvFrom", func(spec ContainerSpec) any { return spec.EnvFrom }},
+	{"Ports", func(spec ContainerSpec) any { return spec.Ports }},
+	{"Volumes", func(spec ContainerSpec) any { return spec.Volumes }},
+	{"WorkingDir", func(spec ContainerSpec) any { return spec.WorkingDir }},
+	{"Stdin", func(spec ContainerSpec) any { return spec.Stdin }},
+	{"ResourceRequests", func(spec ContainerSpec) any { return spec.ResourceRequests }},
+	{"NodeAffinity", func(spec ContainerSpec) any { return spec.NodeAffinity }},
+	{"AntiAffinity", func(spec ContainerSpec) any { return spec.AntiAffinity }},
+	{"Tolerations", func(spec ContainerSpec) any { return spec.Tolerations }},
+}
+
+// changedImmutableFields returns names of immutable fields differ between specs.
+func changedImmutableFields(current, spec ContainerSpec) []string {
+	changed := []string{}
+	for _, field := range immutableFields {
+		if !reflect.DeepEqual(field.value(current), field.value(spec)) {
+			changed = append(changed, field.name)
+		}
+	}
+	return changed
+}
+
+// UpdateContainer replace spec of container with newSpec without recreating it.
+// resource limits of alive container are changed by its runtime, and other mutable fields like restart policy
+// and probes take effect from the next check. It fails with ErrImmutableField naming fields of newSpec
+// which differ and require to recreate container, like image and command.
+func (dcs *DefaultClusterService) UpdateContainer(container *Container, newSpec ContainerSpec) error {
+	return dcs.UpdateContainerContext(context.Background(), container, newSpec)
+}
+
+// UpdateContainerContext is UpdateContainer which gives up when ctx is done.
+// runtime is called without lock, and ErrConflict is returned if container is changed meanwhile.
+func (dcs *DefaultClusterService) UpdateContainerContext(ctx context.Context, container *Container, newSpec ContainerSpec) error {
+	spec := newSpec.Clone()
+	dcs.mu.RLock()
+	owned := dcs.findContainerById(container.Id)
+	var resourceVersion uint64
+	if owned != nil {
+		resourceVersion = owned.ResourceVersion
+	}
+	dcs.mu.RUnlock()
+	if owned == nil {
+		return fmt.Errorf("%w for uid:%v", ErrContainerNotFound, container.Id)
+	}
+	_, err := dcs.updateContainerIf(ctx, container.Id, resourceVersion, func(c *Container) error {
+		c.Spec = spec
+		return nil
+	})
+	dcs.mu.RLock()
+	copyContainer(container, dcs.ownedContainer(container))
+	dcs.mu.RUnlock()
+	return err
+}
+
+// updateContainerIf apply update to copy of container if its resource version is still resourceVersion,
+// and check changed spec can be applied without recreating container. resource limits of alive container
+// are changed by its runtime without lock, then changes of Labels, Spec and ContainerOptions are kept
+// if container is not changed meanwhile. It returns updated container.
+func (dcs *DefaultClusterService) updateContainerIf(ctx context.Context, uid UID, resourceVersion uint64, update func(container *Container) error) (*Container, error) {
+	dcs.mu.RLock()
+	owned := dcs.findContainerById(uid)
+	current := owned.Clone()
+	var node *Node
+	if owned != nil {
+		node = dcs.findNodeById(owned.NodeId).Clone()
+	}
+	dcs.mu.RUnlock()
+	if owned == nil {
+		return nil, fmt.Errorf("%w for uid:%v", ErrContainerNotFound, uid)
+	}
+	if current.ResourceVersion != resourceVersion {
+		return nil, fmt.Errorf("%w for uid:%v, want:%v, have:%v", ErrConflict, uid, resourceVersion, current.ResourceVersion)
+	}
+	updated := current.Clone()
+	if err := update(updated); err != nil {
+		return nil, err
+	}
+	if err := checkUpdate(current, updated.Spec); err != nil {
+		return nil, err
+	}
+	state := containerStateOf(current)
+	if updated.Spec.ResourceLimits != current.Spec.ResourceLimits && (state == ContainerRunning || state == ContainerPaused) {
+		if node == nil {
+			return nil, fmt.Errorf("%w for uid:%v", ErrNodeNotFound, current.NodeId)
+		}
+		if node.Client == nil {
+			return nil, fmt.Errorf("%w:%v", ErrNodeHasNoClient, node.Name)
+		}
+		if err := node.Client.Update(ctx, current, updated.Spec.ResourceLimits); err != nil {
+			return nil, err
+		}
+	}
+
+	dcs.mu.Lock()
+	defer dcs.mu.Unlock()
+	if dcs.findContainerById(uid) != owned || owned.ResourceVersion != resourceVersion {
+		return nil, fmt.Errorf("%w for uid:%v, want:%v, have:%v", ErrConflict, uid, resourceVersion, owned.ResourceVersion)
+	}
+	owned.Labels = updated.Labels
+	owned.Spec = updated.Spec
+	owned.ContainerOptions = updated.ContainerOptions
+	dcs.bumpContainer(owned)
+	return owned.Clone(), nil
+}
+
+// checkUpdate returns error if spec of container can not be changed to spec without recreating container.
+func checkUpdate(container *Container, spec ContainerSpec) error {
+	if changed := changedImmutableFields(container.Spec, spec); len(changed) > 0 {
+		return fmt.Errorf("%w, recreate container:%v to change:%v", ErrImmutableField, container.Name, strings.Join(changed, ", "))
+	}
+	if !spec.ResourceLimits.Covers(spec.ResourceRequests) {
+		return fmt.Errorf("%w, requests:%+v, limits:%+v", ErrInvalidResources, spec.ResourceRequests, spec.ResourceLimits)
+	}
+	return nil
+}
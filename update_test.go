@@ -0,0 +1,127 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDefaultClusterService_UpdateContainer(t *testing.T) {
+	clusterService := newTestProbeService(t)
+	spec := ContainerSpec{Command: []string{"serve"}, ResourceLimits: Capacity{MemoryMB: 256}}
+	container, err := clusterService.CreateContainerWithSpec(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		spec    ContainerSpec
+		err     error
+		message string
+	}{
+		{"limits", ContainerSpec{Command: []string{"serve"}, ResourceLimits: Capacity{MemoryMB: 512}}, nil, ""},
+		{"restartPolicy", ContainerSpec{Command: []string{"serve"}, ResourceLimits: Capacity{MemoryMB: 512}, RestartPolicy: RestartPolicy{Name: RestartAlways}}, nil, ""},
+		{"immutable", ContainerSpec{Image: "nginx:latest", Command: []string{"run"}}, ErrImmutableField, "Image, Command"},
+		{"requests", ContainerSpec{Command: []string{"serve"}, ResourceRequests: Capacity{MemoryMB: 1024}, ResourceLimits: Capacity{MemoryMB: 512}}, ErrImmutableField, "ResourceRequests"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := container.Spec
+			err := clusterService.UpdateContainer(container, tt.spec)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("want:%v,have:%v", tt.err, err)
+			}
+			if err != nil {
+				if !strings.Contains(err.Error(), tt.message) {
+					t.Errorf("want:%v,have:%v", tt.message, err)
+				}
+				if container.Spec.ResourceLimits != before.ResourceLimits {
+					t.Errorf("want:%v,have:%v", before.ResourceLimits, container.Spec.ResourceLimits)
+				}
+				return
+			}
+			if container.Spec.RestartPolicy != tt.spec.RestartPolicy || container.Spec.ResourceLimits != tt.spec.ResourceLimits {
+				t.Errorf("want:%v,have:%v", tt.spec, container.Spec)
+			}
+		})
+	}
+	stats, err := clusterService.Stats(context.Background(), container)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.MemoryLimitBytes != 512<<20 {
+		t.Errorf("want:%v,have:%v", 512<<20, stats.MemoryLimitBytes)
+	}
+	if containerStateOf(container) != ContainerRunning {
+		t.Errorf("want:%v,have:%v", ContainerRunning, containerStateOf(container))
+	}
+}
+
+func TestDefaultClusterService_UpdateContainer_Unlocked(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	client := newBlockingContainerClient()
+	node := runTestNode(t, clusterService, client.FakeContainerClient)
+	container, _ := clusterService.CreateContainerWithSpec(ContainerSpec{ResourceLimits: Capacity{MemoryMB: 256}})
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	// runtime blocks after container runs
+	if err := clusterService.SetNodeClient(node.Id, client); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- clusterService.UpdateContainer(container, ContainerSpec{ResourceLimits: Capacity{MemoryMB: 512}})
+	}()
+	if called := <-client.called; called != "Update" {
+		t.Fatalf("want:%v,have:%v", "Update", called)
+	}
+	assertUnlocked(t, clusterService)
+	close(client.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if have, _ := clusterService.GetContainer(container.Id); have.Spec.ResourceLimits.MemoryMB != 512 {
+		t.Errorf("want:%v,have:%v", 512, have.Spec.ResourceLimits)
+	}
+}
+
+func TestDefaultClusterService_UpdateContainer_Conflict(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	client := newBlockingContainerClient()
+	node := runTestNode(t, clusterService, client.FakeContainerClient)
+	container, _ := clusterService.CreateContainerWithSpec(ContainerSpec{ResourceLimits: Capacity{MemoryMB: 256}})
+	if err := clusterService.RunContainer(container); err != nil {
+		t.Fatal(err)
+	}
+	// runtime blocks after container runs
+	if err := clusterService.SetNodeClient(node.Id, client); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- clusterService.UpdateContainer(container.Clone(), ContainerSpec{ResourceLimits: Capacity{MemoryMB: 512}})
+	}()
+	<-client.called
+	// container is changed while runtime is called
+	if _, err := clusterService.UpdateContainerIf(container.Id, container.ResourceVersion, func(c *Container) error {
+		c.Labels = map[string]string{"app": "web"}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	close(client.release)
+	if err := <-done; !errors.Is(err, ErrConflict) {
+		t.Errorf("want:%v,have:%v", ErrConflict, err)
+	}
+	if have, _ := clusterService.GetContainer(container.Id); have.Spec.ResourceLimits.MemoryMB != 256 || have.Labels["app"] != "web" {
+		t.Errorf("unexpected container:%v", have)
+	}
+}
@@ -0,0 +1,87 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// interval to check state while waiting, in case of events dropped or state changed without event.
+const waitPollInterval = time.Second
+
+// WaitForContainer blocks until container reaches state or ctx is done.
+// It returns ErrUnexpectedState if container exited while waiting other state.
+func (dcs *DefaultClusterService) WaitForContainer(ctx context.Context, uid UID, state ContainerState) error {
+	events, unwatch := dcs.Watch()
+	defer unwatch()
+	ticker := time.NewTicker(waitPollInterval)
+	defer ticker.Stop()
+	for {
+		current, err := dcs.containerState(uid)
+		if err != nil {
+			return err
+		}
+		if current == state {
+			return nil
+		}
+		if current == ContainerExited {
+			return fmt.Errorf("%w for uid:%v, want:%v, have:%v", ErrUnexpectedState, uid, state, current)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-events:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (dcs *DefaultClusterService) containerState(uid UID) (ContainerState, error) {
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
+	cs, err := dcs.containerStatus(uid, "", "")
+	if err != nil {
+		return "", err
+	}
+	return cs.ContainerState, nil
+}
+
+// WaitForNode blocks until node reaches state or ctx is done, flushing node statuses on each check.
+// It returns ErrUnexpectedState if node exited while waiting other state.
+func (dcs *DefaultClusterService) WaitForNode(ctx context.Context, uid UID, state NodeState) error {
+	events, unwatch := dcs.Watch()
+	defer unwatch()
+	ticker := time.NewTicker(waitPollInterval)
+	defer ticker.Stop()
+	for {
+		if err := dcs.FlushNodes(); err != nil {
+			return err
+		}
+		current, err := dcs.nodeState(uid)
+		if err != nil {
+			return err
+		}
+		if current == state {
+			return nil
+		}
+		if current == NodeExited {
+			return fmt.Errorf("%w for uid:%v, want:%v, have:%v", ErrUnexpectedState, uid, state, current)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-events:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (dcs *DefaultClusterService) nodeState(uid UID) (NodeState, error) {
+	dcs.mu.RLock()
+	defer dcs.mu.RUnlock()
+	node := dcs.findNodeById(uid)
+	if node == nil {
+		return "", fmt.Errorf("%w for uid:%v", ErrNodeNotFound, uid)
+	}
+	return node.NodeState, nil
+}
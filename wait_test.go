@@ -0,0 +1,96 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDefaultClusterService_WaitForContainer(t *testing.T) {
+	clusterService, _ := newTestRestartService(t)
+	container, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		clusterService.RunContainer(container)
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := clusterService.WaitForContainer(ctx, container.Id, ContainerRunning); err != nil {
+		t.Fatal(err)
+	}
+
+	created, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	exited, _ := clusterService.CreateContainerWithSpec(ContainerSpec{})
+	clusterService.RunContainer(exited)
+	clusterService.KillContainer(exited, DefaultStopGracePeriod)
+	tests := []struct {
+		name    string
+		uid     UID
+		state   ContainerState
+		timeout time.Duration
+		want    error
+	}{
+		{"alreadyRunning", container.Id, ContainerRunning, time.Second, nil},
+		{"exited", exited.Id, ContainerRunning, time.Second, ErrUnexpectedState},
+		{"timeout", created.Id, ContainerRunning, 10 * time.Millisecond, context.DeadlineExceeded},
+		{"notFound", "unknown", ContainerRunning, time.Second, ErrContainerNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+			if err := clusterService.WaitForContainer(ctx, tt.uid, tt.state); !errors.Is(err, tt.want) {
+				t.Errorf("want:%v,have:%v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestDefaultClusterService_WaitForNode(t *testing.T) {
+	clusterService := NewDefaultClusterService("0.0.0", testImage)
+	node, _ := clusterService.CreateNode()
+	node, err := clusterService.PrepareNode(node.Id, func(node *Node) error {
+		node.ResourceProvider = NewFakeResourceProvider(ResourceInfo{})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		clusterService.RunNode(node)
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := clusterService.WaitForNode(ctx, node.Id, NodeRunning); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := clusterService.NodeStatus(node.Id, ""); status.NodeState != NodeRunning {
+		t.Errorf("want:%v,have:%v", NodeRunning, status.NodeState)
+	}
+
+	created, _ := clusterService.CreateNode()
+	exited := runTestNode(t, clusterService, nil)
+	clusterService.KillNode(*exited, 1000)
+	tests := []struct {
+		name    string
+		uid     UID
+		timeout time.Duration
+		want    error
+	}{
+		{"alreadyRunning", node.Id, time.Second, nil},
+		{"exited", exited.Id, time.Second, ErrUnexpectedState},
+		{"timeout", created.Id, 10 * time.Millisecond, context.DeadlineExceeded},
+		{"notFound", "unknown", time.Second, ErrNodeNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+			if err := clusterService.WaitForNode(ctx, tt.uid, NodeRunning); !errors.Is(err, tt.want) {
+				t.Errorf("want:%v,have:%v", tt.want, err)
+			}
+		})
+	}
+}